@@ -1,6 +1,9 @@
 package main
 
 import (
+	api_a "cmd/internal/annotations/api_a"
+	"cmd/internal/annotations/repo_a"
+	"cmd/internal/annotations/service_a"
 	api_c "cmd/internal/categories/api_c"
 	"cmd/internal/categories/repo_c"
 	"cmd/internal/categories/service_c"
@@ -12,6 +15,8 @@ import (
 
 	"cmd/internal/config"
 
+	"cmd/internal/eventbus"
+
 	api_docs "cmd/internal/documents/api"
 	repoDoc "cmd/internal/documents/repo"
 	"cmd/internal/documents/service"
@@ -22,6 +27,18 @@ import (
 
 	"cmd/internal/logger"
 
+	"cmd/internal/mail"
+
+	api_n "cmd/internal/notifications/api_n"
+	"cmd/internal/notifications/repo_n"
+	"cmd/internal/notifications/service_n"
+
+	api_re "cmd/internal/reactions/api_re"
+	"cmd/internal/reactions/repo_re"
+	"cmd/internal/reactions/service_re"
+
+	"cmd/internal/realtime"
+
 	api_r "cmd/internal/roles/api_r"
 	"cmd/internal/roles/repo_r"
 	"cmd/internal/roles/service_r"
@@ -40,6 +57,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -75,14 +93,15 @@ func main() {
 
 	// === 3. Подключение к БД ===
 	log.Info("Подключение к базе данных...")
-	db, err := storage.New(storage.Config{
+	dbConfig := storage.Config{
 		Host:     cfg.Data.PostgreSQl.Host,
 		Port:     cfg.Data.PostgreSQl.Port,
 		UserName: cfg.Data.PostgreSQl.UserName,
 		Password: cfg.Data.PostgreSQl.Password,
 		DBName:   cfg.Data.PostgreSQl.Database,
 		SSLMode:  cfg.Data.PostgreSQl.SSLMode,
-	})
+	}
+	db, err := storage.New(dbConfig)
 	if err != nil {
 		log.Error("Ошибка подключения к БД", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -98,6 +117,24 @@ func main() {
 	}
 	log.Info("Соединение с базой данных успешно установлено")
 
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+
+	// Шина событий между инстансами: потоки SSE и инвалидация кэшей
+	var eventBus eventbus.Bus
+	switch cfg.Events.Bus {
+	case "memory":
+		eventBus = eventbus.NewMemoryBus()
+	case "", "postgres":
+		pgBus := eventbus.NewPostgresBus(db.DB, dbConfig.DSN(), cfg.Events.Channel)
+		go pgBus.Run(appCtx)
+		eventBus = pgBus
+	default:
+		log.Error("Неизвестная шина событий", slog.String("bus", cfg.Events.Bus))
+		os.Exit(1)
+	}
+	log.Info("Шина событий", slog.String("bus", cfg.Events.Bus))
+
 	// === 4. Инициализация S3 ===
 	log.Info("Инициализация S3 хранилища...")
 	s3Storage, err := storage.NewS3Storage(storage.S3Config{
@@ -115,70 +152,238 @@ func main() {
 	log.Info("S3 успешно инициализирован")
 
 	// === 5. Сервисы документов и зависимостей ===
+	mailTemplates, err := mail.NewRenderer(cfg.Email.TemplatesDir, cfg.Email.DefaultLanguage)
+	if err != nil {
+		log.Error("Ошибка загрузки шаблонов писем", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// События лайков, просмотров, комментариев и документов уходят в открытые потоки SSE;
+	// видимость документа для роли подписчика проверяется по document_roles
 	repoStorage := &repoDoc.Storage{DB: db.DB}
-	serviceLayer := service.NewService(repoStorage, s3Storage)
+	eventHub := realtime.NewHub(repoStorage, eventBus)
+
+	// Адрес фронтенда для ссылок в письмах: приглашения, подтверждения и уведомления
+	portalURL := strings.TrimRight(cfg.Server.PublicURL, "/")
+	if portalURL == "" {
+		portalURL = "http://" + cfg.Server.Address
+		log.Warn("http_server.public_url не задан, ссылки в письмах ведут на адрес API", slog.String("url", portalURL))
+	}
+
+	// Уведомления создаются документами, комментариями и сменой роли; письма уходят через email_outbox
+	notificationStorage := repo_n.NewNotificationStorage(db.DB)
+	notificationService := service_n.NewNotificationService(notificationStorage, mailTemplates, portalURL, eventHub)
+	notificationHandler := api_n.NewHandler(notificationService)
+
+	// Реакции на документы и комментарии
+	reactionStorage := repo_re.NewReactionStorage(db.DB)
+	reactionService := service_re.NewReactionService(reactionStorage, eventHub, cfg.Reactions.Allowed)
+	reactionHandler := api_re.NewHandler(reactionService)
+
+	serviceLayer := service.NewService(repoStorage, s3Storage, notificationService, eventHub, reactionService, service.WorkflowConfig{
+		Enabled:       cfg.Approval.Enabled,
+		ApproverRoles: cfg.Approval.ApproverRoles,
+		ApproverUsers: cfg.Approval.ApproverUsers,
+	}, service.TrashConfig{
+		Retention:     time.Duration(cfg.Documents.TrashRetentionDays) * 24 * time.Hour,
+		PurgeInterval: cfg.Documents.PurgeInterval,
+		PurgeDryRun:   cfg.Documents.PurgeDryRun,
+	})
 	docHandler := api_docs.NewHandler(serviceLayer, s3Storage)
+	// Отложенная публикация и архивация истёкших документов
+	go serviceLayer.RunScheduler(appCtx, cfg.Documents.SchedulerInterval)
+	// Окончательное удаление документов из корзины вместе с файлами в S3
+	go serviceLayer.RunPurgeJob(appCtx)
 
 	categoryStorage := &repo_c.CategoryStorage{DB: db.DB}
 	categoryService := service_c.NewCategoryService(categoryStorage)
 	categoryHandler := api_c.NewHandler(categoryService)
 
 	likeStorage := repo_l.NewLikeStorage(db.DB)
-	likeService := service_l.NewLikeService(likeStorage)
+	likeService := service_l.NewLikeService(likeStorage, eventHub)
 	likeHandler := api_l.NewHandler(likeService)
 
 	viewStorage := repo_v.NewViewStorage(db.DB)
-	viewService := service_v.NewViewService(viewStorage, s3Storage)
+	viewService := service_v.NewViewService(viewStorage, s3Storage, eventHub)
 	viewHandler := api_v.NewHandler(viewService)
 
-	roleStorage := repo_r.NewRoleStorage(db.DB)
-	roleService := service_r.NewRoleService(roleStorage)
-	roleHandler := api_r.NewRoleHandler(roleService)
-
 	commentStorage := repo_k.NewCommentStorage(db.DB)
-	commentService := service_k.NewCommentService(commentStorage, s3Storage)
+	commentService := service_k.NewCommentService(commentStorage, s3Storage, notificationService, eventHub, reactionService, service_k.Config{
+		EditWindow:  cfg.Comments.EditWindow,
+		BannedWords: cfg.Comments.BannedWords,
+	})
 	commentHandler := api_k.NewHandler(commentService)
 
+	// Аннотации PDF к версиям документов; запрет комментировать и фильтр слов общие с комментариями
+	annotationStorage := repo_a.NewAnnotationStorage(db.DB)
+	annotationService := service_a.NewAnnotationService(annotationStorage, eventHub, commentService)
+	annotationHandler := api_a.NewHandler(annotationService)
+
 	// === 6. Email-сервис ===
 	log.Info("Инициализация email сервиса...")
-	emailService := servUser.NewSMTPEmailService(servUser.EmailConfig{
-		SMTPHost:     cfg.Email.SMTPHost,
-		SMTPPort:     cfg.Email.SMTPPort,
-		SMTPUsername: cfg.Email.SMTPUsername,
-		SMTPPassword: cfg.Email.SMTPPassword,
-		FromEmail:    cfg.Email.FromEmail,
-		FromName:     cfg.Email.FromName,
-	})
+	emailTransport := mail.TransportConfig{
+		Kind:      cfg.Email.Transport,
+		FromEmail: cfg.Email.FromEmail,
+		FromName:  cfg.Email.FromName,
+		SMTP: mail.SMTPConfig{
+			Host:               cfg.Email.SMTPHost,
+			Port:               cfg.Email.SMTPPort,
+			Username:           cfg.Email.SMTPUsername,
+			Password:           cfg.Email.SMTPPassword,
+			TLS:                cfg.Email.SMTPTLS,
+			InsecureSkipVerify: cfg.Email.SMTPInsecureSkipVerify,
+		},
+		Dir:     cfg.Email.FileDir,
+		HTTPURL: cfg.Email.HTTPURL,
+		HTTPKey: cfg.Email.HTTPAPIKey,
+	}
+	emailService, err := mail.NewTransport(emailTransport)
+	if err != nil {
+		log.Error("Ошибка инициализации email транспорта", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	log.Info("Email транспорт", slog.String("transport", cfg.Email.Transport), slog.String("provider", emailTransport.ProviderName()))
 
-	// === 7. Пользователи ===
+	// === 7. JWT сервис ===
 	userStorage := repoUser.NewWithStorage(db)
+
+	accessTTL := cfg.Auth.AccessTTL
+	if accessTTL == 0 {
+		accessTTL = 15 * time.Minute
+	}
+	refreshTTL := cfg.Auth.RefreshTTL
+	if refreshTTL == 0 {
+		refreshTTL = 7 * 24 * time.Hour
+	}
+	keyGrace := cfg.Auth.KeyGracePeriod
+	if keyGrace < refreshTTL {
+		// Иначе refresh токены, подписанные выведенным ключом, истекут раньше срока
+		keyGrace = refreshTTL + accessTTL
+	}
+
+	keyManager := servUser.NewKeyManager(userStorage, servUser.KeyManagerConfig{
+		Algorithm:        cfg.Auth.SigningAlgorithm,
+		RotationInterval: cfg.Auth.KeyRotationInterval,
+		GracePeriod:      keyGrace,
+		EncryptionSecret: cfg.Auth.SigningKeySecret,
+		Events:           eventBus,
+	})
+	keysCtx, keysCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := keyManager.Init(keysCtx); err != nil {
+		keysCancel()
+		log.Error("Ошибка инициализации ключей подписи JWT", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	keysCancel()
+
+	go keyManager.Run(appCtx, time.Hour)
+
+	// HS256-токены прежней схемы принимаются только выпущенные до legacy_until и не дольше refresh_ttl после него
+	var legacyUntil time.Time
+	if cfg.Auth.JWTSecret != "" {
+		if cfg.Auth.LegacyUntil == "" {
+			log.Error("jwt_secret задан без legacy_until: укажите момент перехода на ключи с kid или очистите jwt_secret")
+			os.Exit(1)
+		}
+		legacyUntil, err = time.Parse(time.RFC3339, cfg.Auth.LegacyUntil)
+		if err != nil {
+			log.Error("Неверный legacy_until, нужен RFC 3339", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+	jwtService := servUser.NewJWTService(keyManager, cfg.Auth.JWTSecret, legacyUntil, accessTTL, refreshTTL)
+
+	// === 8. Пользователи ===
+	var sso *servUser.SSOConfig
+	if cfg.OIDC.Enabled {
+		sso = &servUser.SSOConfig{
+			Provider: servUser.NewOIDCProvider(servUser.OIDCConfig{
+				Issuer:       cfg.OIDC.Issuer,
+				ClientID:     cfg.OIDC.ClientID,
+				ClientSecret: cfg.OIDC.ClientSecret,
+				RedirectURL:  cfg.OIDC.RedirectURL,
+				Scopes:       cfg.OIDC.Scopes,
+			}),
+			GroupsClaim:   cfg.OIDC.GroupsClaim,
+			GroupRoles:    cfg.OIDC.GroupRoles,
+			DefaultRoleID: cfg.OIDC.DefaultRoleID,
+		}
+		log.Info("Вход через SSO включён", slog.String("issuer", cfg.OIDC.Issuer))
+	}
+
+	var authenticators []servUser.ExternalAuthenticator
+	var directory servUser.DirectorySource
+	if cfg.LDAP.Enabled {
+		ldapProvider := servUser.NewLDAPProvider(servUser.LDAPConfig{
+			URL:                cfg.LDAP.URL,
+			StartTLS:           cfg.LDAP.StartTLS,
+			InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+			BindDN:             cfg.LDAP.BindDN,
+			BindPassword:       cfg.LDAP.BindPassword,
+			BaseDN:             cfg.LDAP.BaseDN,
+			UserFilter:         cfg.LDAP.UserFilter,
+			SyncFilter:         cfg.LDAP.SyncFilter,
+			SubjectAttr:        cfg.LDAP.SubjectAttr,
+			GroupRoles:         cfg.LDAP.GroupRoles,
+		})
+		authenticators = append(authenticators, ldapProvider)
+		directory = ldapProvider
+		log.Info("Вход через LDAP включён", slog.String("url", cfg.LDAP.URL))
+	}
+
 	userService := servUser.New(userStorage, servUser.Config{
-		JWTSecret:    cfg.Auth.JWTSecret,
-		AppBaseURL:   cfg.Server.Address,
-		EmailService: emailService,
+		JWT:             jwtService,
+		AppBaseURL:      portalURL,
+		EmailService:    emailService,
+		SSO:             sso,
+		Authenticators:  authenticators,
+		Directory:       directory,
+		InviteOnly:      cfg.Auth.InviteOnly,
+		InviteTTL:       cfg.Auth.InviteTTL,
+		TokenVersionTTL: cfg.Auth.TokenVersionTTL,
+		Templates:       mailTemplates,
+		Events:          eventBus,
 	}, s3Storage)
+	// Письма отправляются из очереди email_outbox фоновым процессом с повторами
+	outboxSender := servUser.NewOutboxSender(userStorage, emailService, servUser.OutboxConfig{
+		Provider:    emailTransport.ProviderName(),
+		RateLimits:  cfg.Email.RateLimits,
+		MaxAttempts: cfg.Email.MaxAttempts,
+	})
+	go outboxSender.Run(appCtx)
+	if directory != nil && cfg.LDAP.SyncInterval > 0 {
+		go servUser.RunDirectorySyncJob(appCtx, userService, cfg.LDAP.SyncInterval, cfg.LDAP.SyncDryRun)
+	}
 	userHandler := APIUser.New(APIUser.HandlerConfig{
-		Service: userService,
+		Service:        userService,
+		JWT:            jwtService,
+		SSOFrontendURL: cfg.OIDC.FrontendRedirectURL,
 	})
 	log.Info("Сервис и хендлер пользователей инициализированы")
 
-	// === 8. JWT сервис ===
-	jwtService := servUser.NewJWTService(cfg.Auth.JWTSecret, 15*time.Minute, 7*24*time.Hour)
+	// Роли зависят от сервиса пользователей: смена роли отзывает выданные access токены
+	roleStorage := repo_r.NewRoleStorage(db.DB)
+	roleService := service_r.NewRoleService(roleStorage, userService, notificationService, eventHub)
+	roleHandler := api_r.NewRoleHandler(roleService)
+
+	// Поток событий проверяет отзыв сессии тем же сервисом пользователей
+	streamHandler := realtime.NewStreamHandler(eventHub, userService, cfg.Server.StreamHeartbeat)
 
 	// === 9. Роутер ===
 	router := chi.NewRouter()
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Last-Event-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 	router.Use(middleware.RequestID)
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(cfg.Server.Timeout))
+	// Потоки SSE живут дольше таймаута запроса
+	router.Use(realtime.WithoutStreams(middleware.Timeout(cfg.Server.Timeout)))
 	router.Use(func(next http.Handler) http.Handler {
 		return logger.RequestL(log, next)
 	})
@@ -198,6 +403,10 @@ func main() {
 		viewHandler,
 		roleHandler,
 		commentHandler,
+		reactionHandler,
+		annotationHandler,
+		notificationHandler,
+		streamHandler,
 		jwtService,
 		userService,
 	)
@@ -19,3 +19,9 @@ func IsAccessible(docRoleID, roleID int) bool {
 	}
 	return false
 }
+
+// CanSeeDocument — правило document_roles, как в SQL списка документов: администратор и HR видят всё,
+// документ с ролью 5 виден всем, иначе только своей роли
+func CanSeeDocument(roleID, docRoleID int) bool {
+	return roleID == 1 || roleID == 2 || docRoleID == 5 || docRoleID == roleID
+}
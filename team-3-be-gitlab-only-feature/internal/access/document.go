@@ -0,0 +1,134 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrDocumentNotFound = errors.New("document not found")
+
+// DocumentState — всё, от чего зависит видимость документа: роль, автор, статус согласования,
+// расписание публикации и согласующие. Передаётся в событиях между инстансами, поэтому с json-тегами.
+type DocumentState struct {
+	RoleID        int        `json:"role_id"` // 5 — всем
+	AuthorID      int        `json:"author_id,omitempty"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Deleted       bool       `json:"deleted,omitempty"`
+	ApproverUsers []int      `json:"approver_users,omitempty"`
+	ApproverRoles []int      `json:"approver_roles,omitempty"`
+}
+
+// Published — документ согласован и время публикации наступило
+func (d DocumentState) Published(now time.Time) bool {
+	return d.Status == "approved" && (d.PublishAt == nil || !d.PublishAt.After(now))
+}
+
+// Expired — срок действия истёк, документ в архиве
+func (d DocumentState) Expired(now time.Time) bool {
+	return d.ExpiresAt != nil && !d.ExpiresAt.After(now)
+}
+
+func (d DocumentState) isApprover(roleID, userID int) bool {
+	for _, id := range d.ApproverUsers {
+		if id == userID {
+			return true
+		}
+	}
+	for _, id := range d.ApproverRoles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleTo — правило роли и публикации: неопубликованный документ (черновик, на согласовании,
+// отклонённый, с будущей датой публикации) видят только автор, согласующие, администратор и HR.
+// Удаление и архив не учитываются — события о них должны дойти до тех, кто документ видел.
+func (d DocumentState) VisibleTo(roleID, userID int, now time.Time) bool {
+	if !CanSeeDocument(roleID, d.RoleID) {
+		return false
+	}
+	if d.Published(now) || roleID == 1 || roleID == 2 {
+		return true
+	}
+	if userID != 0 && d.AuthorID == userID {
+		return true
+	}
+	return d.isApprover(roleID, userID)
+}
+
+// OpenTo — документ доступен для чтения и действий: виден, не удалён, а архив открыт только администратору.
+// То же правило, что VisibleSQL в запросе документа.
+func (d DocumentState) OpenTo(roleID, userID int, now time.Time) bool {
+	if d.Deleted || (d.Expired(now) && roleID != 1) {
+		return false
+	}
+	return d.VisibleTo(roleID, userID, now)
+}
+
+// VisibleSQL — условие OpenTo для запросов к documents (без проверки deleted_at): doc — псевдоним
+// таблицы, role и user — параметры запроса с ролью и ID пользователя
+func VisibleSQL(doc, role, user string) string {
+	return fmt.Sprintf(`(
+            (%[2]s = 1 OR %[2]s = 2
+             OR NOT EXISTS (SELECT 1 FROM document_roles vr WHERE vr.document_id = %[1]s.id)
+             OR EXISTS (SELECT 1 FROM document_roles vr WHERE vr.document_id = %[1]s.id AND vr.role_id = %[2]s))
+            -- архив (истёкшие документы) виден только администратору
+            AND (%[1]s.expires_at IS NULL OR %[1]s.expires_at > NOW() OR %[2]s = 1)
+            AND (
+              (%[1]s.status = 'approved' AND (%[1]s.publish_at IS NULL OR %[1]s.publish_at <= NOW()))
+              OR %[1]s.user_id = %[3]s OR %[2]s = 1 OR %[2]s = 2
+              OR EXISTS (SELECT 1 FROM document_approvers da
+                         WHERE da.document_id = %[1]s.id AND (da.user_id = %[3]s OR da.role_id = %[2]s))
+            )
+          )`, doc, role, user)
+}
+
+// LoadDocument читает состояние документа, включая удалённые. Согласующие нужны только
+// неопубликованному документу, для опубликованного они не запрашиваются.
+func LoadDocument(ctx context.Context, db *sql.DB, docID int) (DocumentState, error) {
+	var d DocumentState
+	var authorID sql.NullInt64
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT role_id FROM document_roles WHERE document_id = d.id LIMIT 1), 5),
+		       d.user_id, d.status, d.publish_at, d.expires_at, d.deleted_at IS NOT NULL
+		FROM documents d
+		WHERE d.id = $1`, docID,
+	).Scan(&d.RoleID, &authorID, &d.Status, &d.PublishAt, &d.ExpiresAt, &d.Deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, ErrDocumentNotFound
+	}
+	if err != nil {
+		return d, err
+	}
+	d.AuthorID = int(authorID.Int64)
+
+	if d.Published(time.Now()) {
+		return d, nil
+	}
+	rows, err := db.QueryContext(ctx,
+		`SELECT user_id, role_id FROM document_approvers WHERE document_id = $1`, docID)
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, roleID sql.NullInt64
+		if err := rows.Scan(&userID, &roleID); err != nil {
+			return d, err
+		}
+		if userID.Valid {
+			d.ApproverUsers = append(d.ApproverUsers, int(userID.Int64))
+		}
+		if roleID.Valid {
+			d.ApproverRoles = append(d.ApproverRoles, int(roleID.Int64))
+		}
+	}
+	return d, rows.Err()
+}
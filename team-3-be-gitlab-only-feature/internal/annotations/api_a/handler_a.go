@@ -0,0 +1,172 @@
+package api
+
+import (
+	"cmd/internal/annotations/models_a"
+	"cmd/internal/annotations/repo_a"
+	"cmd/internal/annotations/service_a"
+	custommiddleware "cmd/internal/custom_middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AnnotationHandler struct {
+	service *service_a.AnnotationService
+}
+
+func NewHandler(s *service_a.AnnotationService) *AnnotationHandler {
+	return &AnnotationHandler{service: s}
+}
+
+type annotationInput struct {
+	Page     int               `json:"page"`
+	Selector models_a.Selector `json:"selector"`
+	Color    string            `json:"color"`
+	Text     string            `json:"text"`
+}
+
+// List — аннотации документа; фильтры ?version= (по умолчанию текущая), ?page=, ?status=open|resolved
+func (h *AnnotationHandler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	q := r.URL.Query()
+	var f models_a.Filter
+	f.Version, _ = strconv.Atoi(q.Get("version"))
+	f.Page, _ = strconv.Atoi(q.Get("page"))
+	f.Status = q.Get("status")
+
+	annotations, err := h.service.List(r.Context(), docID, user.ID, user.RoleID, f)
+	if err != nil {
+		writeError(w, err, "Ошибка получения аннотаций")
+		return
+	}
+	respondJSON(w, http.StatusOK, annotations)
+}
+
+// Create — аннотация к текущей версии документа
+func (h *AnnotationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	var in annotationInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	a := &models_a.Annotation{Page: in.Page, Selector: in.Selector, Color: in.Color, Text: in.Text}
+	if err := h.service.Create(r.Context(), docID, user.ID, user.RoleID, a); err != nil {
+		writeError(w, err, "Ошибка создания аннотации")
+		return
+	}
+	respondJSON(w, http.StatusCreated, a)
+}
+
+// Update — изменить страницу, место, цвет и текст аннотации
+func (h *AnnotationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+	docID, id := annotationIDs(r)
+
+	var in annotationInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.service.Update(r.Context(), docID, id, user.ID, user.RoleID,
+		models_a.Annotation{Page: in.Page, Selector: in.Selector, Color: in.Color, Text: in.Text})
+	if err != nil {
+		writeError(w, err, "Ошибка изменения аннотации")
+		return
+	}
+	respondJSON(w, http.StatusOK, a)
+}
+
+// Resolve — отметить замечание решённым
+func (h *AnnotationHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	h.setStatus(w, r, models_a.StatusResolved)
+}
+
+// Reopen — вернуть замечание в работу
+func (h *AnnotationHandler) Reopen(w http.ResponseWriter, r *http.Request) {
+	h.setStatus(w, r, models_a.StatusOpen)
+}
+
+func (h *AnnotationHandler) setStatus(w http.ResponseWriter, r *http.Request, status string) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+	docID, id := annotationIDs(r)
+
+	a, err := h.service.SetStatus(r.Context(), docID, id, user.ID, user.RoleID, status)
+	if err != nil {
+		writeError(w, err, "Ошибка смены статуса аннотации")
+		return
+	}
+	respondJSON(w, http.StatusOK, a)
+}
+
+func (h *AnnotationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+	docID, id := annotationIDs(r)
+
+	if err := h.service.Delete(r.Context(), docID, id, user.ID, user.RoleID); err != nil {
+		writeError(w, err, "Ошибка удаления аннотации")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func annotationIDs(r *http.Request) (docID, id int) {
+	docID, _ = strconv.Atoi(chi.URLParam(r, "id"))
+	id, _ = strconv.Atoi(chi.URLParam(r, "annotation_id"))
+	return docID, id
+}
+
+// writeError переводит ошибки сервиса в ответ; fallback — текст для внутренней ошибки
+func writeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repo_a.ErrBadData):
+		http.Error(w, "Неверные данные аннотации: страница, место на странице, цвет #RRGGBB или текст длиннее 2000 символов", http.StatusBadRequest)
+	case errors.Is(err, repo_a.ErrDocNotFound):
+		http.Error(w, "Документ не найден", http.StatusNotFound)
+	case errors.Is(err, repo_a.ErrNotFound):
+		http.Error(w, "Аннотация не найдена", http.StatusNotFound)
+	case errors.Is(err, repo_a.ErrForbidden):
+		http.Error(w, "Нет прав", http.StatusForbidden)
+	case errors.Is(err, repo_a.ErrBannedWords):
+		http.Error(w, "Аннотация содержит недопустимые слова", http.StatusBadRequest)
+	case errors.Is(err, repo_a.ErrMuted):
+		http.Error(w, "Вам запрещено оставлять комментарии и аннотации", http.StatusForbidden)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func respondJSON(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
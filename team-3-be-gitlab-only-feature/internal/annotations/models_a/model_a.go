@@ -0,0 +1,64 @@
+package models_a
+
+import "time"
+
+// Типы selector
+const (
+	SelectorRect = "rect" // прямоугольник в долях ширины и высоты страницы (0..1)
+	SelectorText = "text" // цитата из текстового слоя PDF с контекстом до и после
+)
+
+// Статусы ревью
+const (
+	StatusOpen     = "open"
+	StatusResolved = "resolved"
+)
+
+// Selector — место на странице; заполнены поля своего типа
+type Selector struct {
+	Type   string   `json:"type"`
+	X      *float64 `json:"x,omitempty"`
+	Y      *float64 `json:"y,omitempty"`
+	Width  *float64 `json:"width,omitempty"`
+	Height *float64 `json:"height,omitempty"`
+	Exact  string   `json:"exact,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+	Suffix string   `json:"suffix,omitempty"`
+}
+
+type Annotation struct {
+	ID              int        `json:"id"`
+	DocumentID      int        `json:"document_id"`
+	DocumentVersion int        `json:"document_version"`
+	UserID          int        `json:"user_id"`
+	FirstName       string     `json:"first_name,omitempty"`
+	LastName        string     `json:"last_name,omitempty"`
+	Page            int        `json:"page"`
+	Selector        Selector   `json:"selector"`
+	Color           string     `json:"color"`
+	Text            string     `json:"text"`
+	Status          string     `json:"status"`
+	ResolvedBy      *int       `json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Event — данные события аннотации в потоке SSE: только идентификаторы и метаданные, без текста
+// и цитаты (NOTIFY ограничен ~8000 байт); клиент перечитывает аннотации страницы через список
+type Event struct {
+	ID              int       `json:"annotation_id"`
+	DocumentID      int       `json:"document_id"`
+	DocumentVersion int       `json:"document_version"`
+	UserID          int       `json:"user_id"`
+	Page            int       `json:"page"`
+	Status          string    `json:"status"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Filter — отбор аннотаций документа; нулевые поля не ограничивают
+type Filter struct {
+	Version int // 0 — текущая версия документа
+	Page    int
+	Status  string
+}
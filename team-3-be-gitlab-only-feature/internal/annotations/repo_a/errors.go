@@ -0,0 +1,13 @@
+package repo_a
+
+import "errors"
+
+var (
+	ErrDBFailure   = errors.New("database error")
+	ErrNotFound    = errors.New("annotation not found")
+	ErrForbidden   = errors.New("forbidden")
+	ErrBadData     = errors.New("invalid annotation data")
+	ErrDocNotFound = errors.New("document not found")
+	ErrMuted       = errors.New("user is muted")
+	ErrBannedWords = errors.New("annotation contains banned words")
+)
@@ -0,0 +1,191 @@
+package repo_a
+
+import (
+	"cmd/internal/access"
+	"cmd/internal/annotations/models_a"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type AnnotationStorage struct {
+	DB *sql.DB
+}
+
+func NewAnnotationStorage(db *sql.DB) *AnnotationStorage {
+	return &AnnotationStorage{DB: db}
+}
+
+const selectAnnotations = `
+	SELECT a.id, a.document_id, a.document_version, COALESCE(a.user_id, 0),
+	       COALESCE(u.first_name, 'Удалённый пользователь'), COALESCE(u.last_name, ''),
+	       a.page, a.selector, a.color, a.text, a.status, a.resolved_by, a.resolved_at,
+	       a.created_at, a.updated_at
+	FROM annotations a
+	LEFT JOIN users u ON u.id = a.user_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAnnotation(row rowScanner) (*models_a.Annotation, error) {
+	var a models_a.Annotation
+	var selector []byte
+	var resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+	if err := row.Scan(
+		&a.ID, &a.DocumentID, &a.DocumentVersion, &a.UserID,
+		&a.FirstName, &a.LastName,
+		&a.Page, &selector, &a.Color, &a.Text, &a.Status, &resolvedBy, &resolvedAt,
+		&a.CreatedAt, &a.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(selector, &a.Selector); err != nil {
+		return nil, err
+	}
+	if resolvedBy.Valid {
+		id := int(resolvedBy.Int64)
+		a.ResolvedBy = &id
+	}
+	if resolvedAt.Valid {
+		t := resolvedAt.Time
+		a.ResolvedAt = &t
+	}
+	return &a, nil
+}
+
+// 1. Текущая версия документа и всё, от чего зависит его видимость (роль, статус, публикация)
+func (s *AnnotationStorage) DocumentInfo(ctx context.Context, docID int) (int, access.DocumentState, error) {
+	doc, err := access.LoadDocument(ctx, s.DB, docID)
+	if errors.Is(err, access.ErrDocumentNotFound) || (err == nil && doc.Deleted) {
+		return 0, doc, ErrDocNotFound
+	}
+	if err != nil {
+		return 0, doc, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	var version int
+	if err := s.DB.QueryRowContext(ctx,
+		`SELECT version FROM documents WHERE id = $1`, docID,
+	).Scan(&version); err != nil {
+		return 0, doc, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return version, doc, nil
+}
+
+// 2. Добавить аннотацию
+func (s *AnnotationStorage) Create(ctx context.Context, a *models_a.Annotation) error {
+	selector, err := json.Marshal(a.Selector)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadData, err)
+	}
+	err = s.DB.QueryRowContext(ctx, `
+		INSERT INTO annotations (document_id, document_version, user_id, page, selector, color, text)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, status, created_at, updated_at`,
+		a.DocumentID, a.DocumentVersion, a.UserID, a.Page, selector, a.Color, a.Text,
+	).Scan(&a.ID, &a.Status, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+// 3. Аннотации версии документа по страницам и порядку создания
+func (s *AnnotationStorage) List(ctx context.Context, docID int, f models_a.Filter) ([]models_a.Annotation, error) {
+	rows, err := s.DB.QueryContext(ctx, selectAnnotations+`
+	WHERE a.document_id = $1 AND a.document_version = $2 AND a.deleted_at IS NULL
+	  AND ($3 = 0 OR a.page = $3)
+	  AND ($4 = '' OR a.status = $4)
+	ORDER BY a.page, a.id`, docID, f.Version, f.Page, f.Status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	annotations := []models_a.Annotation{}
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		annotations = append(annotations, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return annotations, nil
+}
+
+// 4. Аннотация по ID
+func (s *AnnotationStorage) GetByID(ctx context.Context, id int) (*models_a.Annotation, error) {
+	a, err := scanAnnotation(s.DB.QueryRowContext(ctx, selectAnnotations+`
+	WHERE a.id = $1 AND a.deleted_at IS NULL`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return a, nil
+}
+
+// 5. Изменить текст, цвет и место аннотации
+func (s *AnnotationStorage) Update(ctx context.Context, a *models_a.Annotation) error {
+	selector, err := json.Marshal(a.Selector)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadData, err)
+	}
+	err = s.DB.QueryRowContext(ctx, `
+		UPDATE annotations
+		SET page = $2, selector = $3, color = $4, text = $5, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at`,
+		a.ID, a.Page, selector, a.Color, a.Text,
+	).Scan(&a.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+// 6. Закрыть или переоткрыть аннотацию
+func (s *AnnotationStorage) SetStatus(ctx context.Context, id int, status string, userID int) error {
+	var resolvedBy *int
+	var resolvedAt *time.Time
+	if status == models_a.StatusResolved {
+		now := time.Now()
+		resolvedBy, resolvedAt = &userID, &now
+	}
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE annotations
+		SET status = $2, resolved_by = $3, resolved_at = $4, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`,
+		id, status, resolvedBy, resolvedAt)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// 7. Удалить аннотацию
+func (s *AnnotationStorage) SoftDelete(ctx context.Context, id int) error {
+	res, err := s.DB.ExecContext(ctx,
+		`UPDATE annotations SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
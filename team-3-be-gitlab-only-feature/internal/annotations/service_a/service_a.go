@@ -0,0 +1,247 @@
+package service_a
+
+import (
+	"cmd/internal/access"
+	"cmd/internal/annotations/models_a"
+	"cmd/internal/annotations/repo_a"
+	"cmd/internal/comments/repo_k"
+	"cmd/internal/realtime"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	maxTextLength = 2000
+	maxQuote      = 1000 // длина цитаты и её контекста
+	defaultColor  = "#FFD400"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// AuthorChecker — запрет комментировать и фильтр запрещённых слов; реализует CommentService,
+// чтобы аннотации и комментарии модерировались одинаково
+type AuthorChecker interface {
+	CheckAuthor(ctx context.Context, userID int, text string) error
+}
+
+type AnnotationService struct {
+	repo    *repo_a.AnnotationStorage
+	events  realtime.Publisher
+	authors AuthorChecker
+}
+
+func NewAnnotationService(r *repo_a.AnnotationStorage, events realtime.Publisher, authors AuthorChecker) *AnnotationService {
+	return &AnnotationService{repo: r, events: events, authors: authors}
+}
+
+// checkAuthor — пользователю не запрещено писать, в тексте нет запрещённых слов
+func (s *AnnotationService) checkAuthor(ctx context.Context, userID int, text string) error {
+	if s.authors == nil {
+		return nil
+	}
+	err := s.authors.CheckAuthor(ctx, userID, text)
+	switch {
+	case errors.Is(err, repo_k.ErrMuted):
+		return repo_a.ErrMuted
+	case errors.Is(err, repo_k.ErrBannedWords):
+		return repo_a.ErrBannedWords
+	}
+	return err
+}
+
+// document проверяет, что документ есть и виден пользователю (роль, согласование, дата публикации);
+// возвращает текущую версию и автора. Неопубликованный чужой документ не раскрывается — 404.
+func (s *AnnotationService) document(ctx context.Context, docID, userID, roleID int) (version, authorID int, err error) {
+	version, doc, err := s.repo.DocumentInfo(ctx, docID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !access.CanSeeDocument(roleID, doc.RoleID) {
+		return 0, 0, repo_a.ErrForbidden
+	}
+	if !doc.OpenTo(roleID, userID, time.Now()) {
+		return 0, 0, repo_a.ErrDocNotFound
+	}
+	return version, doc.AuthorID, nil
+}
+
+// annotation — аннотация документа docID, видимого пользователю
+func (s *AnnotationService) annotation(ctx context.Context, docID, id, userID, roleID int) (*models_a.Annotation, int, error) {
+	_, authorID, err := s.document(ctx, docID, userID, roleID)
+	if err != nil {
+		return nil, 0, err
+	}
+	a, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if a.DocumentID != docID {
+		return nil, 0, repo_a.ErrNotFound
+	}
+	return a, authorID, nil
+}
+
+// validate проверяет страницу, selector, цвет и текст; пустой цвет заменяется цветом по умолчанию
+func validate(a *models_a.Annotation) error {
+	a.Text = strings.TrimSpace(a.Text)
+	if a.Page < 1 || len([]rune(a.Text)) > maxTextLength {
+		return repo_a.ErrBadData
+	}
+	if a.Color == "" {
+		a.Color = defaultColor
+	}
+	if !colorPattern.MatchString(a.Color) {
+		return repo_a.ErrBadData
+	}
+	a.Color = strings.ToUpper(a.Color)
+
+	sel := &a.Selector
+	switch sel.Type {
+	case models_a.SelectorRect:
+		if sel.X == nil || sel.Y == nil || sel.Width == nil || sel.Height == nil {
+			return repo_a.ErrBadData
+		}
+		x, y, w, h := *sel.X, *sel.Y, *sel.Width, *sel.Height
+		if x < 0 || y < 0 || w <= 0 || h <= 0 || x+w > 1 || y+h > 1 {
+			return repo_a.ErrBadData
+		}
+		sel.Exact, sel.Prefix, sel.Suffix = "", "", ""
+	case models_a.SelectorText:
+		if strings.TrimSpace(sel.Exact) == "" ||
+			len([]rune(sel.Exact)) > maxQuote || len([]rune(sel.Prefix)) > maxQuote || len([]rune(sel.Suffix)) > maxQuote {
+			return repo_a.ErrBadData
+		}
+		sel.X, sel.Y, sel.Width, sel.Height = nil, nil, nil, nil
+	default:
+		return repo_a.ErrBadData
+	}
+	return nil
+}
+
+// List — аннотации документа; по умолчанию текущей версии
+func (s *AnnotationService) List(ctx context.Context, docID, userID, roleID int, f models_a.Filter) ([]models_a.Annotation, error) {
+	version, _, err := s.document(ctx, docID, userID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if f.Status != "" && f.Status != models_a.StatusOpen && f.Status != models_a.StatusResolved {
+		return nil, repo_a.ErrBadData
+	}
+	if f.Version <= 0 {
+		f.Version = version
+	}
+	return s.repo.List(ctx, docID, f)
+}
+
+// Create добавляет аннотацию к текущей версии документа
+func (s *AnnotationService) Create(ctx context.Context, docID, userID, roleID int, a *models_a.Annotation) error {
+	version, _, err := s.document(ctx, docID, userID, roleID)
+	if err != nil {
+		return err
+	}
+	if err := validate(a); err != nil {
+		return err
+	}
+	if err := s.checkAuthor(ctx, userID, a.Text); err != nil {
+		return err
+	}
+	a.DocumentID = docID
+	a.DocumentVersion = version
+	a.UserID = userID
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		return err
+	}
+	s.publish(ctx, realtime.EventAnnotationCreated, a)
+	return nil
+}
+
+// Update меняет текст, цвет и место аннотации — автор или администратор, как у комментариев
+func (s *AnnotationService) Update(ctx context.Context, docID, id, userID, roleID int, in models_a.Annotation) (*models_a.Annotation, error) {
+	a, _, err := s.annotation(ctx, docID, id, userID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID && roleID != 1 {
+		return nil, repo_a.ErrForbidden
+	}
+
+	textChanged := strings.TrimSpace(in.Text) != a.Text
+	a.Page, a.Selector, a.Color, a.Text = in.Page, in.Selector, in.Color, in.Text
+	if err := validate(a); err != nil {
+		return nil, err
+	}
+	if textChanged {
+		if err := s.checkAuthor(ctx, userID, a.Text); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, realtime.EventAnnotationUpdated, a)
+	return a, nil
+}
+
+// SetStatus закрывает или переоткрывает аннотацию: её автор, автор документа, администратор или HR
+func (s *AnnotationService) SetStatus(ctx context.Context, docID, id, userID, roleID int, status string) (*models_a.Annotation, error) {
+	if status != models_a.StatusOpen && status != models_a.StatusResolved {
+		return nil, repo_a.ErrBadData
+	}
+	a, docAuthorID, err := s.annotation(ctx, docID, id, userID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID && docAuthorID != userID && roleID != 1 && roleID != 2 {
+		return nil, repo_a.ErrForbidden
+	}
+	if a.Status == status {
+		return a, nil
+	}
+
+	if err := s.repo.SetStatus(ctx, id, status, userID); err != nil {
+		return nil, err
+	}
+	if a, err = s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, realtime.EventAnnotationUpdated, a)
+	return a, nil
+}
+
+// Delete — автор или администратор, как у комментариев
+func (s *AnnotationService) Delete(ctx context.Context, docID, id, userID, roleID int) error {
+	a, _, err := s.annotation(ctx, docID, id, userID, roleID)
+	if err != nil {
+		return err
+	}
+	if a.UserID != userID && roleID != 1 {
+		return repo_a.ErrForbidden
+	}
+	if err := s.repo.SoftDelete(ctx, id); err != nil {
+		return err
+	}
+	if s.events != nil {
+		s.events.Publish(ctx, realtime.DocumentEvent(realtime.EventAnnotationDeleted, docID, map[string]int{
+			"annotation_id": id,
+		}))
+	}
+	return nil
+}
+
+func (s *AnnotationService) publish(ctx context.Context, eventType string, a *models_a.Annotation) {
+	if s.events != nil {
+		s.events.Publish(ctx, realtime.DocumentEvent(eventType, a.DocumentID, models_a.Event{
+			ID:              a.ID,
+			DocumentID:      a.DocumentID,
+			DocumentVersion: a.DocumentVersion,
+			UserID:          a.UserID,
+			Page:            a.Page,
+			Status:          a.Status,
+			UpdatedAt:       a.UpdatedAt,
+		}))
+	}
+}
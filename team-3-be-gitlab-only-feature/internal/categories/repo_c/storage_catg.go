@@ -74,6 +74,9 @@ func (s *CategoryStorage) GetDocumentsByCategory(ctx context.Context, categoryID
 			FROM documents d
 			LEFT JOIN users u ON d.user_id = u.id
 			WHERE d.category_id = $1 AND d.deleted_at IS NULL  -- ← ВЕРНУЛ ПРОВЕРКУ
+			  AND d.status = 'approved'  -- неодобренные документы не публикуются
+			  AND (d.publish_at IS NULL OR d.publish_at <= NOW())
+			  AND (d.expires_at IS NULL OR d.expires_at > NOW())
 			ORDER BY d.created_at DESC`
 
 		rows, err := s.queryDocumentRows(ctx, query, categoryID)
@@ -91,6 +94,9 @@ func (s *CategoryStorage) GetDocumentsByCategory(ctx context.Context, categoryID
 		LEFT JOIN users u ON d.user_id = u.id
 		WHERE d.category_id = $1 
 		  AND d.deleted_at IS NULL  -- ← ВЕРНУЛ ПРОВЕРКУ
+		  AND d.status = 'approved'
+		  AND (d.publish_at IS NULL OR d.publish_at <= NOW())
+		  AND (d.expires_at IS NULL OR d.expires_at > NOW())
 		  AND EXISTS (
 			SELECT 1 FROM document_roles dr2 
 			WHERE dr2.document_id = d.id AND dr2.role_id = $2
@@ -1,9 +1,9 @@
 package api
 
 import (
+	"cmd/internal/comments/repo_k"
 	service "cmd/internal/comments/service_k"
 	custommiddleware "cmd/internal/custom_middleware"
-	"cmd/internal/documents/repo"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -20,16 +20,45 @@ func NewHandler(s *service.CommentService) *CommentHandler {
 	return &CommentHandler{service: s}
 }
 
+// GetByDocument — все комментарии списком, новые сверху; с ?view=threads — деревом
+// постранично по веткам верхнего уровня (limit, offset)
 func (h *CommentHandler) GetByDocument(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
 	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
-	comments, err := h.service.GetByDocument(r.Context(), docID)
+
+	q := r.URL.Query()
+	if q.Get("view") == "threads" {
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		page, err := h.service.GetThreads(r.Context(), docID, user.ID, user.RoleID, limit, offset)
+		if err != nil {
+			respondListError(w, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, page)
+		return
+	}
+
+	comments, err := h.service.GetByDocument(r.Context(), docID, user.ID, user.RoleID)
 	if err != nil {
-		http.Error(w, "Ошибка получения комментариев", http.StatusInternalServerError)
+		respondListError(w, err)
 		return
 	}
 	respondJSON(w, http.StatusOK, comments)
 }
 
+func respondListError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repo_k.ErrDocNotFound) {
+		http.Error(w, "Документ не найден", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Ошибка получения комментариев", http.StatusInternalServerError)
+}
+
 func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	user, ok := custommiddleware.GetCurrentUser(r)
 	if !ok || user == nil {
@@ -39,22 +68,99 @@ func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
 	var in struct {
-		Text string `json:"text" validate:"required,min=1,max=1000"`
+		Text     string `json:"text" validate:"required,min=1,max=1000"`
+		ParentID *int   `json:"parent_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Text == "" {
 		http.Error(w, "Текст обязателен", http.StatusBadRequest)
 		return
 	}
 
-	comment, err := h.service.Create(r.Context(), docID, user.ID, in.Text)
+	comment, err := h.service.Create(r.Context(), docID, user.ID, user.RoleID, in.Text, in.ParentID)
 	if err != nil {
-		http.Error(w, "Не удалось создать комментарий", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, repo_k.ErrBadData):
+			http.Error(w, "Текст комментария должен быть не длиннее 999 символов", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrDocNotFound):
+			http.Error(w, "Документ не найден", http.StatusNotFound)
+		case errors.Is(err, repo_k.ErrBadParent):
+			http.Error(w, "Комментарий, на который вы отвечаете, не найден", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrTooDeep):
+			http.Error(w, "Слишком глубокая вложенность ответов", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrBannedWords):
+			http.Error(w, "Комментарий содержит недопустимые слова", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrMuted):
+			http.Error(w, "Вам запрещено оставлять комментарии", http.StatusForbidden)
+		default:
+			http.Error(w, "Не удалось создать комментарий", http.StatusInternalServerError)
+		}
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, comment)
 }
 
+// Update — правка текста: автор в пределах окна правки, администратор в любое время
+func (h *CommentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	commentID, _ := strconv.Atoi(chi.URLParam(r, "comment_id"))
+	var in struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Text == "" {
+		http.Error(w, "Текст обязателен", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.service.Update(r.Context(), docID, commentID, user.ID, user.RoleID, in.Text)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo_k.ErrBadData):
+			http.Error(w, "Текст комментария должен быть не длиннее 999 символов", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrDocNotFound):
+			http.Error(w, "Документ не найден", http.StatusNotFound)
+		case errors.Is(err, repo_k.ErrNotFound):
+			http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		case errors.Is(err, repo_k.ErrForbidden):
+			http.Error(w, "Доступ запрещён", http.StatusForbidden)
+		case errors.Is(err, repo_k.ErrEditTime):
+			http.Error(w, "Время на правку комментария истекло", http.StatusForbidden)
+		case errors.Is(err, repo_k.ErrBannedWords):
+			http.Error(w, "Комментарий содержит недопустимые слова", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrMuted):
+			http.Error(w, "Вам запрещено оставлять комментарии", http.StatusForbidden)
+		default:
+			http.Error(w, "Не удалось изменить комментарий", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, comment)
+}
+
+// Revisions — история правок, только для модераторов
+func (h *CommentHandler) Revisions(w http.ResponseWriter, r *http.Request) {
+	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	commentID, _ := strconv.Atoi(chi.URLParam(r, "comment_id"))
+
+	revisions, err := h.service.Revisions(r.Context(), docID, commentID)
+	if err != nil {
+		if errors.Is(err, repo_k.ErrNotFound) {
+			http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		} else {
+			http.Error(w, "Ошибка получения истории правок", http.StatusInternalServerError)
+		}
+		return
+	}
+	respondJSON(w, http.StatusOK, revisions)
+}
+
 func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	user, ok := custommiddleware.GetCurrentUser(r)
 	if !ok || user == nil {
@@ -65,9 +171,9 @@ func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	commentID, _ := strconv.Atoi(chi.URLParam(r, "comment_id"))
 
 	if err := h.service.Delete(r.Context(), commentID, user.ID, user.RoleID); err != nil {
-		if errors.Is(err, repo.ErrForbidden) {
+		if errors.Is(err, repo_k.ErrForbidden) {
 			http.Error(w, "Доступ запрещён", http.StatusForbidden)
-		} else if errors.Is(err, repo.ErrNotFound) {
+		} else if errors.Is(err, repo_k.ErrNotFound) {
 			http.Error(w, "Комментарий не найден", http.StatusNotFound)
 		} else {
 			http.Error(w, "Ошибка удаления", http.StatusInternalServerError)
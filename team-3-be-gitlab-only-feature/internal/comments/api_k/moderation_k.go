@@ -0,0 +1,163 @@
+package api
+
+import (
+	"cmd/internal/comments/repo_k"
+	custommiddleware "cmd/internal/custom_middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Report — жалоба сотрудника на комментарий
+func (h *CommentHandler) Report(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	docID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	commentID, _ := strconv.Atoi(chi.URLParam(r, "comment_id"))
+	var in struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Report(r.Context(), docID, commentID, user.ID, user.RoleID, in.Reason); err != nil {
+		switch {
+		case errors.Is(err, repo_k.ErrBadData):
+			http.Error(w, "Укажите причину жалобы (до 500 символов)", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrDocNotFound):
+			http.Error(w, "Документ не найден", http.StatusNotFound)
+		case errors.Is(err, repo_k.ErrNotFound):
+			http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		case errors.Is(err, repo_k.ErrOwnComment):
+			http.Error(w, "Нельзя пожаловаться на свой комментарий", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrAlreadyReported):
+			http.Error(w, "Вы уже пожаловались на этот комментарий", http.StatusConflict)
+		default:
+			http.Error(w, "Не удалось отправить жалобу", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Queue — очередь модерации: комментарии с нерассмотренными жалобами
+func (h *CommentHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	queue, err := h.service.Queue(r.Context(), limit, offset)
+	if err != nil {
+		http.Error(w, "Ошибка получения очереди модерации", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, queue)
+}
+
+// Moderate — решение модератора: dismiss, hide, delete или warn
+func (h *CommentHandler) Moderate(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	commentID, _ := strconv.Atoi(chi.URLParam(r, "comment_id"))
+	var in struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Moderate(r.Context(), commentID, user.ID, in.Action, in.Note); err != nil {
+		switch {
+		case errors.Is(err, repo_k.ErrUnknownAction):
+			http.Error(w, "Неизвестное действие: dismiss, hide, delete или warn", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrBadData):
+			http.Error(w, "Комментарий модератора должен быть не длиннее 500 символов", http.StatusBadRequest)
+		case errors.Is(err, repo_k.ErrNotFound):
+			http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		default:
+			http.Error(w, "Ошибка модерации", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMutes — действующие запреты комментировать
+func (h *CommentHandler) ListMutes(w http.ResponseWriter, r *http.Request) {
+	mutes, err := h.service.Mutes(r.Context())
+	if err != nil {
+		http.Error(w, "Ошибка получения списка запретов", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, mutes)
+}
+
+// Mute — запретить пользователю комментировать; until не задан — бессрочно
+func (h *CommentHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	admin, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || admin == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil || userID <= 0 {
+		http.Error(w, "Неверный ID пользователя", http.StatusBadRequest)
+		return
+	}
+	if userID == admin.ID {
+		http.Error(w, "Нельзя запретить комментарии самому себе", http.StatusBadRequest)
+		return
+	}
+	var in struct {
+		Reason string     `json:"reason"`
+		Until  *time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	mute, err := h.service.Mute(r.Context(), userID, admin.ID, in.Reason, in.Until)
+	if err != nil {
+		if errors.Is(err, repo_k.ErrBadData) {
+			http.Error(w, "Срок запрета должен быть в будущем, причина — до 500 символов", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Не удалось запретить комментарии", http.StatusInternalServerError)
+		}
+		return
+	}
+	respondJSON(w, http.StatusOK, mute)
+}
+
+// Unmute — снять запрет комментировать
+func (h *CommentHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err := h.service.Unmute(r.Context(), userID); err != nil {
+		if errors.Is(err, repo_k.ErrNotFound) {
+			http.Error(w, "Запрет не найден", http.StatusNotFound)
+		} else {
+			http.Error(w, "Не удалось снять запрет", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
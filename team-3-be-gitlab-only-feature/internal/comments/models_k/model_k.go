@@ -1,16 +1,116 @@
 package models_k
 
-import "time"
+import (
+	"cmd/internal/reactions/models_re"
+	"time"
+)
+
+// Тексты заглушек удалённого и скрытого модератором комментария, у которого остались ответы
+const (
+	DeletedText = "[deleted]"
+	HiddenText  = "[hidden]"
+)
+
+// Действия модератора по комментарию
+const (
+	ActionDismiss = "dismiss" // жалобы необоснованны
+	ActionHide    = "hide"    // скрыть комментарий
+	ActionDelete  = "delete"  // удалить комментарий
+	ActionWarn    = "warn"    // предупредить автора
+)
+
+// Статусы жалобы
+const (
+	ReportPending   = "pending"
+	ReportDismissed = "dismissed"
+	ReportResolved  = "resolved"
+)
 
 type Comment struct {
-	ID         int        `json:"id"`
-	DocumentID int        `json:"document_id"`
-	UserID     int        `json:"user_id"`
-	Text       string     `json:"text"`
-	CreatedAt  time.Time  `json:"created_at"`
-	IsDeleted  bool       `json:"is_deleted"`
-	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
-	FirstName  string     `json:"first_name,omitempty"`
-	LastName   string     `json:"last_name,omitempty"`
-	PhotoPath  *string    `json:"photo_path,omitempty"`
+	ID         int                  `json:"id"`
+	DocumentID int                  `json:"document_id"`
+	UserID     int                  `json:"user_id"`
+	ParentID   *int                 `json:"parent_id,omitempty"`
+	ThreadID   *int                 `json:"-"` // корневой комментарий ветки; nil у самого корня
+	Depth      int                  `json:"depth"`
+	Text       string               `json:"text"`
+	CreatedAt  time.Time            `json:"created_at"`
+	IsDeleted  bool                 `json:"is_deleted"`
+	IsHidden   bool                 `json:"is_hidden"`
+	DeletedAt  *time.Time           `json:"deleted_at,omitempty"`
+	EditedAt   *time.Time           `json:"edited_at,omitempty"`
+	FirstName  string               `json:"first_name,omitempty"`
+	LastName   string               `json:"last_name,omitempty"`
+	PhotoPath  *string              `json:"photo_path,omitempty"`
+	Mentions   []Mention            `json:"mentions,omitempty"`
+	Reactions  []models_re.Reaction `json:"reactions,omitempty"`
+	Replies    []*Comment           `json:"replies,omitempty"` // только в виде дерева
+}
+
+// Event — данные события комментария в потоке SSE: только идентификаторы и метаданные, без текста
+// и упоминаний (NOTIFY ограничен ~8000 байт); клиент перечитывает комментарии документа
+type Event struct {
+	ID       int        `json:"comment_id"`
+	ParentID *int       `json:"parent_id,omitempty"`
+	UserID   int        `json:"user_id"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+}
+
+// Mention — пользователь, упомянутый в тексте через @
+type Mention struct {
+	UserID    int    `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Revision — текст комментария до очередной правки
+type Revision struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	Text      string    `json:"text"`
+	EditedBy  int       `json:"edited_by"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+// ThreadPage — страница веток обсуждения; Total — число веток верхнего уровня
+type ThreadPage struct {
+	Items []*Comment `json:"items"`
+	Total int        `json:"total"`
+}
+
+// Report — жалоба на комментарий
+type Report struct {
+	ID         int       `json:"id"`
+	CommentID  int       `json:"comment_id"`
+	ReporterID int       `json:"reporter_id"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReportedComment — комментарий в очереди модерации вместе с жалобами на него
+type ReportedComment struct {
+	Comment       Comment  `json:"comment"`
+	DocumentTitle string   `json:"document_title"`
+	ReportCount   int      `json:"report_count"`
+	Reports       []Report `json:"reports"`
+}
+
+type ModerationQueue struct {
+	Items []ReportedComment `json:"items"`
+	Total int               `json:"total"`
+}
+
+// Mute — запрет пользователю оставлять комментарии; Until nil — бессрочно
+type Mute struct {
+	UserID    int        `json:"user_id"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	MutedBy   int        `json:"muted_by"`
+	Reason    string     `json:"reason"`
+	Until     *time.Time `json:"until,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
 }
@@ -3,9 +3,19 @@ package repo_k
 import "errors"
 
 var (
-	ErrDBFailure = errors.New("database error")
-	ErrNotFound  = errors.New("comment not found")
-	ErrForbidden = errors.New("forbidden")
-	ErrBadData   = errors.New("invalid comment data")
-	ErrInvalidID = errors.New("invalid id")
+	ErrDBFailure   = errors.New("database error")
+	ErrNotFound    = errors.New("comment not found")
+	ErrDocNotFound = errors.New("document not found")
+	ErrForbidden   = errors.New("forbidden")
+	ErrBadData     = errors.New("invalid comment data")
+	ErrInvalidID   = errors.New("invalid id")
+	ErrBadParent   = errors.New("parent comment not found in this document")
+	ErrTooDeep     = errors.New("reply nesting too deep")
+	ErrEditTime    = errors.New("comment edit window has expired")
+
+	ErrAlreadyReported = errors.New("comment already reported by this user")
+	ErrOwnComment      = errors.New("cannot report own comment")
+	ErrMuted           = errors.New("user is muted")
+	ErrBannedWords     = errors.New("comment contains banned words")
+	ErrUnknownAction   = errors.New("unknown moderation action")
 )
@@ -0,0 +1,234 @@
+package repo_k
+
+import (
+	models "cmd/internal/comments/models_k"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 1. Пожаловаться на комментарий; повторная жалоба того же пользователя — ErrAlreadyReported
+func (s *CommentStorage) CreateReport(ctx context.Context, commentID, reporterID int, reason string) error {
+	res, err := s.DB.ExecContext(ctx, `
+		INSERT INTO comment_reports (comment_id, reporter_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (comment_id, reporter_id) DO NOTHING`,
+		commentID, reporterID, reason)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyReported
+	}
+	return nil
+}
+
+// 2. Число комментариев с нерассмотренными жалобами
+func (s *CommentStorage) CountReported(ctx context.Context) (int, error) {
+	var total int
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT comment_id) FROM comment_reports WHERE status = 'pending'`).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return total, nil
+}
+
+// 3. Очередь модерации: сначала комментарии с большим числом жалоб, при равенстве — с более старой
+func (s *CommentStorage) GetReported(ctx context.Context, limit, offset int) ([]models.ReportedComment, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT c.id, c.document_id, COALESCE(c.user_id, 0), c.parent_id, c.depth, c.text, c.created_at,
+		       c.is_deleted, c.is_hidden, c.edited_at,
+		       COALESCE(u.first_name, 'Удалённый пользователь'), COALESCE(u.last_name, ''),
+		       d.title, q.cnt
+		FROM (SELECT comment_id, COUNT(*) AS cnt, MIN(created_at) AS first_at
+		      FROM comment_reports
+		      WHERE status = 'pending'
+		      GROUP BY comment_id) q
+		JOIN comments c ON c.id = q.comment_id
+		JOIN documents d ON d.id = c.document_id
+		LEFT JOIN users u ON u.id = c.user_id
+		ORDER BY q.cnt DESC, q.first_at, c.id
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	items := []models.ReportedComment{}
+	for rows.Next() {
+		var item models.ReportedComment
+		c := &item.Comment
+		var parentID sql.NullInt64
+		var editedAt sql.NullTime
+		if err := rows.Scan(
+			&c.ID, &c.DocumentID, &c.UserID, &parentID, &c.Depth, &c.Text, &c.CreatedAt,
+			&c.IsDeleted, &c.IsHidden, &editedAt,
+			&c.FirstName, &c.LastName,
+			&item.DocumentTitle, &item.ReportCount,
+		); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			c.ParentID = &id
+		}
+		if editedAt.Valid {
+			t := editedAt.Time
+			c.EditedAt = &t
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return items, nil
+}
+
+// 4. Нерассмотренные жалобы на комментарии
+func (s *CommentStorage) GetPendingReports(ctx context.Context, commentIDs []int) (map[int][]models.Report, error) {
+	reports := make(map[int][]models.Report)
+	if len(commentIDs) == 0 {
+		return reports, nil
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT r.id, r.comment_id, COALESCE(r.reporter_id, 0), r.reason, r.created_at,
+		       COALESCE(u.first_name, 'Удалённый пользователь'), COALESCE(u.last_name, '')
+		FROM comment_reports r
+		LEFT JOIN users u ON u.id = r.reporter_id
+		WHERE r.comment_id = ANY($1) AND r.status = 'pending'
+		ORDER BY r.id`, commentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.Report
+		if err := rows.Scan(&r.ID, &r.CommentID, &r.ReporterID, &r.Reason, &r.CreatedAt, &r.FirstName, &r.LastName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		reports[r.CommentID] = append(reports[r.CommentID], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return reports, nil
+}
+
+// 5. Решение модератора: скрыть или удалить комментарий и закрыть жалобы на него одной транзакцией
+func (s *CommentStorage) Moderate(ctx context.Context, commentID, moderatorID int, action, note string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	switch action {
+	case models.ActionHide:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE comments SET is_hidden = TRUE, hidden_by = $2, hidden_at = $3
+			WHERE id = $1 AND is_hidden = FALSE`, commentID, moderatorID, now)
+	case models.ActionDelete:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE comments SET is_deleted = TRUE, deleted_at = $2
+			WHERE id = $1 AND is_deleted = FALSE`, commentID, now)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	status := models.ReportResolved
+	if action == models.ActionDismiss {
+		status = models.ReportDismissed
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE comment_reports
+		SET status = $2, action = $3, resolved_by = $4, resolved_at = $5, note = NULLIF($6, '')
+		WHERE comment_id = $1 AND status = 'pending'`,
+		commentID, status, action, moderatorID, now, note,
+	); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+// 6. Запретить пользователю комментировать или изменить срок запрета
+func (s *CommentStorage) SetMute(ctx context.Context, m *models.Mute) error {
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO comment_mutes (user_id, muted_by, reason, muted_until)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET muted_by = EXCLUDED.muted_by, reason = EXCLUDED.reason,
+		    muted_until = EXCLUDED.muted_until, created_at = NOW()
+		RETURNING created_at`,
+		m.UserID, m.MutedBy, m.Reason, m.Until,
+	).Scan(&m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+// 7. Снять запрет
+func (s *CommentStorage) RemoveMute(ctx context.Context, userID int) error {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM comment_mutes WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// 8. Действующие запреты
+func (s *CommentStorage) ListMutes(ctx context.Context) ([]models.Mute, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT m.user_id, u.first_name, u.last_name, COALESCE(m.muted_by, 0), m.reason, m.muted_until, m.created_at
+		FROM comment_mutes m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.muted_until IS NULL OR m.muted_until > NOW()
+		ORDER BY m.created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	mutes := []models.Mute{}
+	for rows.Next() {
+		var m models.Mute
+		var until sql.NullTime
+		if err := rows.Scan(&m.UserID, &m.FirstName, &m.LastName, &m.MutedBy, &m.Reason, &until, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		if until.Valid {
+			t := until.Time
+			m.Until = &t
+		}
+		mutes = append(mutes, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return mutes, nil
+}
+
+// 9. Действует ли запрет комментировать
+func (s *CommentStorage) IsMuted(ctx context.Context, userID int) (bool, error) {
+	var muted bool
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM comment_mutes
+		               WHERE user_id = $1 AND (muted_until IS NULL OR muted_until > NOW()))`, userID,
+	).Scan(&muted)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return muted, nil
+}
@@ -1,9 +1,11 @@
 package repo_k
 
 import (
+	"cmd/internal/access"
 	models "cmd/internal/comments/models_k"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -16,18 +18,21 @@ func NewCommentStorage(db *sql.DB) *CommentStorage {
 	return &CommentStorage{DB: db}
 }
 
-// 1. Получить все комментарии к документу
-func (s *CommentStorage) GetByDocument(ctx context.Context, docID int) ([]models.Comment, error) {
-	const q = `
-		SELECT c.id, c.document_id, c.user_id, c.text, c.created_at, 
-		       u.first_name, u.last_name, u.photo_path
-		FROM comments c
-		JOIN users u ON u.id = c.user_id
-		WHERE c.document_id = $1 AND c.is_deleted = FALSE
-		ORDER BY c.created_at DESC
-	`
+// Комментарии вместе с удалёнными: удалённый с ответами показывается заглушкой, решает сервис
+const selectComments = `
+	SELECT c.id, c.document_id, COALESCE(c.user_id, 0), c.parent_id, c.thread_id, c.depth, c.text,
+	       c.created_at, c.is_deleted, c.is_hidden, c.deleted_at, c.edited_at,
+	       COALESCE(u.first_name, 'Удалённый пользователь'), COALESCE(u.last_name, ''), u.photo_path
+	FROM comments c
+	LEFT JOIN users u ON u.id = c.user_id`
 
-	rows, err := s.DB.QueryContext(ctx, q, docID)
+// Ветка видна, пока в ней остался хотя бы один неудалённый и нескрытый комментарий
+const visibleThread = `
+	  AND EXISTS (SELECT 1 FROM comments t
+	              WHERE COALESCE(t.thread_id, t.id) = c.id AND t.is_deleted = FALSE AND t.is_hidden = FALSE)`
+
+func (s *CommentStorage) queryComments(ctx context.Context, where string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := s.DB.QueryContext(ctx, selectComments+where, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
 	}
@@ -36,45 +41,92 @@ func (s *CommentStorage) GetByDocument(ctx context.Context, docID int) ([]models
 	var comments []models.Comment
 	for rows.Next() {
 		var c models.Comment
+		var parentID, threadID sql.NullInt64
+		var deletedAt, editedAt sql.NullTime
 		var photoPath sql.NullString
 		if err := rows.Scan(
-			&c.ID, &c.DocumentID, &c.UserID, &c.Text, &c.CreatedAt,
+			&c.ID, &c.DocumentID, &c.UserID, &parentID, &threadID, &c.Depth, &c.Text,
+			&c.CreatedAt, &c.IsDeleted, &c.IsHidden, &deletedAt, &editedAt,
 			&c.FirstName, &c.LastName, &photoPath,
 		); err != nil {
 			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
 		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			c.ParentID = &id
+		}
+		if threadID.Valid {
+			id := int(threadID.Int64)
+			c.ThreadID = &id
+		}
+		if deletedAt.Valid {
+			t := deletedAt.Time
+			c.DeletedAt = &t
+		}
+		if editedAt.Valid {
+			t := editedAt.Time
+			c.EditedAt = &t
+		}
 		if photoPath.Valid {
 			p := photoPath.String
 			c.PhotoPath = &p
 		}
 		comments = append(comments, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
 	return comments, nil
 }
 
-// 2. Добавить комментарий
+// 1. Получить все комментарии к документу, включая удалённые, в порядке написания
+func (s *CommentStorage) GetByDocument(ctx context.Context, docID int) ([]models.Comment, error) {
+	return s.queryComments(ctx, `
+	WHERE c.document_id = $1
+	ORDER BY c.created_at, c.id`, docID)
+}
+
+// 2. Добавить комментарий вместе с упоминаниями
 func (s *CommentStorage) Create(ctx context.Context, c *models.Comment) error {
 	if c.Text == "" || len(c.Text) > 999 {
 		return ErrBadData
 	}
 
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
 	const q = `
-		INSERT INTO comments (document_id, user_id, text)
-		VALUES ($1, $2, $3)
+		INSERT INTO comments (document_id, user_id, parent_id, thread_id, depth, text)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`
-	err := s.DB.QueryRowContext(ctx, q, c.DocumentID, c.UserID, c.Text).
+	err = tx.QueryRowContext(ctx, q, c.DocumentID, c.UserID, c.ParentID, c.ThreadID, c.Depth, c.Text).
 		Scan(&c.ID, &c.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrDBFailure, err)
 	}
+	for _, m := range c.Mentions {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO comment_mentions (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			c.ID, m.UserID,
+		); err != nil {
+			return fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
 	return nil
 }
 
 // 3. Удаление комментария
 func (s *CommentStorage) SoftDelete(ctx context.Context, id int) error {
 	const q = `
-		UPDATE comments 
+		UPDATE comments
 		SET is_deleted = TRUE, deleted_at = $2
 		WHERE id = $1 AND is_deleted = FALSE
 	`
@@ -92,13 +144,17 @@ func (s *CommentStorage) SoftDelete(ctx context.Context, id int) error {
 // 4. Получить комментарий по ID
 func (s *CommentStorage) GetByID(ctx context.Context, id int) (*models.Comment, error) {
 	const q = `
-		SELECT id, document_id, user_id, text, created_at, is_deleted
+		SELECT id, document_id, COALESCE(user_id, 0), parent_id, thread_id, depth, text, created_at,
+		       is_deleted, is_hidden, edited_at
 		FROM comments
 		WHERE id = $1
 	`
 	var c models.Comment
+	var parentID, threadID sql.NullInt64
+	var editedAt sql.NullTime
 	err := s.DB.QueryRowContext(ctx, q, id).Scan(
-		&c.ID, &c.DocumentID, &c.UserID, &c.Text, &c.CreatedAt, &c.IsDeleted,
+		&c.ID, &c.DocumentID, &c.UserID, &parentID, &threadID, &c.Depth, &c.Text, &c.CreatedAt,
+		&c.IsDeleted, &c.IsHidden, &editedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -106,5 +162,207 @@ func (s *CommentStorage) GetByID(ctx context.Context, id int) (*models.Comment,
 		}
 		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
 	}
+	if parentID.Valid {
+		pid := int(parentID.Int64)
+		c.ParentID = &pid
+	}
+	if threadID.Valid {
+		tid := int(threadID.Int64)
+		c.ThreadID = &tid
+	}
+	if editedAt.Valid {
+		t := editedAt.Time
+		c.EditedAt = &t
+	}
 	return &c, nil
 }
+
+// 5. Число видимых веток верхнего уровня
+func (s *CommentStorage) CountThreads(ctx context.Context, docID int) (int, error) {
+	var total int
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM comments c
+		WHERE c.document_id = $1 AND c.parent_id IS NULL`+visibleThread, docID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return total, nil
+}
+
+// 6. Страница веток: корневые комментарии новые сверху и все ответы в них
+func (s *CommentStorage) GetThreads(ctx context.Context, docID, limit, offset int) ([]models.Comment, error) {
+	return s.queryComments(ctx, `
+	WHERE c.document_id = $1
+	  AND COALESCE(c.thread_id, c.id) IN (
+	      SELECT c.id FROM comments c
+	      WHERE c.document_id = $1 AND c.parent_id IS NULL`+visibleThread+`
+	      ORDER BY c.created_at DESC, c.id DESC
+	      LIMIT $2 OFFSET $3)
+	ORDER BY c.created_at, c.id`, docID, limit, offset)
+}
+
+// 7. Упоминания в комментариях
+func (s *CommentStorage) GetMentions(ctx context.Context, commentIDs []int) (map[int][]models.Mention, error) {
+	mentions := make(map[int][]models.Mention)
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT m.comment_id, u.id, u.first_name, u.last_name
+		FROM comment_mentions m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.comment_id = ANY($1)
+		ORDER BY m.comment_id, u.last_name, u.first_name`, commentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID int
+		var m models.Mention
+		if err := rows.Scan(&commentID, &m.UserID, &m.FirstName, &m.LastName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		mentions[commentID] = append(mentions[commentID], m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return mentions, nil
+}
+
+// 8. Кого можно упомянуть: активные пользователи, которым виден документ (включая неопубликованный —
+// автору, согласующим, администратору и HR), по email или
+// по имени и фамилии через точку или подчёркивание. Handle — в нижнем регистре;
+// одному handle может соответствовать несколько пользователей
+func (s *CommentStorage) FindMentionable(ctx context.Context, docID int, handles []string) (map[string][]models.Mention, error) {
+	found := make(map[string][]models.Mention)
+	if len(handles) == 0 {
+		return found, nil
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT h.handle, u.id, u.first_name, u.last_name
+		FROM unnest($2::text[]) AS h(handle)
+		JOIN users u ON LOWER(u.email) = h.handle
+		             OR LOWER(u.first_name || '.' || u.last_name) = h.handle
+		             OR LOWER(u.first_name || '_' || u.last_name) = h.handle
+		JOIN documents d ON d.id = $1
+		WHERE u.deleted_at IS NULL
+		  AND `+access.VisibleSQL("d", "u.role_id", "u.id"),
+		docID, handles)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var handle string
+		var m models.Mention
+		if err := rows.Scan(&handle, &m.UserID, &m.FirstName, &m.LastName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		found[handle] = append(found[handle], m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return found, nil
+}
+
+// 9. Изменить текст: прежний сохраняется в comment_revisions, упоминания заменяются новыми.
+// c — комментарий с новым текстом и упоминаниями; EditedAt проставляется
+func (s *CommentStorage) UpdateText(ctx context.Context, c *models.Comment, editorID int) error {
+	if c.Text == "" || len(c.Text) > 999 {
+		return ErrBadData
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	var oldText string
+	err = tx.QueryRowContext(ctx,
+		`SELECT text FROM comments WHERE id = $1 AND is_deleted = FALSE FOR UPDATE`, c.ID,
+	).Scan(&oldText)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO comment_revisions (comment_id, text, edited_by, edited_at) VALUES ($1, $2, $3, $4)`,
+		c.ID, oldText, editorID, now,
+	); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE comments SET text = $2, edited_at = $3 WHERE id = $1`, c.ID, c.Text, now,
+	); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM comment_mentions WHERE comment_id = $1`, c.ID); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	for _, m := range c.Mentions {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO comment_mentions (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			c.ID, m.UserID,
+		); err != nil {
+			return fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	c.EditedAt = &now
+	return nil
+}
+
+// 10. История правок комментария, старые сверху
+func (s *CommentStorage) GetRevisions(ctx context.Context, commentID int) ([]models.Revision, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT r.id, r.comment_id, r.text, COALESCE(r.edited_by, 0), r.edited_at,
+		       COALESCE(u.first_name, 'Удалённый пользователь'), COALESCE(u.last_name, '')
+		FROM comment_revisions r
+		LEFT JOIN users u ON u.id = r.edited_by
+		WHERE r.comment_id = $1
+		ORDER BY r.id`, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		var r models.Revision
+		if err := rows.Scan(&r.ID, &r.CommentID, &r.Text, &r.EditedBy, &r.EditedAt, &r.FirstName, &r.LastName); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return revisions, nil
+}
+
+// 11. Состояние документа для проверки видимости: роль, статус согласования, публикация, удалён ли
+func (s *CommentStorage) Document(ctx context.Context, docID int) (access.DocumentState, error) {
+	doc, err := access.LoadDocument(ctx, s.DB, docID)
+	if errors.Is(err, access.ErrDocumentNotFound) {
+		return doc, ErrDocNotFound
+	}
+	if err != nil {
+		return doc, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return doc, nil
+}
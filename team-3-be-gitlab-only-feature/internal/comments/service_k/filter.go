@@ -0,0 +1,55 @@
+package service_k
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wordFilter ищет запрещённые слова без учёта регистра и различия е/ё.
+// Слово со звёздочкой на конце («спам*») запрещает все слова с этим началом
+type wordFilter struct {
+	words    map[string]bool
+	prefixes []string
+}
+
+func newWordFilter(list []string) *wordFilter {
+	f := &wordFilter{words: make(map[string]bool)}
+	for _, w := range list {
+		w = normalizeWord(strings.TrimSpace(w))
+		if prefix, ok := strings.CutSuffix(w, "*"); ok {
+			if prefix != "" {
+				f.prefixes = append(f.prefixes, prefix)
+			}
+			continue
+		}
+		if w != "" {
+			f.words[w] = true
+		}
+	}
+	return f
+}
+
+func normalizeWord(w string) string {
+	return strings.ReplaceAll(strings.ToLower(w), "ё", "е")
+}
+
+// match возвращает первое запрещённое слово в тексте
+func (f *wordFilter) match(text string) (string, bool) {
+	if len(f.words) == 0 && len(f.prefixes) == 0 {
+		return "", false
+	}
+	words := strings.FieldsFunc(normalizeWord(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if f.words[w] {
+			return w, true
+		}
+		for _, p := range f.prefixes {
+			if strings.HasPrefix(w, p) {
+				return w, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,31 @@
+package service_k
+
+import (
+	"regexp"
+	"strings"
+)
+
+const maxMentions = 20 // больше упоминаний в одном комментарии не разбираем
+
+// @email или @Имя.Фамилия / @Имя_Фамилия; перед @ не должно быть буквы или цифры,
+// иначе это середина email в тексте
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.+\-]+(?:@[\p{L}\p{N}\-]+(?:\.[\p{L}\p{N}\-]+)+)?)`)
+
+// parseMentions возвращает handle упоминаний в нижнем регистре без повторов
+func parseMentions(text string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// точка в конце — скорее конец предложения, чем часть имени
+		handle := strings.ToLower(strings.TrimRight(m[1], "."))
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+		if len(handles) == maxMentions {
+			break
+		}
+	}
+	return handles
+}
@@ -0,0 +1,159 @@
+package service_k
+
+import (
+	"cmd/internal/comments/models_k"
+	"cmd/internal/comments/repo_k"
+	"cmd/internal/realtime"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+const maxReasonLength = 500
+
+// CheckAuthor — может ли пользователь писать этот текст: нет запрета комментировать
+// и нет запрещённых слов. Той же проверкой проходят аннотации
+func (s *CommentService) CheckAuthor(ctx context.Context, userID int, text string) error {
+	muted, err := s.repo.IsMuted(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if muted {
+		return repo_k.ErrMuted
+	}
+	if word, ok := s.filter.match(text); ok {
+		log.Printf("🔒 Комментарий пользователя %d отклонён фильтром: %q", userID, word)
+		return repo_k.ErrBannedWords
+	}
+	return nil
+}
+
+// Report — жалоба на комментарий документа docID
+func (s *CommentService) Report(ctx context.Context, docID, commentID, userID, roleID int, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len([]rune(reason)) > maxReasonLength {
+		return repo_k.ErrBadData
+	}
+	if err := s.document(ctx, docID, userID, roleID); err != nil {
+		return err
+	}
+
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.DocumentID != docID || comment.IsDeleted || comment.IsHidden {
+		return repo_k.ErrNotFound
+	}
+	if comment.UserID == userID {
+		return repo_k.ErrOwnComment
+	}
+
+	if err := s.repo.CreateReport(ctx, commentID, userID, reason); err != nil {
+		return err
+	}
+	log.Printf("⚠️ Жалоба на комментарий %d от пользователя %d", commentID, userID)
+	return nil
+}
+
+// Queue — комментарии с нерассмотренными жалобами
+func (s *CommentService) Queue(ctx context.Context, limit, offset int) (*models_k.ModerationQueue, error) {
+	if limit <= 0 {
+		limit = defaultThreadsPage
+	}
+	if limit > maxThreadsPage {
+		limit = maxThreadsPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	total, err := s.repo.CountReported(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.GetReported(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Comment.ID)
+	}
+	reports, err := s.repo.GetPendingReports(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Reports = reports[items[i].Comment.ID]
+	}
+	return &models_k.ModerationQueue{Items: items, Total: total}, nil
+}
+
+// Moderate применяет решение модератора к комментарию и закрывает жалобы на него
+func (s *CommentService) Moderate(ctx context.Context, commentID, moderatorID int, action, note string) error {
+	switch action {
+	case models_k.ActionDismiss, models_k.ActionHide, models_k.ActionDelete, models_k.ActionWarn:
+	default:
+		return repo_k.ErrUnknownAction
+	}
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > maxReasonLength {
+		return repo_k.ErrBadData
+	}
+
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Moderate(ctx, commentID, moderatorID, action, note); err != nil {
+		return err
+	}
+	log.Printf("🔒 Модератор %d: %s комментария %d", moderatorID, action, commentID)
+
+	switch action {
+	case models_k.ActionWarn:
+		if s.notifier != nil && comment.UserID != 0 {
+			if err := s.notifier.CommentWarning(ctx, comment.UserID, comment.DocumentID, commentID, moderatorID, note); err != nil {
+				log.Printf("⚠️ Не удалось отправить предупреждение пользователю %d: %v", comment.UserID, err)
+			}
+		}
+	case models_k.ActionHide:
+		if s.events != nil && !comment.IsHidden {
+			s.events.Publish(ctx, realtime.DocumentEvent(realtime.EventCommentHidden, comment.DocumentID, map[string]int{
+				"comment_id": commentID,
+			}))
+		}
+	case models_k.ActionDelete:
+		if s.events != nil && !comment.IsDeleted {
+			s.events.Publish(ctx, realtime.DocumentEvent(realtime.EventCommentDeleted, comment.DocumentID, map[string]int{
+				"comment_id": commentID,
+			}))
+		}
+	}
+	return nil
+}
+
+// Mute запрещает пользователю комментировать до until (nil — бессрочно)
+func (s *CommentService) Mute(ctx context.Context, userID, adminID int, reason string, until *time.Time) (*models_k.Mute, error) {
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxReasonLength || (until != nil && until.Before(time.Now())) {
+		return nil, repo_k.ErrBadData
+	}
+	m := &models_k.Mute{UserID: userID, MutedBy: adminID, Reason: reason, Until: until}
+	if err := s.repo.SetMute(ctx, m); err != nil {
+		return nil, err
+	}
+	log.Printf("🔒 Пользователю %d запрещено комментировать (администратор %d)", userID, adminID)
+	return m, nil
+}
+
+func (s *CommentService) Unmute(ctx context.Context, userID int) error {
+	return s.repo.RemoveMute(ctx, userID)
+}
+
+func (s *CommentService) Mutes(ctx context.Context) ([]models_k.Mute, error) {
+	return s.repo.ListMutes(ctx)
+}
@@ -3,24 +3,94 @@ package service_k
 import (
 	"cmd/internal/comments/models_k"
 	"cmd/internal/comments/repo_k"
+	"cmd/internal/reactions/models_re"
+	"cmd/internal/realtime"
 	"cmd/internal/storage"
 	"context"
+	"log"
+	"sort"
+	"time"
 )
 
+// MaxDepth — наибольшая вложенность ответа; корневой комментарий имеет глубину 0
+const MaxDepth = 4
+
+const (
+	defaultThreadsPage = 20
+	maxThreadsPage     = 100
+	defaultEditWindow  = 15 * time.Minute
+)
+
+// Notifier уведомляет упомянутых пользователей, автора документа и участников обсуждения
+// о новом комментарии; mentioned получают уведомление об упоминании вместо обычного.
+// CommentMentioned — только об упоминании, для тех, кого добавили правкой;
+// CommentWarning — предупреждение модератора автору комментария
+type Notifier interface {
+	CommentCreated(ctx context.Context, docID, commentID, authorID int, text string, mentioned []int) error
+	CommentMentioned(ctx context.Context, docID, commentID, authorID int, text string, mentioned []int) error
+	CommentWarning(ctx context.Context, userID, docID, commentID, moderatorID int, note string) error
+}
+
+// Reactions — реакции сразу для многих комментариев, без запроса на каждый
+type Reactions interface {
+	CommentReactions(ctx context.Context, commentIDs []int, viewerID int) (map[int][]models_re.Reaction, error)
+}
+
+type Config struct {
+	EditWindow  time.Duration // сколько после публикации автор может править комментарий; 0 — 15 минут
+	BannedWords []string      // запрещённые слова; «слово*» — все слова с этим началом
+}
+
 type CommentService struct {
-	repo      *repo_k.CommentStorage
-	s3Storage *storage.S3Storage
+	repo       *repo_k.CommentStorage
+	s3Storage  *storage.S3Storage
+	notifier   Notifier
+	events     realtime.Publisher
+	reactions  Reactions
+	editWindow time.Duration
+	filter     *wordFilter
 }
 
-func NewCommentService(r *repo_k.CommentStorage, s3Storage *storage.S3Storage) *CommentService {
-	return &CommentService{repo: r, s3Storage: s3Storage}
+func NewCommentService(r *repo_k.CommentStorage, s3Storage *storage.S3Storage, notifier Notifier, events realtime.Publisher, reactions Reactions, cfg Config) *CommentService {
+	if cfg.EditWindow <= 0 {
+		cfg.EditWindow = defaultEditWindow
+	}
+	return &CommentService{
+		repo:       r,
+		s3Storage:  s3Storage,
+		notifier:   notifier,
+		events:     events,
+		reactions:  reactions,
+		editWindow: cfg.EditWindow,
+		filter:     newWordFilter(cfg.BannedWords),
+	}
 }
 
-// Создать комментарий — userID передаём явно
-func (s *CommentService) Create(ctx context.Context, docID, userID int, text string) (*models_k.Comment, error) {
+// document проверяет, что документ виден пользователю: роль, согласование, дата публикации, архив.
+// Невидимый документ не отличить от несуществующего — ErrDocNotFound.
+func (s *CommentService) document(ctx context.Context, docID, userID, roleID int) error {
+	doc, err := s.repo.Document(ctx, docID)
+	if err != nil {
+		return err
+	}
+	if !doc.OpenTo(roleID, userID, time.Now()) {
+		return repo_k.ErrDocNotFound
+	}
+	return nil
+}
+
+// Создать комментарий — userID передаём явно; parentID задан для ответа
+func (s *CommentService) Create(ctx context.Context, docID, userID, roleID int, text string, parentID *int) (*models_k.Comment, error) {
 	if text == "" || len(text) > 1000 {
 		return nil, repo_k.ErrBadData
 	}
+	if err := s.document(ctx, docID, userID, roleID); err != nil {
+		return nil, err
+	}
+
+	if err := s.CheckAuthor(ctx, userID, text); err != nil {
+		return nil, err
+	}
 
 	c := &models_k.Comment{
 		DocumentID: docID,
@@ -28,35 +98,310 @@ func (s *CommentService) Create(ctx context.Context, docID, userID int, text str
 		Text:       text,
 	}
 
+	if parentID != nil {
+		parent, err := s.repo.GetByID(ctx, *parentID)
+		if err == repo_k.ErrNotFound {
+			return nil, repo_k.ErrBadParent
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.DocumentID != docID || parent.IsDeleted || parent.IsHidden {
+			return nil, repo_k.ErrBadParent
+		}
+		if parent.Depth >= MaxDepth {
+			return nil, repo_k.ErrTooDeep
+		}
+		threadID := parent.ID
+		if parent.ThreadID != nil {
+			threadID = *parent.ThreadID
+		}
+		c.ParentID = &parent.ID
+		c.ThreadID = &threadID
+		c.Depth = parent.Depth + 1
+	}
+
+	mentions, err := s.resolveMentions(ctx, docID, text)
+	if err != nil {
+		return nil, err
+	}
+	c.Mentions = mentions
+
 	if err := s.repo.Create(ctx, c); err != nil {
 		return nil, err
 	}
+
+	// Комментарий уже сохранён — ошибка рассылки только логируется
+	if s.notifier != nil {
+		mentioned := make([]int, 0, len(mentions))
+		for _, m := range mentions {
+			mentioned = append(mentioned, m.UserID)
+		}
+		if err := s.notifier.CommentCreated(ctx, docID, c.ID, userID, text, mentioned); err != nil {
+			log.Printf("⚠️ Не удалось разослать уведомления о комментарии %d: %v", c.ID, err)
+		}
+	}
+	s.publish(ctx, realtime.EventCommentCreated, docID, c)
 	return c, nil
 }
 
-func (s *CommentService) GetByDocument(ctx context.Context, docID int) ([]models_k.Comment, error) {
-	comments, err := s.repo.GetByDocument(ctx, docID)
+func (s *CommentService) publish(ctx context.Context, eventType string, docID int, c *models_k.Comment) {
+	if s.events != nil {
+		s.events.Publish(ctx, realtime.DocumentEvent(eventType, docID, models_k.Event{
+			ID:       c.ID,
+			ParentID: c.ParentID,
+			UserID:   c.UserID,
+			EditedAt: c.EditedAt,
+		}))
+	}
+}
+
+// resolveMentions находит упомянутых пользователей; неоднозначное упоминание
+// (например, двое с одинаковыми именем и фамилией) пропускается
+func (s *CommentService) resolveMentions(ctx context.Context, docID int, text string) ([]models_k.Mention, error) {
+	handles := parseMentions(text)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	found, err := s.repo.FindMentionable(ctx, docID, handles)
+	if err != nil {
+		return nil, err
+	}
+
+	var mentions []models_k.Mention
+	seen := make(map[int]bool)
+	for _, h := range handles {
+		users := found[h]
+		if len(users) != 1 || seen[users[0].UserID] {
+			continue
+		}
+		seen[users[0].UserID] = true
+		mentions = append(mentions, users[0])
+	}
+	return mentions, nil
+}
+
+// GetByDocument — все видимые комментарии списком, новые сверху; реакции — с отметками viewerID
+func (s *CommentService) GetByDocument(ctx context.Context, docID, viewerID, roleID int) ([]models_k.Comment, error) {
+	if err := s.document(ctx, docID, viewerID, roleID); err != nil {
+		return nil, err
+	}
+	all, err := s.repo.GetByDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.prepare(ctx, all, viewerID)
 	if err != nil {
 		return nil, err
 	}
 
-	if s.s3Storage != nil {
-		for i := range comments {
-			if comments[i].PhotoPath != nil {
-				if url, err := s.s3Storage.GenerateDownloadURL(*comments[i].PhotoPath); err == nil {
-					comments[i].PhotoPath = &url
-				}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.After(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// GetThreads — ветки обсуждения деревом: корневые комментарии новые сверху,
+// ответы в порядке написания; постранично по веткам верхнего уровня
+func (s *CommentService) GetThreads(ctx context.Context, docID, viewerID, roleID, limit, offset int) (*models_k.ThreadPage, error) {
+	if err := s.document(ctx, docID, viewerID, roleID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultThreadsPage
+	}
+	if limit > maxThreadsPage {
+		limit = maxThreadsPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	total, err := s.repo.CountThreads(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.repo.GetThreads(ctx, docID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.prepare(ctx, all, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return &models_k.ThreadPage{Items: buildTree(comments), Total: total}, nil
+}
+
+// prepare оставляет неудалённые комментарии, а удалённые и скрытые — если у них есть видимые ответы
+// (они становятся заглушками), и дополняет их упоминаниями, реакциями и ссылками на фото
+func (s *CommentService) prepare(ctx context.Context, all []models_k.Comment, viewerID int) ([]models_k.Comment, error) {
+	// Ответ всегда написан позже родителя: идём с конца, и потомки разобраны раньше предка
+	hasVisibleReplies := make(map[int]bool)
+	visible := make([]bool, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		c := all[i]
+		visible[i] = !(c.IsDeleted || c.IsHidden) || hasVisibleReplies[c.ID]
+		if visible[i] && c.ParentID != nil {
+			hasVisibleReplies[*c.ParentID] = true
+		}
+	}
+
+	comments := make([]models_k.Comment, 0, len(all))
+	var ids []int
+	for i, c := range all {
+		if !visible[i] {
+			continue
+		}
+		if c.IsDeleted || c.IsHidden {
+			c.Text = models_k.DeletedText
+			if !c.IsDeleted {
+				c.Text = models_k.HiddenText
 			}
+			c.UserID = 0
+			c.FirstName = ""
+			c.LastName = ""
+			c.PhotoPath = nil
+			c.EditedAt = nil
+		} else {
+			ids = append(ids, c.ID)
 		}
+		comments = append(comments, c)
 	}
 
+	mentions, err := s.repo.GetMentions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	reactions := map[int][]models_re.Reaction{}
+	if s.reactions != nil {
+		if reactions, err = s.reactions.CommentReactions(ctx, ids, viewerID); err != nil {
+			return nil, err
+		}
+	}
+	for i := range comments {
+		comments[i].Mentions = mentions[comments[i].ID]
+		comments[i].Reactions = reactions[comments[i].ID]
+		if s.s3Storage != nil && comments[i].PhotoPath != nil {
+			if url, err := s.s3Storage.GenerateDownloadURL(*comments[i].PhotoPath); err == nil {
+				comments[i].PhotoPath = &url
+			}
+		}
+	}
 	return comments, nil
 }
 
+// buildTree собирает дерево из комментариев в порядке написания
+func buildTree(comments []models_k.Comment) []*models_k.Comment {
+	nodes := make(map[int]*models_k.Comment, len(comments))
+	for i := range comments {
+		nodes[comments[i].ID] = &comments[i]
+	}
+
+	roots := make([]*models_k.Comment, 0)
+	for i := range comments {
+		c := &comments[i]
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		if parent, ok := nodes[*c.ParentID]; ok {
+			parent.Replies = append(parent.Replies, c)
+		}
+	}
+
+	// Ветки новые сверху
+	for i, j := 0, len(roots)-1; i < j; i, j = i+1, j-1 {
+		roots[i], roots[j] = roots[j], roots[i]
+	}
+	return roots
+}
+
 func (s *CommentService) GetByID(ctx context.Context, id int) (*models_k.Comment, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
+// Update меняет текст комментария документа docID: автор — в пределах окна правки,
+// администратор — в любое время. Прежний текст попадает в историю правок
+func (s *CommentService) Update(ctx context.Context, docID, commentID, userID, roleID int, text string) (*models_k.Comment, error) {
+	if text == "" || len(text) > 1000 {
+		return nil, repo_k.ErrBadData
+	}
+	if err := s.document(ctx, docID, userID, roleID); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.DocumentID != docID || comment.IsDeleted || comment.IsHidden {
+		return nil, repo_k.ErrNotFound
+	}
+	if roleID != 1 {
+		if comment.UserID != userID {
+			return nil, repo_k.ErrForbidden
+		}
+		if time.Since(comment.CreatedAt) > s.editWindow {
+			return nil, repo_k.ErrEditTime
+		}
+	}
+	if comment.Text == text {
+		return comment, nil
+	}
+	if err := s.CheckAuthor(ctx, userID, text); err != nil {
+		return nil, err
+	}
+
+	before, err := s.repo.GetMentions(ctx, []int{commentID})
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.resolveMentions(ctx, docID, text)
+	if err != nil {
+		return nil, err
+	}
+	comment.Text = text
+	comment.Mentions = mentions
+
+	if err := s.repo.UpdateText(ctx, comment, userID); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Комментарий %d изменён пользователем %d", commentID, userID)
+
+	// Уведомляем только тех, кого упомянули впервые
+	if s.notifier != nil {
+		already := make(map[int]bool)
+		for _, m := range before[commentID] {
+			already[m.UserID] = true
+		}
+		var added []int
+		for _, m := range mentions {
+			if !already[m.UserID] {
+				added = append(added, m.UserID)
+			}
+		}
+		if len(added) > 0 {
+			if err := s.notifier.CommentMentioned(ctx, docID, commentID, comment.UserID, text, added); err != nil {
+				log.Printf("⚠️ Не удалось разослать уведомления об упоминании в комментарии %d: %v", commentID, err)
+			}
+		}
+	}
+	s.publish(ctx, realtime.EventCommentUpdated, docID, comment)
+	return comment, nil
+}
+
+// Revisions — история правок комментария документа docID
+func (s *CommentService) Revisions(ctx context.Context, docID, commentID int) ([]models_k.Revision, error) {
+	comment, err := s.repo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.DocumentID != docID {
+		return nil, repo_k.ErrNotFound
+	}
+	return s.repo.GetRevisions(ctx, commentID)
+}
+
 // Удалить с проверкой прав
 func (s *CommentService) Delete(ctx context.Context, commentID, userID, roleID int) error {
 	comment, err := s.repo.GetByID(ctx, commentID)
@@ -68,5 +413,13 @@ func (s *CommentService) Delete(ctx context.Context, commentID, userID, roleID i
 		return repo_k.ErrForbidden
 	}
 
-	return s.repo.SoftDelete(ctx, commentID)
+	if err := s.repo.SoftDelete(ctx, commentID); err != nil {
+		return err
+	}
+	if s.events != nil {
+		s.events.Publish(ctx, realtime.DocumentEvent(realtime.EventCommentDeleted, comment.DocumentID, map[string]int{
+			"comment_id": commentID,
+		}))
+	}
+	return nil
 }
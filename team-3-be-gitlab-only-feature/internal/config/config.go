@@ -19,6 +19,10 @@ type Config struct {
 		Port        int           `config:"server_port" yaml:"server_port"`
 		Timeout     time.Duration `config:"timeout" yaml:"timeout"`
 		IdleTimeout time.Duration `config:"idle_timeout" yaml:"idle_timeout"`
+		// StreamHeartbeat — период пустых сообщений в потоке событий SSE (по умолчанию 25s)
+		StreamHeartbeat time.Duration `config:"stream_heartbeat" yaml:"stream_heartbeat"`
+		// PublicURL — адрес фронтенда, на страницы которого ведут ссылки из писем
+		PublicURL string `config:"public_url" yaml:"public_url" env:"PUBLIC_URL"`
 	} `config:"http_server" yaml:"http_server"`
 	Data struct {
 		PostgreSQl struct {
@@ -31,16 +35,90 @@ type Config struct {
 		} `config:"postgresql" yaml:"postgresql"`
 	} `config:"database" yaml:"database"`
 	Auth struct {
-		JWTSecret string `yaml:"jwt_secret" env:"JWT_SECRET"`
+		JWTSecret           string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+		LegacyUntil         string        `yaml:"legacy_until"` // RFC 3339: момент перехода на ключи с kid; без него jwt_secret должен быть пуст
+		AccessTTL           time.Duration `yaml:"access_ttl"`
+		RefreshTTL          time.Duration `yaml:"refresh_ttl"`
+		SigningAlgorithm    string        `yaml:"signing_algorithm"`     // EdDSA или RS256
+		KeyRotationInterval time.Duration `yaml:"key_rotation_interval"` // как часто выпускать новый ключ подписи
+		KeyGracePeriod      time.Duration `yaml:"key_grace_period"`      // сколько принимать токены выведенного ключа
+		SigningKeySecret    string        `yaml:"signing_key_secret" env:"SIGNING_KEY_SECRET"`
+		InviteOnly          bool          `yaml:"invite_only"`       // публичная регистрация выключена
+		InviteTTL           time.Duration `yaml:"invite_ttl"`        // срок действия ссылки из приглашения
+		TokenVersionTTL     time.Duration `yaml:"token_version_ttl"` // сколько версия токенов живёт в кэше без явной инвалидации
 	}
+	OIDC struct {
+		Enabled             bool           `yaml:"enabled"`
+		Issuer              string         `yaml:"issuer"`
+		ClientID            string         `yaml:"client_id"`
+		ClientSecret        string         `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+		RedirectURL         string         `yaml:"redirect_url"` // адрес нашего /api/v1/auth/oidc/callback
+		Scopes              []string       `yaml:"scopes"`
+		GroupsClaim         string         `yaml:"groups_claim"`          // claim с группами, например groups или realm_access.roles
+		GroupRoles          map[string]int `yaml:"group_roles"`           // группа провайдера → role_id
+		DefaultRoleID       int            `yaml:"default_role_id"`       // роль новых пользователей без сопоставленных групп
+		FrontendRedirectURL string         `yaml:"frontend_redirect_url"` // куда вернуть пользователя с токенами
+	} `yaml:"oidc"`
+	LDAP struct {
+		Enabled            bool           `yaml:"enabled"`
+		URL                string         `yaml:"url"`
+		StartTLS           bool           `yaml:"start_tls"`
+		InsecureSkipVerify bool           `yaml:"insecure_skip_verify"`
+		BindDN             string         `yaml:"bind_dn"`
+		BindPassword       string         `yaml:"bind_password" env:"LDAP_BIND_PASSWORD"`
+		BaseDN             string         `yaml:"base_dn"`
+		UserFilter         string         `yaml:"user_filter"` // %s — email
+		SyncFilter         string         `yaml:"sync_filter"`
+		SubjectAttr        string         `yaml:"subject_attr"`  // objectGUID для AD, entryUUID для OpenLDAP
+		GroupRoles         map[string]int `yaml:"group_roles"`   // CN или DN группы → role_id
+		SyncInterval       time.Duration  `yaml:"sync_interval"` // 0 — плановая синхронизация выключена
+		SyncDryRun         bool           `yaml:"sync_dry_run"`  // плановая синхронизация только строит отчёт
+	} `yaml:"ldap"`
 	Email struct {
-		SMTPHost     string `config:"smtp_host" yaml:"smtp_host"`
-		SMTPPort     int    `config:"smtp_port" yaml:"smtp_port"`
-		SMTPUsername string `config:"smtp_username" yaml:"smtp_username"`
-		SMTPPassword string `config:"smtp_password" yaml:"smtp_password"`
-		FromEmail    string `config:"from_email" yaml:"from_email"`
-		FromName     string `config:"from_name" yaml:"from_name"`
+		// Transport — smtp, file (maildir для локальной разработки), memory или http (API провайдера)
+		Transport              string `config:"transport" yaml:"transport"`
+		SMTPHost               string `config:"smtp_host" yaml:"smtp_host"`
+		SMTPPort               int    `config:"smtp_port" yaml:"smtp_port"`
+		SMTPUsername           string `config:"smtp_username" yaml:"smtp_username"` // пусто — без авторизации
+		SMTPPassword           string `config:"smtp_password" yaml:"smtp_password" env:"SMTP_PASSWORD"`
+		SMTPTLS                string `config:"smtp_tls" yaml:"smtp_tls"` // starttls (по умолчанию), tls или none
+		SMTPInsecureSkipVerify bool   `config:"smtp_insecure_skip_verify" yaml:"smtp_insecure_skip_verify"`
+		FileDir                string `config:"file_dir" yaml:"file_dir"`
+		HTTPURL                string `config:"http_url" yaml:"http_url"`
+		HTTPAPIKey             string `config:"http_api_key" yaml:"http_api_key" env:"EMAIL_API_KEY"`
+		FromEmail              string `config:"from_email" yaml:"from_email"`
+		FromName               string `config:"from_name" yaml:"from_name"`
+		// TemplatesDir — каталог с переопределениями шаблонов (<язык>/<тип>.tmpl); пусто — только встроенные
+		TemplatesDir    string `config:"templates_dir" yaml:"templates_dir"`
+		DefaultLanguage string `config:"default_language" yaml:"default_language"`
+		// Очередь исходящих писем
+		RateLimits  map[string]int `config:"rate_limits" yaml:"rate_limits"`   // писем в минуту на все экземпляры: smtp_host → лимит, "default" — остальные
+		MaxAttempts int            `config:"max_attempts" yaml:"max_attempts"` // после стольких неудач письмо уходит в dead
 	} `config:"email" yaml:"email"`
+	Comments struct {
+		EditWindow  time.Duration `config:"edit_window" yaml:"edit_window"`   // сколько автор может править комментарий, по умолчанию 15m
+		BannedWords []string      `config:"banned_words" yaml:"banned_words"` // «слово*» запрещает все слова с этим началом
+	} `config:"comments" yaml:"comments"`
+	Reactions struct {
+		Allowed []string `config:"allowed" yaml:"allowed"` // набор реакций в порядке показа; пусто — набор по умолчанию
+	} `config:"reactions" yaml:"reactions"`
+	Documents struct {
+		SchedulerInterval  time.Duration `config:"scheduler_interval" yaml:"scheduler_interval"`     // как часто проверять publish_at и expires_at, по умолчанию 1m
+		TrashRetentionDays int           `config:"trash_retention_days" yaml:"trash_retention_days"` // сколько дней удалённые документы лежат в корзине, по умолчанию 30
+		PurgeInterval      time.Duration `config:"purge_interval" yaml:"purge_interval"`             // как часто очищать корзину, по умолчанию 24h
+		PurgeDryRun        bool          `config:"purge_dry_run" yaml:"purge_dry_run"`               // плановая очистка только строит отчёт
+	} `config:"documents" yaml:"documents"`
+	Approval struct {
+		// Enabled — документы создаются черновиками и публикуются после согласования; выключено — сразу
+		Enabled       bool  `config:"enabled" yaml:"enabled"`
+		ApproverRoles []int `config:"approver_roles" yaml:"approver_roles"` // согласующие по умолчанию: любой пользователь каждой роли
+		ApproverUsers []int `config:"approver_users" yaml:"approver_users"` // и конкретные пользователи
+	} `config:"approval" yaml:"approval"`
+	Events struct {
+		// Bus — postgres (LISTEN/NOTIFY, нужен при нескольких инстансах) или memory (один инстанс)
+		Bus     string `config:"bus" yaml:"bus" env:"EVENT_BUS"`
+		Channel string `config:"channel" yaml:"channel"` // канал NOTIFY, по умолчанию dochub_events
+	} `config:"events" yaml:"events"`
 	S3 struct {
 		Bucket          string `config:"bucket" yaml:"bucket"`
 		Region          string `config:"region" yaml:"region"`
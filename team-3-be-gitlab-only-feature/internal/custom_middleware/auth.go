@@ -5,6 +5,7 @@ import (
 	"cmd/internal/user/service"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 )
@@ -34,8 +35,15 @@ func GetCurrentUser(r *http.Request) (*models.User, bool) {
 	return GetUserFromContext(r.Context())
 }
 
+// SessionChecker — проверка, что токен не отозван (смена роли, удаление, "выйти везде"),
+// и проверка персональных токенов доступа
+type SessionChecker interface {
+	CheckTokenVersion(ctx context.Context, userID, tokenVersion int) error
+	AuthenticateAPIToken(ctx context.Context, raw string) (*models.User, []string, error)
+}
+
 // AuthMiddleware — кладёт пользователя в контекст
-func AuthMiddleware(jwtService *service.JWTService) func(next http.Handler) http.Handler {
+func AuthMiddleware(jwtService *service.JWTService, sessions SessionChecker) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if isPublicEndpoint(r.URL.Path) {
@@ -43,25 +51,82 @@ func AuthMiddleware(jwtService *service.JWTService) func(next http.Handler) http
 				return
 			}
 
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			tokenStr, ok := bearerToken(r)
+			if !ok {
 				respondJSONError(w, "UNAUTHORIZED", "Требуется авторизация", http.StatusUnauthorized)
 				return
 			}
 
-			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+			if strings.HasPrefix(tokenStr, service.PersonalTokenPrefix) {
+				servePersonalToken(w, r, next, sessions, tokenStr)
+				return
+			}
+
 			user, err := jwtService.GetUserFromToken(tokenStr)
 			if err != nil {
 				respondJSONError(w, "UNAUTHORIZED", "Неверный токен", http.StatusUnauthorized)
 				return
 			}
 
+			if err := sessions.CheckTokenVersion(r.Context(), user.ID, user.TokenVersion); err != nil {
+				if errors.Is(err, service.ErrTokenRevoked) {
+					respondJSONError(w, "TOKEN_REVOKED", "Сессия недействительна, войдите заново", http.StatusUnauthorized)
+					return
+				}
+				respondJSONError(w, "INTERNAL_ERROR", "Не удалось проверить сессию", http.StatusInternalServerError)
+				return
+			}
+
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// bearerToken — токен из заголовка Authorization. EventSource в браузере не умеет задавать заголовки,
+// поэтому потоку событий (Accept: text/event-stream) токен можно передать параметром access_token.
+func bearerToken(r *http.Request) (string, bool) {
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer "), true
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		if token := r.URL.Query().Get("access_token"); token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// servePersonalToken — авторизация персональным токеном с проверкой области действия
+func servePersonalToken(w http.ResponseWriter, r *http.Request, next http.Handler, sessions SessionChecker, raw string) {
+	user, scopes, err := sessions.AuthenticateAPIToken(r.Context(), raw)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPersonalTokenInvalid):
+			respondJSONError(w, "UNAUTHORIZED", "Неверный или просроченный токен", http.StatusUnauthorized)
+		case errors.Is(err, service.ErrTokenRevoked):
+			respondJSONError(w, "TOKEN_REVOKED", "Сессия недействительна, войдите заново", http.StatusUnauthorized)
+		default:
+			respondJSONError(w, "INTERNAL_ERROR", "Не удалось проверить токен", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	required, allowed := requiredScope(r)
+	if !allowed {
+		respondJSONError(w, "FORBIDDEN", "Операция недоступна для персональных токенов", http.StatusForbidden)
+		return
+	}
+	if !hasScope(scopes, required) {
+		respondJSONError(w, "INSUFFICIENT_SCOPE", "У токена нет области действия "+required, http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), UserContextKey, user)
+	ctx = context.WithValue(ctx, TokenScopesKey, scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // isPublicEndpoint проверяет, является ли эндпоинт публичным
 func isPublicEndpoint(path string) bool {
 	publicEndpoints := []string{
@@ -0,0 +1,71 @@
+package custommiddleware
+
+import (
+	"cmd/internal/user/service"
+	"context"
+	"net/http"
+	"strings"
+)
+
+const TokenScopesKey contextKey = "token_scopes"
+
+// GetTokenScopes — области действия персонального токена; ok=false для обычной JWT сессии
+func GetTokenScopes(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(TokenScopesKey).([]string)
+	return scopes, ok
+}
+
+// personalTokenDenied — маршруты, закрытые для персональных токенов при любой области действия:
+// управление самими токенами, смена email, администрирование пользователей, ролей, корзины и модерация
+var personalTokenDenied = []string{
+	"/api/v1/user/tokens",
+	"/api/v1/user/email",
+	"/api/v1/user/admin",
+	"/api/v1/user/roles",
+	"/api/v1/admin",
+	"/api/v1/moderation",
+}
+
+// requiredScope определяет, какая область действия нужна персональному токену для запроса.
+// allowed=false — запрос недоступен персональным токенам вовсе (см. personalTokenDenied).
+func requiredScope(r *http.Request) (scope string, allowed bool) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	for _, prefix := range personalTokenDenied {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return "", false
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return service.ScopeRead, true
+	}
+
+	switch {
+	case path == "/api/v1/documents":
+		return service.ScopeDocumentsWrite, true
+	case strings.HasPrefix(path, "/api/v1/documents/") &&
+		(strings.HasSuffix(path, "/submit") || strings.HasSuffix(path, "/withdraw") ||
+			strings.HasSuffix(path, "/approve") || strings.HasSuffix(path, "/reject") ||
+			strings.HasSuffix(path, "/schedule") || strings.HasSuffix(path, "/restore")):
+		return service.ScopeDocumentsWrite, true
+	case strings.HasPrefix(path, "/api/v1/documents/") &&
+		(strings.Contains(path, "/comments") || strings.HasSuffix(path, "/like") || strings.HasSuffix(path, "/view") ||
+			strings.HasSuffix(path, "/reactions") || strings.Contains(path, "/annotations")):
+		return service.ScopeCommentsWrite, true
+	case path == "/api/v1/user/profile" || path == "/api/v1/user/photo" || path == "/api/v1/user/avatar/generate":
+		return service.ScopeProfileWrite, true
+	}
+	return service.ScopeWrite, true
+}
+
+// hasScope — scope "write" покрывает любые изменяющие области, но не заменяет "read"
+func hasScope(scopes []string, required string) bool {
+	for _, s := range scopes {
+		if s == required || (s == service.ScopeWrite && required != service.ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
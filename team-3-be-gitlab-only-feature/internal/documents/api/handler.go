@@ -59,6 +59,13 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		Title          string `json:"title"`
 		CategoryID     int    `json:"category_id"`
 		AccessibleRole int    `json:"accessible_role"` // 1-5
+		// При включённом согласовании документ создаётся черновиком; submit — сразу отправить
+		// на согласование, approvers — свои согласующие вместо заданных в конфигурации
+		Submit    bool               `json:"submit"`
+		Approvers models.ApproverSet `json:"approvers"`
+		// Необязательные сроки (RFC 3339): с какого момента документ виден и когда уходит в архив
+		PublishAt *time.Time `json:"publish_at"`
+		ExpiresAt *time.Time `json:"expires_at"`
 	}
 
 	if err := json.Unmarshal([]byte(metadata), &input); err != nil {
@@ -78,6 +85,10 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "accessible_role должен быть от 1 до 5", http.StatusBadRequest)
 		return
 	}
+	if err := service.ValidateSchedule(input.PublishAt, input.ExpiresAt); err != nil {
+		http.Error(w, "expires_at должен быть в будущем и позже publish_at", http.StatusBadRequest)
+		return
+	}
 
 	file, handler, err := r.FormFile("file")
 	if err != nil {
@@ -105,10 +116,13 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Создаём документ в БД с S3 ключом
 	doc := &models.Document{
-		Title:   input.Title,
-		PDFPath: s3Key, // Сохраняем S3 ключ
-		UserID:  &user.ID,
-		RoleID:  user.RoleID,
+		Title:     input.Title,
+		PDFPath:   s3Key, // Сохраняем S3 ключ
+		UserID:    &user.ID,
+		RoleID:    user.RoleID,
+		Status:    h.service.InitialStatus(),
+		PublishAt: input.PublishAt,
+		ExpiresAt: input.ExpiresAt,
 	}
 
 	doc.Category.ID = input.CategoryID
@@ -123,11 +137,24 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	status := doc.Status
+	if status == models.StatusApproved {
+		h.service.NotifyPublished(r.Context(), doc)
+	} else if input.Submit {
+		// Документ уже сохранён черновиком: при ошибке отправки его можно отправить повторно
+		if _, err := h.service.Submit(r.Context(), doc.ID, user.ID, user.RoleID, input.Approvers); err != nil {
+			writeWorkflowError(w, err)
+			return
+		}
+		status = models.StatusInReview
+	}
+
 	// Успешный ответ - файл уже загружен
 	respondJSON(w, map[string]any{
 		"status":          "success",
 		"doc_id":          doc.ID,
 		"accessible_role": input.AccessibleRole,
+		"document_status": status,
 	}, http.StatusCreated)
 }
 
@@ -0,0 +1,70 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/documents/repo"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetSchedule — задать сроки публикации и действия; null снимает ограничение
+func (h *Handler) SetSchedule(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var in struct {
+		PublishAt *time.Time `json:"publish_at"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный формат запроса: даты в RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := h.service.SetSchedule(r.Context(), id, user.ID, user.RoleID, in.PublishAt, in.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrBadData):
+			http.Error(w, "expires_at должен быть в будущем и позже publish_at", http.StatusBadRequest)
+		case errors.Is(err, repo.ErrNotFound):
+			http.Error(w, "Документ не найден", http.StatusNotFound)
+		case errors.Is(err, repo.ErrForbidden):
+			http.Error(w, "Сроки меняет автор или администратор", http.StatusForbidden)
+		default:
+			http.Error(w, "Ошибка изменения сроков", http.StatusInternalServerError)
+		}
+		return
+	}
+	respondJSON(w, doc, http.StatusOK)
+}
+
+// Archive — поиск по архиву истёкших документов (?q=, limit, offset); только администратор
+func (h *Handler) Archive(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	docs, total, err := h.service.SearchArchived(r.Context(), q.Get("q"), limit, offset)
+	if err != nil {
+		http.Error(w, "Ошибка поиска по архиву", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]any{
+		"documents": docs,
+		"count":     len(docs),
+		"total":     total,
+	}, http.StatusOK)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/documents/repo"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Trash — корзина: удалённые документы и когда они будут удалены окончательно (limit, offset)
+func (h *Handler) Trash(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	items, total, err := h.service.ListTrash(r.Context(), limit, offset)
+	if err != nil {
+		http.Error(w, "Ошибка получения корзины", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]any{
+		"documents": items,
+		"count":     len(items),
+		"total":     total,
+	}, http.StatusOK)
+}
+
+// Restore — вернуть документ из корзины (только админ)
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Restore(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			http.Error(w, "Документа нет в корзине", http.StatusNotFound)
+		} else {
+			http.Error(w, "Ошибка восстановления", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	doc, err := h.service.GetDocumentByID(r.Context(), id, user.RoleID, user.ID)
+	if err != nil {
+		// Восстановлен, но не виден в ленте: например, истёк срок действия
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	respondJSON(w, doc, http.StatusOK)
+}
+
+// PurgeTrash — окончательно удалить документы старше срока хранения; ?dry_run=true только строит отчёт
+func (h *Handler) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Параметр dry_run должен быть true или false", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	report, err := h.service.PurgeTrash(r.Context(), dryRun)
+	if err != nil {
+		http.Error(w, "Ошибка очистки корзины", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, report, http.StatusOK)
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/documents/models"
+	"cmd/internal/documents/repo"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Workflow — статус согласования, согласующие и журнал переходов
+func (h *Handler) Workflow(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	wf, err := h.service.GetWorkflow(r.Context(), id, user.RoleID, user.ID)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	respondJSON(w, wf, http.StatusOK)
+}
+
+// Submit — отправить на согласование; тело {"approvers": {"user_ids": [], "role_ids": []}} необязательно
+func (h *Handler) Submit(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var in struct {
+		Approvers models.ApproverSet `json:"approvers"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+			return
+		}
+	}
+
+	wf, err := h.service.Submit(r.Context(), id, user.ID, user.RoleID, in.Approvers)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	respondJSON(w, wf, http.StatusOK)
+}
+
+// Withdraw — вернуть документ с согласования в черновики
+func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	wf, err := h.service.Withdraw(r.Context(), id, user.ID, user.RoleID)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	respondJSON(w, wf, http.StatusOK)
+}
+
+// Approve — одобрить; комментарий необязателен
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, models.ActionApprove)
+}
+
+// Reject — отклонить с обязательным комментарием
+func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, models.ActionReject)
+}
+
+func (h *Handler) decide(w http.ResponseWriter, r *http.Request, action string) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var in struct {
+		Comment string `json:"comment"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var wf *models.Workflow
+	if action == models.ActionReject {
+		wf, err = h.service.Reject(r.Context(), id, user.ID, user.RoleID, in.Comment)
+	} else {
+		wf, err = h.service.Approve(r.Context(), id, user.ID, user.RoleID, in.Comment)
+	}
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	respondJSON(w, wf, http.StatusOK)
+}
+
+// ReviewQueue — документы, ожидающие решения текущего пользователя
+func (h *Handler) ReviewQueue(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := h.service.ReviewQueue(r.Context(), user.ID, user.RoleID)
+	if err != nil {
+		http.Error(w, "Ошибка получения очереди согласования", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]any{"documents": items, "count": len(items)}, http.StatusOK)
+}
+
+// Unpublished — свои черновики, документы на согласовании и отклонённые (администратор — все)
+func (h *Handler) Unpublished(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Неавторизован", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := h.service.Unpublished(r.Context(), user.ID, user.RoleID)
+	if err != nil {
+		http.Error(w, "Ошибка получения черновиков", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]any{"documents": items, "count": len(items)}, http.StatusOK)
+}
+
+func writeWorkflowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		http.Error(w, "Документ не найден", http.StatusNotFound)
+	case errors.Is(err, repo.ErrForbidden):
+		http.Error(w, "Доступ запрещён", http.StatusForbidden)
+	case errors.Is(err, repo.ErrBadData):
+		http.Error(w, "Неверные согласующие или комментарий: отказ требует комментария, автор не может согласовывать свой документ", http.StatusBadRequest)
+	case errors.Is(err, repo.ErrBadTransition):
+		http.Error(w, "Действие недоступно в текущем статусе документа", http.StatusConflict)
+	case errors.Is(err, repo.ErrNotApprover):
+		http.Error(w, "Вы не согласующий этого документа", http.StatusForbidden)
+	case errors.Is(err, repo.ErrAlreadyDecided):
+		http.Error(w, "Вы уже приняли решение по документу", http.StatusConflict)
+	default:
+		http.Error(w, "Ошибка согласования", http.StatusInternalServerError)
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"cmd/internal/reactions/models_re"
+	"time"
+)
 
 type Document struct {
 	ID        int        `json:"id"`
@@ -8,12 +11,17 @@ type Document struct {
 	PDFPath   string     `json:"pdf_path"`
 	UserID    *int       `json:"user_id,omitempty"`
 	RoleID    int        `json:"role_id"`
+	Status    string     `json:"status"`
 	CreatedAt time.Time  `json:"created_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
-	FirstName string     `json:"first_name"`
-	LastName  string     `json:"last_name"`
-	PhotoPath string     `json:"photo_path"`
-	Category  struct {
+	// Документ виден с PublishAt до ExpiresAt; истёкший попадает в архив (ArchivedAt)
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	PhotoPath  string     `json:"photo_path"`
+	Category   struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"category"`
@@ -22,9 +30,10 @@ type Document struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"accessible_role"`
-	AccessibleRoles []int `json:"accessible_roles,omitempty"`
-	LikesCount      int   `json:"likes_count"`
-	CommentsCount   int   `json:"comments_count"`
-	IsViewed        bool  `json:"is_viewed"`
-	IsLiked         bool  `json:"is_liked"`
+	AccessibleRoles []int                `json:"accessible_roles,omitempty"`
+	LikesCount      int                  `json:"likes_count"`
+	CommentsCount   int                  `json:"comments_count"`
+	IsViewed        bool                 `json:"is_viewed"`
+	IsLiked         bool                 `json:"is_liked"`
+	Reactions       []models_re.Reaction `json:"reactions"`
 }
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Что произошло (или произошло бы в dry-run) с документом при очистке корзины
+const (
+	PurgeActionPurged = "purged"
+	PurgeActionFailed = "failed"
+)
+
+// TrashItem — удалённый документ в корзине
+type TrashItem struct {
+	DocumentID int       `json:"document_id"`
+	Title      string    `json:"title"`
+	PDFPath    string    `json:"pdf_path"`
+	AuthorID   *int      `json:"author_id,omitempty"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAt    time.Time `json:"purge_at"` // когда документ будет удалён окончательно
+}
+
+// PurgeReport — итог очистки корзины
+type PurgeReport struct {
+	DryRun       bool         `json:"dry_run"`
+	Cutoff       time.Time    `json:"cutoff"` // удаляются документы, удалённые раньше этого момента
+	StartedAt    time.Time    `json:"started_at"`
+	FinishedAt   time.Time    `json:"finished_at"`
+	Total        int          `json:"total"`
+	Purged       int          `json:"purged"`
+	FilesDeleted int          `json:"files_deleted"`
+	Failed       int          `json:"failed"`
+	Items        []PurgedItem `json:"items"`
+	Errors       []string     `json:"errors"`
+}
+
+// PurgedItem — один документ в отчёте очистки
+type PurgedItem struct {
+	DocumentID  int       `json:"document_id"`
+	Title       string    `json:"title"`
+	PDFPath     string    `json:"pdf_path"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	Action      string    `json:"action"`
+	FileDeleted bool      `json:"file_deleted"` // false — файл общий с другим документом или его нет
+	Error       string    `json:"error,omitempty"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// Статусы согласования; в ленте только одобренные
+const (
+	StatusDraft    = "draft"
+	StatusInReview = "in_review"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Действия над документом в процессе согласования
+const (
+	ActionSubmit   = "submit"   // отправить на согласование
+	ActionWithdraw = "withdraw" // вернуть в черновики
+	ActionApprove  = "approve"
+	ActionReject   = "reject"
+)
+
+// transitions — допустимые переходы: статус → действие → новый статус.
+// approve переводит в approved только последнее из нужных одобрений, до этого документ остаётся in_review
+var transitions = map[string]map[string]string{
+	StatusDraft:    {ActionSubmit: StatusInReview},
+	StatusRejected: {ActionSubmit: StatusInReview},
+	StatusInReview: {
+		ActionWithdraw: StatusDraft,
+		ActionApprove:  StatusApproved,
+		ActionReject:   StatusRejected,
+	},
+}
+
+// NextStatus — статус после действия; false, если из этого статуса действие недопустимо
+func NextStatus(from, action string) (string, bool) {
+	to, ok := transitions[from][action]
+	return to, ok
+}
+
+// Approver — согласующий: конкретный пользователь или любой пользователь роли
+type Approver struct {
+	ID        int        `json:"id"`
+	UserID    *int       `json:"user_id,omitempty"`
+	RoleID    *int       `json:"role_id,omitempty"`
+	Name      string     `json:"name"` // имя пользователя или название роли
+	Decision  *string    `json:"decision"`
+	DecidedBy *int       `json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+}
+
+// Transition — запись журнала: переход или решение согласующего
+type Transition struct {
+	ID        int       `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Action    string    `json:"action"`
+	UserID    *int      `json:"user_id,omitempty"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Workflow — состояние согласования документа
+type Workflow struct {
+	DocumentID int          `json:"document_id"`
+	Title      string       `json:"title"`
+	AuthorID   int          `json:"author_id"`
+	Status     string       `json:"status"`
+	Approvers  []Approver   `json:"approvers"`
+	History    []Transition `json:"history"`
+}
+
+// ApproverSet — кого назначить согласующими при отправке
+type ApproverSet struct {
+	UserIDs []int `json:"user_ids"`
+	RoleIDs []int `json:"role_ids"`
+}
+
+// WorkflowItem — документ в очереди согласования или в черновиках
+type WorkflowItem struct {
+	DocumentID int       `json:"document_id"`
+	Title      string    `json:"title"`
+	AuthorID   int       `json:"author_id"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"` // время последнего перехода
+}
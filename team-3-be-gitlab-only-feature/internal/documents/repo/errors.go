@@ -9,3 +9,10 @@ var (
 	ErrDBFailure = errors.New("database error")
 	ErrForbidden = errors.New("forbidden")
 )
+
+// Согласование
+var (
+	ErrBadTransition  = errors.New("status transition not allowed")
+	ErrNotApprover    = errors.New("user is not an approver of the document")
+	ErrAlreadyDecided = errors.New("user already decided on the document")
+)
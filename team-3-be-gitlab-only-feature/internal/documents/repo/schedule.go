@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"cmd/internal/documents/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// MarkPublished отмечает, что о публикации объявлено; false — документ ещё не виден
+// (не одобрен или publish_at в будущем) либо о нём уже объявили
+func (s *Storage) MarkPublished(ctx context.Context, docID int) (bool, error) {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE documents SET published_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND published_at IS NULL
+		  AND status = 'approved' AND (publish_at IS NULL OR publish_at <= NOW())`, docID)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClaimDuePublications отмечает опубликованными документы, чей publish_at наступил, и возвращает их.
+// UPDATE ... RETURNING забирает каждый документ ровно одним инстансом.
+func (s *Storage) ClaimDuePublications(ctx context.Context, limit int) ([]*models.Document, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		UPDATE documents SET published_at = NOW()
+		WHERE id IN (
+			SELECT id FROM documents
+			WHERE published_at IS NULL AND deleted_at IS NULL
+			  AND status = 'approved' AND publish_at <= NOW()
+			  AND (expires_at IS NULL OR expires_at > NOW())
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, title, user_id`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	var docs []*models.Document
+	for rows.Next() {
+		var doc models.Document
+		if err := rows.Scan(&doc.ID, &doc.Title, &doc.UserID); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		docs = append(docs, &doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return docs, nil
+}
+
+// ArchiveExpired переводит в архив документы с истёкшим сроком и возвращает их ID
+func (s *Storage) ArchiveExpired(ctx context.Context) ([]int, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		UPDATE documents SET archived_at = NOW()
+		WHERE archived_at IS NULL AND deleted_at IS NULL AND expires_at <= NOW()
+		RETURNING id`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return ids, nil
+}
+
+// SetSchedule меняет сроки документа; продлённый документ возвращается из архива
+func (s *Storage) SetSchedule(ctx context.Context, docID int, publishAt, expiresAt *time.Time) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE documents
+		SET publish_at = $2, expires_at = $3,
+		    archived_at = CASE WHEN $3::timestamp IS NULL OR $3::timestamp > NOW() THEN NULL ELSE archived_at END
+		WHERE id = $1 AND deleted_at IS NULL`, docID, publishAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SearchArchived — архив: документы с истёкшим сроком, поиск по названию; только для администратора
+func (s *Storage) SearchArchived(ctx context.Context, query string, limit, offset int) ([]*models.Document, int, error) {
+	const where = `
+		FROM documents d
+		LEFT JOIN users u ON u.id = d.user_id
+		LEFT JOIN categories c ON c.id = d.category_id
+		WHERE d.deleted_at IS NULL AND d.expires_at <= NOW()
+		  AND ($1 = '' OR d.title ILIKE '%' || $1 || '%')`
+
+	var total int
+	if err := s.DB.QueryRowContext(ctx, `SELECT COUNT(*)`+where, query).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT d.id, d.title, d.pdf_path, d.user_id, d.role_id, d.status, d.created_at,
+		       d.publish_at, d.expires_at, d.archived_at,
+		       COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+		       COALESCE(c.id, 0), COALESCE(c.name, 'Без категории')`+where+`
+		ORDER BY d.expires_at DESC
+		LIMIT $2 OFFSET $3`, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	docs := []*models.Document{}
+	for rows.Next() {
+		var doc models.Document
+		if err := rows.Scan(&doc.ID, &doc.Title, &doc.PDFPath, &doc.UserID, &doc.RoleID, &doc.Status, &doc.CreatedAt,
+			&doc.PublishAt, &doc.ExpiresAt, &doc.ArchivedAt,
+			&doc.FirstName, &doc.LastName, &doc.Category.ID, &doc.Category.Name); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		docs = append(docs, &doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return docs, total, nil
+}
@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"cmd/internal/access"
 	"cmd/internal/documents/models"
 	"context"
 	"database/sql"
@@ -73,7 +74,10 @@ func (s *Storage) GetAllDocuments(
             d.pdf_path, 
             d.user_id, 
             d.role_id, 
+            d.status,
             d.created_at,
+            d.publish_at,
+            d.expires_at,
             u.first_name, 
             u.last_name, 
             u.photo_path,
@@ -92,9 +96,12 @@ func (s *Storage) GetAllDocuments(
         LEFT JOIN roles r ON r.id = COALESCE(dr.role_id, 5)
         LEFT JOIN (SELECT document_id, COUNT(*) AS cnt FROM document_likes GROUP BY document_id) likes 
                ON likes.document_id = d.id
-        LEFT JOIN (SELECT document_id, COUNT(*) AS cnt FROM comments WHERE deleted_at IS NULL GROUP BY document_id) comments 
+        LEFT JOIN (SELECT document_id, COUNT(*) AS cnt FROM comments WHERE deleted_at IS NULL AND is_hidden = FALSE GROUP BY document_id) comments 
                ON comments.document_id = d.id
         WHERE d.deleted_at IS NULL
+          AND d.status = 'approved'
+          AND (d.publish_at IS NULL OR d.publish_at <= NOW())
+          AND (d.expires_at IS NULL OR d.expires_at > NOW())
           AND (
             $1 = 1 OR $1 = 2
             OR dr.role_id IS NULL
@@ -121,7 +128,10 @@ func (s *Storage) GetAllDocuments(
 			&doc.PDFPath,
 			&doc.UserID,
 			&doc.RoleID,
+			&doc.Status,
 			&doc.CreatedAt,
+			&doc.PublishAt,
+			&doc.ExpiresAt,
 			&firstName,
 			&lastName,
 			&photoPath,
@@ -157,7 +167,8 @@ func (s *Storage) GetAllDocuments(
 func (s *Storage) GetDocumentByID(ctx context.Context, id, roleID, viewerID int) (*models.Document, error) {
 	query := `
         SELECT 
-            d.id, d.title, d.pdf_path, d.user_id, d.role_id, d.created_at,
+            d.id, d.title, d.pdf_path, d.user_id, d.role_id, d.status, d.created_at,
+            d.publish_at, d.expires_at, d.archived_at,
             u.first_name, u.last_name, u.photo_path,
             COALESCE(c.id, 0), COALESCE(c.name, 'Без категории'),
             COALESCE(dr.role_id, 5), COALESCE(r.name, 'Всем сотрудникам'),
@@ -172,15 +183,10 @@ func (s *Storage) GetDocumentByID(ctx context.Context, id, roleID, viewerID int)
         LEFT JOIN roles r ON r.id = COALESCE(dr.role_id, 5)
         LEFT JOIN (SELECT document_id, COUNT(*) AS cnt FROM document_likes GROUP BY document_id) likes 
                ON likes.document_id = d.id
-        LEFT JOIN (SELECT document_id, COUNT(*) AS cnt FROM comments WHERE deleted_at IS NULL GROUP BY document_id) comments 
+        LEFT JOIN (SELECT document_id, COUNT(*) AS cnt FROM comments WHERE deleted_at IS NULL AND is_hidden = FALSE GROUP BY document_id) comments 
                ON comments.document_id = d.id
         WHERE d.id = $1 AND d.deleted_at IS NULL
-          AND (
-            $2 = 1 OR $2 = 2
-            OR dr.role_id IS NULL
-            OR dr.role_id = $2
-          )
-    `
+          AND ` + access.VisibleSQL("d", "$2", "$3")
 
 	var doc models.Document
 	var firstName, lastName, photoPath sql.NullString
@@ -192,7 +198,11 @@ func (s *Storage) GetDocumentByID(ctx context.Context, id, roleID, viewerID int)
 		&doc.PDFPath,
 		&userID,
 		&doc.RoleID,
+		&doc.Status,
 		&doc.CreatedAt,
+		&doc.PublishAt,
+		&doc.ExpiresAt,
+		&doc.ArchivedAt,
 		&firstName,
 		&lastName,
 		&photoPath,
@@ -224,10 +234,15 @@ func (s *Storage) GetDocumentByID(ctx context.Context, id, roleID, viewerID int)
 	return &doc, nil
 }
 
+// Create сохраняет документ; без статуса он сразу опубликован (approved)
 func (s *Storage) Create(ctx context.Context, doc *models.Document) error {
+	if doc.Status == "" {
+		doc.Status = models.StatusApproved
+	}
+
 	query := `
-		INSERT INTO documents (title, pdf_path, user_id, category_id, role_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO documents (title, pdf_path, user_id, category_id, role_id, status, publish_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at
 	`
 
@@ -237,6 +252,9 @@ func (s *Storage) Create(ctx context.Context, doc *models.Document) error {
 		doc.UserID,
 		doc.Category.ID,
 		doc.RoleID,
+		doc.Status,
+		doc.PublishAt,
+		doc.ExpiresAt,
 	).Scan(&doc.ID, &doc.CreatedAt)
 }
 
@@ -263,6 +281,16 @@ func (s *Storage) SaveAccessibleRole(ctx context.Context, docID, roleID int) err
 	return tx.Commit()
 }
 
+// DocumentState — видимость документа для событий в реальном времени; удалённые документы тоже учитываются,
+// чтобы событие об удалении получили только те, кто документ видел
+func (s *Storage) DocumentState(ctx context.Context, docID int) (access.DocumentState, error) {
+	d, err := access.LoadDocument(ctx, s.DB, docID)
+	if errors.Is(err, access.ErrDocumentNotFound) {
+		return d, ErrNotFound
+	}
+	return d, err
+}
+
 func (s *Storage) Delete(ctx context.Context, id int) error {
 	query := `UPDATE documents SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
 	result, err := s.DB.ExecContext(ctx, query, id)
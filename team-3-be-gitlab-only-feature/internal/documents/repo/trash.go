@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"cmd/internal/documents/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ListTrash — удалённые документы, недавно удалённые сверху; PurgeAt заполняет сервис
+func (s *Storage) ListTrash(ctx context.Context, limit, offset int) ([]models.TrashItem, int, error) {
+	var total int
+	if err := s.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM documents WHERE deleted_at IS NOT NULL`,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT d.id, d.title, d.pdf_path, d.user_id,
+		       COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), d.deleted_at
+		FROM documents d
+		LEFT JOIN users u ON u.id = d.user_id
+		WHERE d.deleted_at IS NOT NULL
+		ORDER BY d.deleted_at DESC, d.id DESC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var it models.TrashItem
+		if err := rows.Scan(&it.DocumentID, &it.Title, &it.PDFPath, &it.AuthorID,
+			&it.FirstName, &it.LastName, &it.DeletedAt); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return items, total, nil
+}
+
+// Restore возвращает документ из корзины
+func (s *Storage) Restore(ctx context.Context, id int) error {
+	res, err := s.DB.ExecContext(ctx,
+		`UPDATE documents SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeCandidates — документы, удалённые раньше cutoff, по возрастанию ID после afterID
+func (s *Storage) PurgeCandidates(ctx context.Context, cutoff time.Time, afterID, limit int) ([]models.PurgedItem, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, title, pdf_path, deleted_at
+		FROM documents
+		WHERE deleted_at < $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`, cutoff, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	var items []models.PurgedItem
+	for rows.Next() {
+		var it models.PurgedItem
+		if err := rows.Scan(&it.DocumentID, &it.Title, &it.PDFPath, &it.DeletedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return items, nil
+}
+
+// FileShared — файл нужен другому документу (в том числе лежащему в корзине)
+func (s *Storage) FileShared(ctx context.Context, docID int, key string) (bool, error) {
+	var shared bool
+	err := s.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM documents WHERE pdf_path = $1 AND id <> $2)`, key, docID,
+	).Scan(&shared)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return shared, nil
+}
+
+// Purge окончательно удаляет документ из корзины вместе со связанными записями (ON DELETE CASCADE).
+// Строка заблокирована, пока removeFile удаляет файл: восстановить документ в это время нельзя,
+// а при ошибке удаления файла запись остаётся для следующей попытки.
+func (s *Storage) Purge(ctx context.Context, id int, cutoff time.Time, removeFile func(ctx context.Context) error) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	var locked int
+	err = tx.QueryRowContext(ctx,
+		`SELECT id FROM documents WHERE id = $1 AND deleted_at < $2 FOR UPDATE`, id, cutoff,
+	).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound // восстановлен или уже удалён
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	if removeFile != nil {
+		if err := removeFile(ctx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM documents WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package repo
+
+import (
+	"cmd/internal/documents/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// lockDocument блокирует строку документа до конца транзакции и возвращает статус и автора
+func lockDocument(ctx context.Context, tx *sql.Tx, docID int) (status string, authorID int, err error) {
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, COALESCE(user_id, 0) FROM documents
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`, docID,
+	).Scan(&status, &authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, ErrNotFound
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return status, authorID, nil
+}
+
+// record пишет запись в журнал и, если статус меняется, обновляет документ
+func record(ctx context.Context, tx *sql.Tx, docID int, from, to, action string, userID int, comment string) error {
+	if from != to {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE documents SET status = $2 WHERE id = $1`, docID, to); err != nil {
+			return fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO document_status_history (document_id, from_status, to_status, action, user_id, comment)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		docID, from, to, action, userID, comment); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+// Submit отправляет документ на согласование и назначает согласующих нового круга;
+// решения прошлого круга остаются в журнале
+func (s *Storage) Submit(ctx context.Context, docID, userID int, approvers models.ApproverSet) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	from, _, err := lockDocument(ctx, tx, docID)
+	if err != nil {
+		return err
+	}
+	to, ok := models.NextStatus(from, models.ActionSubmit)
+	if !ok {
+		return ErrBadTransition
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_approvers WHERE document_id = $1`, docID); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO document_approvers (document_id, user_id)
+		SELECT $1, u.id FROM users u WHERE u.id = ANY($2) AND u.deleted_at IS NULL`,
+		docID, approvers.UserIDs); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO document_approvers (document_id, role_id)
+		SELECT $1, r.id FROM roles r WHERE r.id = ANY($2)`,
+		docID, approvers.RoleIDs); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM document_approvers WHERE document_id = $1`, docID,
+	).Scan(&count); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if count != len(approvers.UserIDs)+len(approvers.RoleIDs) {
+		return ErrBadData // несуществующий пользователь или роль
+	}
+
+	if err := record(ctx, tx, docID, from, to, models.ActionSubmit, userID, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Withdraw возвращает документ с согласования в черновики
+func (s *Storage) Withdraw(ctx context.Context, docID, userID int) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	from, _, err := lockDocument(ctx, tx, docID)
+	if err != nil {
+		return err
+	}
+	to, ok := models.NextStatus(from, models.ActionWithdraw)
+	if !ok {
+		return ErrBadTransition
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_approvers WHERE document_id = $1`, docID); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if err := record(ctx, tx, docID, from, to, models.ActionWithdraw, userID, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Decide записывает решение согласующего. Один пользователь закрывает одно место —
+// сначала назначенное лично ему, затем по роли. Отказ сразу отклоняет документ,
+// последнее одобрение публикует. Возвращает статус после решения.
+func (s *Storage) Decide(ctx context.Context, docID, userID, roleID int, action, comment string) (string, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	from, authorID, err := lockDocument(ctx, tx, docID)
+	if err != nil {
+		return "", err
+	}
+	to, ok := models.NextStatus(from, action)
+	if !ok || (action != models.ActionApprove && action != models.ActionReject) {
+		return "", ErrBadTransition
+	}
+	if authorID == userID {
+		return "", ErrForbidden // автор не согласует свой документ
+	}
+
+	var decided bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM document_approvers WHERE document_id = $1 AND decided_by = $2)`,
+		docID, userID,
+	).Scan(&decided); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if decided {
+		return "", ErrAlreadyDecided
+	}
+
+	var approverID int
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM document_approvers
+		WHERE document_id = $1 AND decision IS NULL AND (user_id = $2 OR role_id = $3)
+		ORDER BY user_id IS NULL, id
+		LIMIT 1`, docID, userID, roleID,
+	).Scan(&approverID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotApprover
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	decision := models.StatusApproved
+	if action == models.ActionReject {
+		decision = models.StatusRejected
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE document_approvers
+		SET decision = $2, decided_by = $3, decided_at = NOW(), comment = $4
+		WHERE id = $1`, approverID, decision, userID, comment); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	if action == models.ActionApprove {
+		var pending int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM document_approvers WHERE document_id = $1 AND decision IS NULL`, docID,
+		).Scan(&pending); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		if pending > 0 {
+			to = from // ждём остальных согласующих
+		}
+	}
+
+	if err := record(ctx, tx, docID, from, to, action, userID, comment); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return to, nil
+}
+
+// GetWorkflow — статус, согласующие текущего круга и журнал документа
+func (s *Storage) GetWorkflow(ctx context.Context, docID int) (*models.Workflow, error) {
+	w := &models.Workflow{DocumentID: docID, Approvers: []models.Approver{}, History: []models.Transition{}}
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT title, COALESCE(user_id, 0), status FROM documents
+		WHERE id = $1 AND deleted_at IS NULL`, docID,
+	).Scan(&w.Title, &w.AuthorID, &w.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT da.id, da.user_id, da.role_id,
+		       COALESCE(NULLIF(TRIM(CONCAT(u.first_name, ' ', u.last_name)), ''), r.name, ''),
+		       da.decision, da.decided_by, da.decided_at, da.comment
+		FROM document_approvers da
+		LEFT JOIN users u ON u.id = da.user_id
+		LEFT JOIN roles r ON r.id = da.role_id
+		WHERE da.document_id = $1
+		ORDER BY da.id`, docID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.Approver
+		var userID, roleID, decidedBy sql.NullInt64
+		var decision sql.NullString
+		var decidedAt sql.NullTime
+		if err := rows.Scan(&a.ID, &userID, &roleID, &a.Name, &decision, &decidedBy, &decidedAt, &a.Comment); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		a.UserID = nullIntPtr(userID)
+		a.RoleID = nullIntPtr(roleID)
+		a.DecidedBy = nullIntPtr(decidedBy)
+		if decision.Valid {
+			a.Decision = &decision.String
+		}
+		if decidedAt.Valid {
+			a.DecidedAt = &decidedAt.Time
+		}
+		w.Approvers = append(w.Approvers, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+
+	hRows, err := s.DB.QueryContext(ctx, `
+		SELECT h.id, h.from_status, h.to_status, h.action, h.user_id,
+		       COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), h.comment, h.created_at
+		FROM document_status_history h
+		LEFT JOIN users u ON u.id = h.user_id
+		WHERE h.document_id = $1
+		ORDER BY h.id`, docID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer hRows.Close()
+	for hRows.Next() {
+		var t models.Transition
+		var userID sql.NullInt64
+		if err := hRows.Scan(&t.ID, &t.From, &t.To, &t.Action, &userID,
+			&t.FirstName, &t.LastName, &t.Comment, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		t.UserID = nullIntPtr(userID)
+		w.History = append(w.History, t)
+	}
+	if err := hRows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return w, nil
+}
+
+const selectWorkflowItems = `
+	SELECT d.id, d.title, COALESCE(d.user_id, 0), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+	       d.status, d.created_at,
+	       COALESCE((SELECT MAX(h.created_at) FROM document_status_history h WHERE h.document_id = d.id), d.created_at)
+	FROM documents d
+	LEFT JOIN users u ON u.id = d.user_id`
+
+// ReviewQueue — документы на согласовании, где пользователь может принять решение
+func (s *Storage) ReviewQueue(ctx context.Context, userID, roleID int) ([]models.WorkflowItem, error) {
+	return s.queryWorkflowItems(ctx, selectWorkflowItems+`
+	WHERE d.deleted_at IS NULL AND d.status = 'in_review'
+	  AND d.user_id IS DISTINCT FROM $1
+	  AND EXISTS (SELECT 1 FROM document_approvers da
+	              WHERE da.document_id = d.id AND da.decision IS NULL AND (da.user_id = $1 OR da.role_id = $2))
+	  AND NOT EXISTS (SELECT 1 FROM document_approvers da
+	                  WHERE da.document_id = d.id AND da.decided_by = $1)
+	ORDER BY d.created_at`, userID, roleID)
+}
+
+// Unpublished — неопубликованные документы автора, включая одобренные с отложенной публикацией;
+// authorID = 0 — всех авторов
+func (s *Storage) Unpublished(ctx context.Context, authorID int) ([]models.WorkflowItem, error) {
+	return s.queryWorkflowItems(ctx, selectWorkflowItems+`
+	WHERE d.deleted_at IS NULL
+	  AND (d.status <> 'approved' OR d.publish_at > NOW())
+	  AND (d.expires_at IS NULL OR d.expires_at > NOW())
+	  AND ($1 = 0 OR d.user_id = $1)
+	ORDER BY d.created_at DESC`, authorID)
+}
+
+func (s *Storage) queryWorkflowItems(ctx context.Context, query string, args ...any) ([]models.WorkflowItem, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	items := []models.WorkflowItem{}
+	for rows.Next() {
+		var it models.WorkflowItem
+		if err := rows.Scan(&it.DocumentID, &it.Title, &it.AuthorID, &it.FirstName, &it.LastName,
+			&it.Status, &it.CreatedAt, &it.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return items, nil
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
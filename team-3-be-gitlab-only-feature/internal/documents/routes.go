@@ -1,11 +1,15 @@
 package routes
 
 import (
+	api_a "cmd/internal/annotations/api_a"
 	api_c "cmd/internal/categories/api_c"
 	api_k "cmd/internal/comments/api_k"
 	custommiddleware "cmd/internal/custom_middleware"
 	api "cmd/internal/documents/api"
 	api_l "cmd/internal/likes/api_l"
+	api_n "cmd/internal/notifications/api_n"
+	api_re "cmd/internal/reactions/api_re"
+	"cmd/internal/realtime"
 	"cmd/internal/roles/api_r"
 	APIUser "cmd/internal/user/api"
 	servUser "cmd/internal/user/service"
@@ -24,6 +28,10 @@ func RegisterAPIRoutes(
 	viewHandler *api_v.ViewHandler,
 	roleHandler *api_r.RoleHandler,
 	commentHandler *api_k.CommentHandler,
+	reactionHandler *api_re.ReactionHandler,
+	annotationHandler *api_a.AnnotationHandler,
+	notificationHandler *api_n.NotificationHandler,
+	streamHandler *realtime.StreamHandler,
 	jwtService *servUser.JWTService,
 	userService servUser.Service,
 ) {
@@ -35,8 +43,16 @@ func RegisterAPIRoutes(
 		r.Post("/resend", userHandler.ResendVerificationHandler)
 		r.Post("/refresh", userHandler.RefreshTokenHandler)
 		r.Get("/verify-email", userHandler.VerifyEmailHandler)
+		r.Post("/email/confirm", userHandler.ConfirmEmailChangeHandler)
+
+		// Вход через корпоративный SSO (OIDC)
+		r.Get("/oidc/login", userHandler.OIDCLoginHandler)
+		r.Get("/oidc/callback", userHandler.OIDCCallbackHandler)
 	})
 
+	// === ПУБЛИЧНЫЕ КЛЮЧИ ПОДПИСИ JWT ===
+	r.Get("/.well-known/jwks.json", userHandler.JWKSHandler)
+
 	// === ЗДОРОВЬЕ СИСТЕМЫ ===
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -46,23 +62,47 @@ func RegisterAPIRoutes(
 
 	// === ЗАЩИЩЁННЫЕ МАРШРУТЫ ===
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(custommiddleware.AuthMiddleware(jwtService))
+		r.Use(custommiddleware.AuthMiddleware(jwtService, userService))
 
 		// === ПОЛЬЗОВАТЕЛЬ ===
 		r.Route("/user", func(r chi.Router) {
 			r.Get("/", userHandler.GetUserByIDHandler)
 			r.Get("/me", userHandler.GetCurrentUserHandler)
+			r.Get("/me/export", userHandler.ExportUserDataHandler)
+			r.Get("/directory", userHandler.EmployeeDirectoryHandler)
 			r.Put("/profile", userHandler.UpdateProfileHandler)
+			r.Post("/email/change", userHandler.RequestEmailChangeHandler)
 			r.Post("/photo", userHandler.UploadPhotoHandler)
 			r.Post("/avatar/generate", userHandler.GenerateAvatarHandler)
 			r.Post("/logout", userHandler.LogoutHandler)
 
+			// Персональные токены доступа для скриптов и интеграций
+			r.Get("/tokens", userHandler.ListPersonalTokensHandler)
+			r.Post("/tokens", userHandler.CreatePersonalTokenHandler)
+			r.Delete("/tokens/{id}", userHandler.RevokePersonalTokenHandler)
+
 			// Админские маршруты для управления пользователями
 			r.Route("/admin", func(r chi.Router) {
 				r.Use(custommiddleware.RequireRole(1))
 				r.Get("/users", userHandler.GetUsersHandler)
 				r.Delete("/", userHandler.DeleteUserHandler)
+				r.Post("/users/{id}/deactivate", userHandler.DeactivateUserHandler)
+				r.Post("/users/{id}/reactivate", userHandler.ReactivateUserHandler)
+				r.Post("/users/{id}/anonymize", userHandler.AnonymizeUserHandler)
+				r.Delete("/users/{id}/purge", userHandler.PurgeUserHandler)
 				r.Post("/logout-all", userHandler.LogoutAllHandler)
+
+				// Приглашения (регистрация только через администратора)
+				r.Post("/invitations", userHandler.InviteUserHandler)
+				r.Post("/invitations/import", userHandler.ImportInvitationsHandler)
+
+				// Синхронизация с LDAP / Active Directory
+				r.Post("/directory/sync", userHandler.SyncDirectoryHandler)
+				r.Get("/directory/sync", userHandler.DirectorySyncReportHandler)
+
+				// Очередь исходящих писем
+				r.Get("/email-outbox", userHandler.ListOutboxEmailsHandler)
+				r.Post("/email-outbox/{id}/resend", userHandler.ResendOutboxEmailHandler)
 			})
 
 			r.Route("/roles", func(r chi.Router) {
@@ -72,30 +112,82 @@ func RegisterAPIRoutes(
 		})
 
 		// === ДОКУМЕНТЫ ===
-		r.Get("/documents", docHandler.GetAll)                                          //просмотр всех
-		r.With(custommiddleware.ModeratorOrAdmin).Post("/documents", docHandler.Create) //создание
+		r.Get("/documents", docHandler.GetAll)                                           //просмотр всех
+		r.With(custommiddleware.ModeratorOrAdmin).Post("/documents", docHandler.Create)  //создание
+		r.Get("/documents/review", docHandler.ReviewQueue)                               //ждут моего согласования
+		r.Get("/documents/drafts", docHandler.Unpublished)                               //мои неопубликованные
+		r.With(custommiddleware.AdminOnly).Get("/documents/archive", docHandler.Archive) //архив истёкших (?q=)
 
 		r.Route("/documents/{id}", func(r chi.Router) {
-			r.Get("/", docHandler.GetByID)                                    //просмотр конкретного
-			r.With(custommiddleware.AdminOnly).Delete("/", docHandler.Delete) //удаление
+			r.Get("/", docHandler.GetByID)                                          //просмотр конкретного
+			r.With(custommiddleware.AdminOnly).Delete("/", docHandler.Delete)       //удаление
+			r.With(custommiddleware.AdminOnly).Post("/restore", docHandler.Restore) //вернуть из корзины
+
+			// === СОГЛАСОВАНИЕ ===
+			r.Get("/workflow", docHandler.Workflow)  //статус, согласующие, журнал
+			r.Post("/submit", docHandler.Submit)     //отправить на согласование
+			r.Post("/withdraw", docHandler.Withdraw) //вернуть в черновики
+			r.Post("/approve", docHandler.Approve)   //одобрить
+			r.Post("/reject", docHandler.Reject)     //отклонить с комментарием
+
+			// === СРОКИ ПУБЛИКАЦИИ ===
+			r.Put("/schedule", docHandler.SetSchedule) //publish_at и expires_at
 
 			// === КОММЕНТАРИИ ===
 			r.Get("/comments", commentHandler.GetByDocument) //просмотреть все
 			r.Post("/comments", commentHandler.Create)       //оставить
 
+			// === АННОТАЦИИ PDF ===
+			r.Get("/annotations", annotationHandler.List)                             //список (?version, ?page, ?status)
+			r.Post("/annotations", annotationHandler.Create)                          //добавить к текущей версии
+			r.Patch("/annotations/{annotation_id}", annotationHandler.Update)         //изменить
+			r.Delete("/annotations/{annotation_id}", annotationHandler.Delete)        //удалить
+			r.Post("/annotations/{annotation_id}/resolve", annotationHandler.Resolve) //решено
+			r.Post("/annotations/{annotation_id}/reopen", annotationHandler.Reopen)   //вернуть в работу
+
 			// === ЛАЙКИ ===
 			r.Put("/like", likeHandler.AddLike)       //поставить
 			r.Delete("/like", likeHandler.RemoveLike) //удалить
 			r.Get("/likes", likeHandler.GetLikes)     //количество
 
+			// === РЕАКЦИИ ===
+			r.Put("/reactions", reactionHandler.React)                            //поставить на документ
+			r.Delete("/reactions", reactionHandler.Unreact)                       //снять с документа (?emoji=)
+			r.Put("/comments/{comment_id}/reactions", reactionHandler.React)      //поставить на комментарий
+			r.Delete("/comments/{comment_id}/reactions", reactionHandler.Unreact) //снять с комментария (?emoji=)
+
 			// === ПРОСМОТРЫ ===
 			r.Put("/view", viewHandler.AddView)                                               //отметить
 			r.Get("/views", viewHandler.CountViews)                                           //количество
 			r.With(custommiddleware.ModeratorOrAdmin).Get("/viewers", viewHandler.GetViewers) //список
 		})
 
-		// === УДАЛЕНИЕ КОММЕНТАРИЯ ===
+		// === ПРАВКА И УДАЛЕНИЕ КОММЕНТАРИЯ ===
+		r.Patch("/documents/{id}/comments/{comment_id}", commentHandler.Update)
 		r.Delete("/documents/{id}/comments/{comment_id}", commentHandler.Delete)
+		r.With(custommiddleware.ModeratorOrAdmin).Get("/documents/{id}/comments/{comment_id}/revisions", commentHandler.Revisions) //история правок
+		r.Post("/documents/{id}/comments/{comment_id}/report", commentHandler.Report)                                              //пожаловаться
+
+		// === КОРЗИНА ДОКУМЕНТОВ (только админ) ===
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(custommiddleware.RequireRole(1))
+			r.Get("/trash", docHandler.Trash)             //удалённые документы
+			r.Post("/trash/purge", docHandler.PurgeTrash) //окончательное удаление (?dry_run=true — только отчёт)
+		})
+
+		// === МОДЕРАЦИЯ КОММЕНТАРИЕВ ===
+		r.Route("/moderation", func(r chi.Router) {
+			r.With(custommiddleware.ModeratorOrAdmin).Get("/comments", commentHandler.Queue)                  //очередь жалоб
+			r.With(custommiddleware.ModeratorOrAdmin).Post("/comments/{comment_id}", commentHandler.Moderate) //решение по комментарию
+
+			// Запрет комментировать — только администратор
+			r.With(custommiddleware.RequireRole(1)).Get("/mutes", commentHandler.ListMutes)
+			r.With(custommiddleware.RequireRole(1)).Put("/mutes/{user_id}", commentHandler.Mute)
+			r.With(custommiddleware.RequireRole(1)).Delete("/mutes/{user_id}", commentHandler.Unmute)
+		})
+
+		// === НАБОР РЕАКЦИЙ ===
+		r.Get("/reactions", reactionHandler.Allowed)
 
 		// === КАТЕГОРИИ ===
 		r.Get("/categories", catHandler.GetAll)                                          //просмотр
@@ -104,5 +196,18 @@ func RegisterAPIRoutes(
 
 		// === РОЛИ ===
 		r.Get("/roles/getall", roleHandler.GetAll) //получение
+
+		// === СОБЫТИЯ В РЕАЛЬНОМ ВРЕМЕНИ (SSE) ===
+		r.Method(http.MethodGet, "/stream", streamHandler)
+
+		// === УВЕДОМЛЕНИЯ ===
+		r.Route("/notifications", func(r chi.Router) {
+			r.Get("/", notificationHandler.List)                         //список и число непрочитанных
+			r.Get("/unread-count", notificationHandler.UnreadCount)      //число непрочитанных
+			r.Post("/read-all", notificationHandler.MarkAllRead)         //прочитать все
+			r.Post("/{id}/read", notificationHandler.MarkRead)           //прочитать одно
+			r.Get("/preferences", notificationHandler.GetPreferences)    //настройки
+			r.Put("/preferences", notificationHandler.UpdatePreferences) //изменить настройки
+		})
 	})
 }
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"cmd/internal/documents/models"
+	"cmd/internal/documents/repo"
+	"cmd/internal/realtime"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+const publishBatch = 100
+
+// RunScheduler до отмены ctx объявляет документы, чей срок публикации наступил,
+// и отправляет в архив истёкшие
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.publishDue(ctx)
+			s.archiveExpired(ctx)
+		}
+	}
+}
+
+func (s *Service) publishDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		docs, err := s.Repo.ClaimDuePublications(ctx, publishBatch)
+		if err != nil {
+			log.Printf("❌ Плановая публикация документов: %v", err)
+			return
+		}
+		for _, doc := range docs {
+			log.Printf("✅ Документ %d опубликован по расписанию", doc.ID)
+			s.announce(ctx, doc)
+		}
+		if len(docs) < publishBatch {
+			return
+		}
+	}
+}
+
+func (s *Service) archiveExpired(ctx context.Context) {
+	ids, err := s.Repo.ArchiveExpired(ctx)
+	if err != nil {
+		log.Printf("❌ Архивация истёкших документов: %v", err)
+		return
+	}
+	for _, id := range ids {
+		log.Printf("🔄 Документ %d перенесён в архив: истёк срок действия", id)
+		if s.Events != nil {
+			s.Events.Publish(ctx, realtime.FeedEvent(realtime.EventDocumentArchived, id, map[string]int{"id": id}))
+		}
+	}
+}
+
+// ValidateSchedule проверяет сроки: окончание в будущем и позже начала публикации
+func ValidateSchedule(publishAt, expiresAt *time.Time) error {
+	if expiresAt == nil {
+		return nil
+	}
+	if !expiresAt.After(time.Now()) || (publishAt != nil && !expiresAt.After(*publishAt)) {
+		return repo.ErrBadData
+	}
+	return nil
+}
+
+// SetSchedule меняет сроки публикации и действия — автор или администратор.
+// Продление срока возвращает документ из архива.
+func (s *Service) SetSchedule(ctx context.Context, docID, userID, roleID int, publishAt, expiresAt *time.Time) (*models.Document, error) {
+	if err := ValidateSchedule(publishAt, expiresAt); err != nil {
+		return nil, err
+	}
+	doc, err := s.Repo.GetDocumentByID(ctx, docID, roleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if roleID != 1 && (doc.UserID == nil || *doc.UserID != userID) {
+		return nil, repo.ErrForbidden
+	}
+
+	if err := s.Repo.SetSchedule(ctx, docID, publishAt, expiresAt); err != nil {
+		return nil, err
+	}
+	// Если новый publish_at уже наступил, объявляем сразу, не дожидаясь планировщика
+	s.NotifyPublished(ctx, doc)
+	return s.Repo.GetDocumentByID(ctx, docID, roleID, userID)
+}
+
+// SearchArchived — поиск в архиве по названию
+func (s *Service) SearchArchived(ctx context.Context, query string, limit, offset int) ([]*models.Document, int, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	// % и _ в запросе ищутся буквально
+	query = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.TrimSpace(query))
+	return s.Repo.SearchArchived(ctx, query, limit, offset)
+}
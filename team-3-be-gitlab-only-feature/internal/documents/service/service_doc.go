@@ -3,21 +3,95 @@ package service
 import (
 	"cmd/internal/documents/models"
 	"cmd/internal/documents/repo"
+	"cmd/internal/reactions/models_re"
+	"cmd/internal/realtime"
 	"cmd/internal/storage"
 	"context"
 	"log"
 	"strings"
 )
 
+// Notifier рассылает уведомления о новых документах
+type Notifier interface {
+	DocumentPublished(ctx context.Context, docID int, title string, authorID int) error
+}
+
+// Reactions — реакции сразу для многих документов, без запроса на каждый
+type Reactions interface {
+	DocumentReactions(ctx context.Context, docIDs []int, viewerID int) (map[int][]models_re.Reaction, error)
+}
+
 type Service struct {
 	Repo      *repo.Storage
 	S3Storage *storage.S3Storage // ← ДОБАВЛЯЕМ
+	Notifier  Notifier
+	Events    realtime.Publisher
+	Reactions Reactions
+	Workflow  WorkflowConfig
+	Trash     TrashConfig
 }
 
-func NewService(r *repo.Storage, s3Storage *storage.S3Storage) *Service {
+func NewService(r *repo.Storage, s3Storage *storage.S3Storage, notifier Notifier, events realtime.Publisher, reactions Reactions, workflow WorkflowConfig, trash TrashConfig) *Service {
 	return &Service{
 		Repo:      r,
 		S3Storage: s3Storage,
+		Notifier:  notifier,
+		Events:    events,
+		Reactions: reactions,
+		Workflow:  workflow,
+		Trash:     trash,
+	}
+}
+
+// attachReactions проставляет реакции документам одним запросом; ошибка только логируется —
+// список документов важнее счётчиков
+func (s *Service) attachReactions(ctx context.Context, docs []*models.Document, viewerID int) {
+	ids := make([]int, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+		d.Reactions = []models_re.Reaction{}
+	}
+	if s.Reactions == nil || len(ids) == 0 {
+		return
+	}
+	reactions, err := s.Reactions.DocumentReactions(ctx, ids, viewerID)
+	if err != nil {
+		log.Printf("⚠️ Не удалось получить реакции документов: %v", err)
+		return
+	}
+	for _, d := range docs {
+		if r, ok := reactions[d.ID]; ok {
+			d.Reactions = r
+		}
+	}
+}
+
+// NotifyPublished уведомляет тех, кому виден документ; вызывается после сохранения прав доступа.
+// Документ с будущим publish_at объявит планировщик. Ошибка рассылки не отменяет публикацию.
+func (s *Service) NotifyPublished(ctx context.Context, doc *models.Document) {
+	ok, err := s.Repo.MarkPublished(ctx, doc.ID)
+	if err != nil {
+		log.Printf("⚠️ Не удалось отметить публикацию документа %d: %v", doc.ID, err)
+		return
+	}
+	if ok {
+		s.announce(ctx, doc)
+	}
+}
+
+// announce рассылает событие ленты и уведомления о публикации
+func (s *Service) announce(ctx context.Context, doc *models.Document) {
+	if s.Events != nil {
+		s.Events.Publish(ctx, realtime.FeedEvent(realtime.EventDocumentCreated, doc.ID, map[string]any{
+			"id":    doc.ID,
+			"title": doc.Title,
+		}))
+	}
+	if s.Notifier == nil || doc.UserID == nil {
+		return
+	}
+	if err := s.Notifier.DocumentPublished(ctx, doc.ID, doc.Title, *doc.UserID); err != nil {
+		log.Printf("⚠️ Не удалось разослать уведомления о документе %d: %v", doc.ID, err)
 	}
 }
 
@@ -26,6 +100,7 @@ func (s *Service) GetAllDocuments(ctx context.Context, roleID, viewerID int) ([]
 	if err != nil {
 		return nil, err
 	}
+	s.attachReactions(ctx, docsPtr, viewerID)
 
 	docs := make([]models.Document, len(docsPtr))
 
@@ -67,6 +142,9 @@ func (s *Service) GetDocumentByID(ctx context.Context, id, roleID, viewerID int)
 	if err != nil {
 		return nil, err
 	}
+	if doc != nil {
+		s.attachReactions(ctx, []*models.Document{doc}, viewerID)
+	}
 
 	// Генерируем presigned URL для PDF файла если нужно
 	if doc != nil && s.S3Storage != nil && doc.PDFPath != "" {
@@ -107,5 +185,11 @@ func (s *Service) DeleteDocument(ctx context.Context, id, roleID, viewerID int)
 	if _, err := s.Repo.GetDocumentByID(ctx, id, roleID, viewerID); err != nil {
 		return err
 	}
-	return s.Repo.Delete(ctx, id)
+	if err := s.Repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if s.Events != nil {
+		s.Events.Publish(ctx, realtime.FeedEvent(realtime.EventDocumentDeleted, id, map[string]int{"id": id}))
+	}
+	return nil
 }
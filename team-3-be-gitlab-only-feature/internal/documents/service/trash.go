@@ -0,0 +1,173 @@
+package service
+
+import (
+	"cmd/internal/documents/models"
+	"cmd/internal/documents/repo"
+	"cmd/internal/realtime"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const purgeBatch = 100
+
+// TrashConfig — сколько документы лежат в корзине до окончательного удаления
+// и как часто запускается очистка
+type TrashConfig struct {
+	Retention     time.Duration // по умолчанию 30 дней
+	PurgeInterval time.Duration // по умолчанию раз в сутки
+	PurgeDryRun   bool          // плановая очистка только строит отчёт
+}
+
+func (s *Service) retention() time.Duration {
+	if s.Trash.Retention <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return s.Trash.Retention
+}
+
+// ListTrash — корзина с датой окончательного удаления каждого документа
+func (s *Service) ListTrash(ctx context.Context, limit, offset int) ([]models.TrashItem, int, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	items, total, err := s.Repo.ListTrash(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.retention())
+	}
+	return items, total, nil
+}
+
+// Restore возвращает документ из корзины в ленту
+func (s *Service) Restore(ctx context.Context, id int) error {
+	if err := s.Repo.Restore(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("🔄 Документ %d восстановлен из корзины", id)
+	if s.Events != nil {
+		s.Events.Publish(ctx, realtime.FeedEvent(realtime.EventDocumentRestored, id, map[string]int{"id": id}))
+	}
+	return nil
+}
+
+// PurgeTrash окончательно удаляет документы, пролежавшие в корзине дольше срока хранения,
+// вместе с файлами в S3. dryRun — только отчёт, ничего не удаляется.
+func (s *Service) PurgeTrash(ctx context.Context, dryRun bool) (*models.PurgeReport, error) {
+	report := &models.PurgeReport{
+		DryRun:    dryRun,
+		Cutoff:    time.Now().Add(-s.retention()),
+		StartedAt: time.Now(),
+		Items:     []models.PurgedItem{},
+		Errors:    []string{},
+	}
+	log.Printf("🔄 Очистка корзины начата (dry_run=%t, удалены до %s)", dryRun, report.Cutoff.Format(time.RFC3339))
+
+	afterID := 0
+	for {
+		items, err := s.Repo.PurgeCandidates(ctx, report.Cutoff, afterID, purgeBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			afterID = it.DocumentID
+			s.purgeOne(ctx, report, it)
+		}
+		if len(items) < purgeBatch || ctx.Err() != nil {
+			break
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	log.Printf("✅ Очистка корзины завершена: документов %d, удалено %d, файлов %d, ошибок %d",
+		report.Total, report.Purged, report.FilesDeleted, report.Failed)
+	return report, nil
+}
+
+func (s *Service) purgeOne(ctx context.Context, report *models.PurgeReport, it models.PurgedItem) {
+	report.Total++
+
+	// Файл удаляется, только если это наш ключ в S3 и он не нужен другому документу
+	removable := s.S3Storage != nil && strings.HasPrefix(it.PDFPath, "documents/")
+	if removable {
+		shared, err := s.Repo.FileShared(ctx, it.DocumentID, it.PDFPath)
+		if err != nil {
+			s.purgeFailed(report, it, err)
+			return
+		}
+		removable = !shared
+	}
+
+	if report.DryRun {
+		it.Action = models.PurgeActionPurged
+		it.FileDeleted = removable
+		report.Purged++
+		if removable {
+			report.FilesDeleted++
+		}
+		report.Items = append(report.Items, it)
+		return
+	}
+
+	var removeFile func(ctx context.Context) error
+	if removable {
+		removeFile = func(ctx context.Context) error {
+			return s.S3Storage.DeleteFile(ctx, it.PDFPath)
+		}
+	}
+	err := s.Repo.Purge(ctx, it.DocumentID, report.Cutoff, removeFile)
+	if errors.Is(err, repo.ErrNotFound) {
+		report.Total-- // восстановлен, пока шла очистка
+		return
+	}
+	if err != nil {
+		s.purgeFailed(report, it, err)
+		return
+	}
+
+	it.Action = models.PurgeActionPurged
+	it.FileDeleted = removable
+	report.Purged++
+	if removable {
+		report.FilesDeleted++
+	}
+	report.Items = append(report.Items, it)
+}
+
+func (s *Service) purgeFailed(report *models.PurgeReport, it models.PurgedItem, err error) {
+	log.Printf("❌ Не удалось окончательно удалить документ %d: %v", it.DocumentID, err)
+	it.Action = models.PurgeActionFailed
+	it.Error = err.Error()
+	report.Failed++
+	report.Items = append(report.Items, it)
+	report.Errors = append(report.Errors, fmt.Sprintf("документ %d: %v", it.DocumentID, err))
+}
+
+// RunPurgeJob периодически очищает корзину до отмены ctx
+func (s *Service) RunPurgeJob(ctx context.Context) {
+	interval := s.Trash.PurgeInterval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.PurgeTrash(ctx, s.Trash.PurgeDryRun); err != nil {
+				log.Printf("❌ Плановая очистка корзины: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"cmd/internal/documents/models"
+	"cmd/internal/documents/repo"
+	"context"
+	"log"
+	"strings"
+)
+
+const maxDecisionComment = 2000
+
+// WorkflowConfig — согласование перед публикацией. Выключено — документ публикуется сразу.
+// Согласующие по умолчанию назначаются, если автор не выбрал своих при отправке.
+type WorkflowConfig struct {
+	Enabled       bool
+	ApproverRoles []int
+	ApproverUsers []int
+}
+
+// InitialStatus — статус нового документа
+func (s *Service) InitialStatus() string {
+	if s.Workflow.Enabled {
+		return models.StatusDraft
+	}
+	return models.StatusApproved
+}
+
+// canManage — отправлять и отзывать документ может автор или администратор
+func canManage(w *models.Workflow, userID, roleID int) bool {
+	return w.AuthorID == userID || roleID == 1
+}
+
+// Submit отправляет черновик или отклонённый документ на согласование
+func (s *Service) Submit(ctx context.Context, docID, userID, roleID int, in models.ApproverSet) (*models.Workflow, error) {
+	w, err := s.Repo.GetWorkflow(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage(w, userID, roleID) {
+		return nil, repo.ErrForbidden
+	}
+
+	approvers := in
+	if len(approvers.UserIDs) == 0 && len(approvers.RoleIDs) == 0 {
+		approvers = models.ApproverSet{UserIDs: s.Workflow.ApproverUsers, RoleIDs: s.Workflow.ApproverRoles}
+	}
+	approvers.UserIDs = uniqueIDs(approvers.UserIDs)
+	approvers.RoleIDs = uniqueIDs(approvers.RoleIDs)
+	if len(approvers.UserIDs)+len(approvers.RoleIDs) == 0 {
+		return nil, repo.ErrBadData
+	}
+	for _, id := range approvers.UserIDs {
+		if id == w.AuthorID {
+			return nil, repo.ErrBadData // автор не согласует свой документ
+		}
+	}
+	for _, id := range approvers.RoleIDs {
+		if id < 1 || id > 4 {
+			return nil, repo.ErrBadData // 5 — «всем», это не роль согласующего
+		}
+	}
+
+	if err := s.Repo.Submit(ctx, docID, userID, approvers); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Документ %d отправлен на согласование", docID)
+	return s.Repo.GetWorkflow(ctx, docID)
+}
+
+// Withdraw возвращает документ с согласования в черновики
+func (s *Service) Withdraw(ctx context.Context, docID, userID, roleID int) (*models.Workflow, error) {
+	w, err := s.Repo.GetWorkflow(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage(w, userID, roleID) {
+		return nil, repo.ErrForbidden
+	}
+	if err := s.Repo.Withdraw(ctx, docID, userID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetWorkflow(ctx, docID)
+}
+
+// Approve — одобрение согласующего; последнее одобрение публикует документ
+func (s *Service) Approve(ctx context.Context, docID, userID, roleID int, comment string) (*models.Workflow, error) {
+	return s.decide(ctx, docID, userID, roleID, models.ActionApprove, comment)
+}
+
+// Reject отклоняет документ; комментарий обязателен, чтобы автор знал, что исправить
+func (s *Service) Reject(ctx context.Context, docID, userID, roleID int, comment string) (*models.Workflow, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, repo.ErrBadData
+	}
+	return s.decide(ctx, docID, userID, roleID, models.ActionReject, comment)
+}
+
+func (s *Service) decide(ctx context.Context, docID, userID, roleID int, action, comment string) (*models.Workflow, error) {
+	comment = strings.TrimSpace(comment)
+	if len([]rune(comment)) > maxDecisionComment {
+		return nil, repo.ErrBadData
+	}
+
+	status, err := s.Repo.Decide(ctx, docID, userID, roleID, action, comment)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.Repo.GetWorkflow(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == models.StatusApproved {
+		log.Printf("✅ Документ %d согласован и опубликован", docID)
+		doc := &models.Document{ID: docID, Title: w.Title}
+		if w.AuthorID != 0 {
+			doc.UserID = &w.AuthorID
+		}
+		s.NotifyPublished(ctx, doc)
+	}
+	return w, nil
+}
+
+// GetWorkflow — согласование документа видно тем, кому виден сам документ
+func (s *Service) GetWorkflow(ctx context.Context, docID, roleID, viewerID int) (*models.Workflow, error) {
+	if _, err := s.Repo.GetDocumentByID(ctx, docID, roleID, viewerID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetWorkflow(ctx, docID)
+}
+
+// ReviewQueue — документы, ожидающие решения пользователя
+func (s *Service) ReviewQueue(ctx context.Context, userID, roleID int) ([]models.WorkflowItem, error) {
+	return s.Repo.ReviewQueue(ctx, userID, roleID)
+}
+
+// Unpublished — черновики, документы на согласовании и отклонённые; администратор видит все
+func (s *Service) Unpublished(ctx context.Context, userID, roleID int) ([]models.WorkflowItem, error) {
+	if roleID == 1 {
+		return s.Repo.Unpublished(ctx, 0)
+	}
+	return s.Repo.Unpublished(ctx, userID)
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// TopicResync публикуется только локально после восстановления связи с шиной: сообщения за время
+// разрыва потеряны, подписчики с кэшами должны сбросить их целиком
+const TopicResync = "eventbus.resync"
+
+// Message — сообщение шины; Payload — JSON
+type Message struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Decode разбирает Payload в v
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// Handler вызывается синхронно: для локальных сообщений — в горутине публикующего,
+// для сообщений с других инстансов — в горутине слушателя. Долгую работу выносите в свою горутину.
+type Handler func(Message)
+
+// Bus — шина событий между инстансами API
+type Bus interface {
+	// Publish доставляет payload (сериализуется в JSON) подписчикам темы на всех инстансах, включая текущий
+	Publish(ctx context.Context, topic string, payload interface{}) error
+	// Subscribe регистрирует обработчик темы; возвращает функцию отписки
+	Subscribe(topic string, h Handler) (unsubscribe func())
+}
+
+// subscribers — локальная раздача сообщений, общая для всех реализаций
+type subscribers struct {
+	mu     sync.RWMutex
+	nextID int
+	topics map[string]map[int]Handler
+}
+
+func (s *subscribers) Subscribe(topic string, h Handler) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topics == nil {
+		s.topics = make(map[string]map[int]Handler)
+	}
+	if s.topics[topic] == nil {
+		s.topics[topic] = make(map[int]Handler)
+	}
+	s.nextID++
+	id := s.nextID
+	s.topics[topic][id] = h
+
+	return func() {
+		s.mu.Lock()
+		delete(s.topics[topic], id)
+		s.mu.Unlock()
+	}
+}
+
+func (s *subscribers) dispatch(msg Message) {
+	s.mu.RLock()
+	handlers := make([]Handler, 0, len(s.topics[msg.Topic]))
+	for _, h := range s.topics[msg.Topic] {
+		handlers = append(handlers, h)
+	}
+	s.mu.RUnlock()
+
+	// Обработчики вызываются без замка: им можно подписываться и отписываться
+	for _, h := range handlers {
+		h(msg)
+	}
+}
@@ -0,0 +1,24 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// MemoryBus доставляет сообщения только внутри процесса: один инстанс, тесты
+type MemoryBus struct {
+	subscribers
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	b.dispatch(Message{Topic: topic, Payload: data})
+	return nil
+}
@@ -0,0 +1,133 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultChannel — канал LISTEN/NOTIFY по умолчанию
+const DefaultChannel = "dochub_events"
+
+// Ограничение PostgreSQL на payload NOTIFY — 8000 байт, с запасом на конверт
+const maxNotifyPayload = 7900
+
+var ErrPayloadTooLarge = errors.New("event payload too large for NOTIFY")
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// envelope — то, что уходит в NOTIFY; Origin отличает свои сообщения от чужих
+type envelope struct {
+	Origin  string          `json:"o"`
+	Topic   string          `json:"t"`
+	Payload json.RawMessage `json:"p,omitempty"`
+}
+
+// PostgresBus — шина между инстансами через LISTEN/NOTIFY. Публикация идёт через общий пул,
+// прослушивание — через отдельное соединение pgx (в пуле database/sql держать LISTEN нельзя).
+// Локальные подписчики получают сообщение сразу, остальные инстансы — через NOTIFY.
+type PostgresBus struct {
+	subscribers
+	db      *sql.DB
+	dsn     string
+	channel string
+	origin  string
+}
+
+func NewPostgresBus(db *sql.DB, dsn, channel string) *PostgresBus {
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	origin := make([]byte, 8)
+	_, _ = rand.Read(origin)
+	return &PostgresBus{db: db, dsn: dsn, channel: channel, origin: hex.EncodeToString(origin)}
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	b.dispatch(Message{Topic: topic, Payload: data})
+
+	raw, err := json.Marshal(envelope{Origin: b.origin, Topic: topic, Payload: data})
+	if err != nil {
+		return err
+	}
+	if len(raw) > maxNotifyPayload {
+		return fmt.Errorf("%w: %s, %d байт", ErrPayloadTooLarge, topic, len(raw))
+	}
+	if _, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, b.channel, string(raw)); err != nil {
+		return fmt.Errorf("ошибка NOTIFY: %w", err)
+	}
+	return nil
+}
+
+// Run слушает канал и переподключается при обрыве, пока не отменён ctx.
+// После переподключения локально публикуется TopicResync.
+func (b *PostgresBus) Run(ctx context.Context) {
+	delay := minReconnectDelay
+	connectedBefore := false
+	for {
+		err := b.listen(ctx, func() {
+			delay = minReconnectDelay
+			if connectedBefore {
+				b.dispatch(Message{Topic: TopicResync})
+			}
+			connectedBefore = true
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("⚠️ Шина событий: LISTEN %s прерван: %v, повтор через %s", b.channel, err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+func (b *PostgresBus) listen(ctx context.Context, connected func()) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+	log.Printf("✅ Шина событий: LISTEN %s", b.channel)
+	connected()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var env envelope
+		if err := json.Unmarshal([]byte(n.Payload), &env); err != nil {
+			log.Printf("⚠️ Шина событий: неверное сообщение: %v", err)
+			continue
+		}
+		if env.Origin == b.origin {
+			continue // своим подписчикам уже доставлено при публикации
+		}
+		b.dispatch(Message{Topic: env.Topic, Payload: env.Payload})
+	}
+}
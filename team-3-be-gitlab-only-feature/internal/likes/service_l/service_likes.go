@@ -2,24 +2,49 @@ package service_l
 
 import (
 	"cmd/internal/likes/repo_l"
+	"cmd/internal/realtime"
 	"context"
 )
 
 type LikeService struct {
-	repo *repo_l.LikeStorage
+	repo   *repo_l.LikeStorage
+	events realtime.Publisher
 }
 
-func NewLikeService(r *repo_l.LikeStorage) *LikeService {
-	return &LikeService{repo: r}
+func NewLikeService(r *repo_l.LikeStorage, events realtime.Publisher) *LikeService {
+	return &LikeService{repo: r, events: events}
 }
 
 // Теперь принимает userID явно
 func (s *LikeService) Add(ctx context.Context, userID, docID int) error {
-	return s.repo.AddLike(ctx, userID, docID)
+	if err := s.repo.AddLike(ctx, userID, docID); err != nil {
+		return err
+	}
+	s.publish(ctx, realtime.EventLikeAdded, userID, docID)
+	return nil
 }
 
 func (s *LikeService) Remove(ctx context.Context, userID, docID int) error {
-	return s.repo.RemoveLike(ctx, userID, docID)
+	if err := s.repo.RemoveLike(ctx, userID, docID); err != nil {
+		return err
+	}
+	s.publish(ctx, realtime.EventLikeRemoved, userID, docID)
+	return nil
+}
+
+// publish отправляет подписчикам документа новое число лайков
+func (s *LikeService) publish(ctx context.Context, eventType string, userID, docID int) {
+	if s.events == nil {
+		return
+	}
+	count, err := s.repo.CountLikes(ctx, docID)
+	if err != nil {
+		return
+	}
+	s.events.Publish(ctx, realtime.DocumentEvent(eventType, docID, map[string]int{
+		"likes":   count,
+		"user_id": userID,
+	}))
 }
 
 func (s *LikeService) Count(ctx context.Context, docID int) (int, error) {
@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// APIEmail — письмо в том виде, в каком его принимают HTTP API транзакционных провайдеров
+type APIEmail struct {
+	FromEmail string `json:"from"`
+	FromName  string `json:"from_name,omitempty"`
+	To        string `json:"to"`
+	Subject   string `json:"subject"`
+	Text      string `json:"text"`
+	HTML      string `json:"html,omitempty"`
+}
+
+// APIClient — клиент HTTP API провайдера (Postmark, Mailgun, SendGrid и т.п.);
+// реализация переводит APIEmail в формат провайдера
+type APIClient interface {
+	Send(ctx context.Context, email APIEmail) error
+}
+
+// HTTPTransport отправляет письма через APIClient
+type HTTPTransport struct {
+	client    APIClient
+	fromEmail string
+	fromName  string
+}
+
+func NewHTTPTransport(client APIClient, fromEmail, fromName string) *HTTPTransport {
+	return &HTTPTransport{client: client, fromEmail: fromEmail, fromName: fromName}
+}
+
+func (t *HTTPTransport) SendMessage(to string, msg Message) error {
+	return t.client.Send(context.Background(), APIEmail{
+		FromEmail: t.fromEmail,
+		FromName:  t.fromName,
+		To:        to,
+		Subject:   msg.Subject,
+		Text:      msg.Text,
+		HTML:      msg.HTML,
+	})
+}
+
+// JSONAPIClient — универсальный клиент: POST APIEmail в JSON с ключом в заголовке Authorization: Bearer.
+// Подходит для провайдеров с таким API и для собственного шлюза рассылки.
+type JSONAPIClient struct {
+	url    string
+	apiKey string
+	http   *http.Client
+}
+
+func NewJSONAPIClient(url, apiKey string, timeout time.Duration) *JSONAPIClient {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &JSONAPIClient{url: url, apiKey: apiKey, http: &http.Client{Timeout: timeout}}
+}
+
+func (c *JSONAPIClient) Send(ctx context.Context, email APIEmail) error {
+	body, err := json.Marshal(email)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("email api: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("email api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("email api: статус %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileTransport складывает письма в maildir (<dir>/new/*.eml): их можно открыть почтовым клиентом
+// или просто текстовым редактором, реальные письма при этом не уходят
+type FileTransport struct {
+	dir       string
+	fromEmail string
+	fromName  string
+}
+
+// NewFileTransport создаёт каталоги maildir (tmp, new, cur), если их нет
+func NewFileTransport(dir, fromEmail, fromName string) (*FileTransport, error) {
+	if dir == "" {
+		return nil, errors.New("file transport: не задан каталог")
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("file transport: %w", err)
+		}
+	}
+	return &FileTransport{dir: dir, fromEmail: fromEmail, fromName: fromName}, nil
+}
+
+// SendMessage пишет письмо в tmp и переносит в new — читатель maildir не увидит недописанный файл
+func (t *FileTransport) SendMessage(to string, msg Message) error {
+	raw, err := BuildMIME(t.fromName, t.fromEmail, to, msg)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 6)
+	_, _ = rand.Read(suffix)
+	name := fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), hex.EncodeToString(suffix))
+
+	tmp := filepath.Join(t.dir, "tmp", name)
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("file transport: %w", err)
+	}
+	dst := filepath.Join(t.dir, "new", name)
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("file transport: %w", err)
+	}
+
+	log.Printf("📧 Письмо для %s сохранено: %s", to, dst)
+	return nil
+}
+
+// SentMessage — письмо, принятое MemoryTransport
+type SentMessage struct {
+	To      string
+	Message Message
+	SentAt  time.Time
+}
+
+// MemoryTransport запоминает письма в памяти процесса (тесты, отладка)
+type MemoryTransport struct {
+	mu   sync.Mutex
+	sent []SentMessage
+	// Err, если задан, возвращается вместо отправки — для проверки повторов
+	Err error
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) SendMessage(to string, msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Err != nil {
+		return t.Err
+	}
+	t.sent = append(t.sent, SentMessage{To: to, Message: msg, SentAt: time.Now()})
+	log.Printf("📧 Письмо для %s сохранено в памяти: %s", to, msg.Subject)
+	return nil
+}
+
+// Messages — копия принятых писем в порядке отправки
+func (t *MemoryTransport) Messages() []SentMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SentMessage(nil), t.sent...)
+}
+
+// Reset очищает принятые письма
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	t.sent = nil
+	t.mu.Unlock()
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message — готовое к отправке письмо; HTML необязателен
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// BuildMIME собирает письмо: text/plain или multipart/alternative (text + HTML).
+// Заголовки с кириллицей кодируются по RFC 2047, тела — quoted-printable.
+func BuildMIME(fromName, fromEmail, to string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	from := fromEmail
+	if fromName != "" {
+		from = fmt.Sprintf("%s <%s>", mime.BEncoding.Encode("UTF-8", fromName), fromEmail)
+	}
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", to)
+	writeHeader(&buf, "Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID(fromEmail))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		writeHeader(&buf, "Content-Type", `text/plain; charset="UTF-8"`)
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", fmt.Sprintf(`multipart/alternative; boundary="%s"`, mw.Boundary()))
+	buf.WriteString("\r\n")
+
+	// Порядок важен: почтовые клиенты показывают последнюю понятную им часть
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{`text/plain; charset="UTF-8"`, msg.Text},
+		{`text/html; charset="UTF-8"`, msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка создания части письма: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка сборки письма: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	// Переводы строк в значении позволили бы подставить произвольные заголовки
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+}
+
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return fmt.Errorf("ошибка кодирования письма: %w", err)
+	}
+	return qp.Close()
+}
+
+func messageID(fromEmail string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(fromEmail, "@"); i >= 0 && i < len(fromEmail)-1 {
+		domain = fromEmail[i+1:]
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s.%d@%s>", hex.EncodeToString(b), time.Now().UnixNano(), domain)
+}
@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Режимы шифрования SMTP
+const (
+	SMTPStartTLS = "starttls" // обычное соединение с обязательным STARTTLS (порт 587), по умолчанию
+	SMTPTLS      = "tls"      // неявный TLS с первого байта (порт 465)
+	SMTPNone     = "none"     // без шифрования — только локальные ловушки писем (MailHog на 1025)
+)
+
+var ErrSTARTTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+
+type SMTPConfig struct {
+	Host               string
+	Port               int
+	Username           string // пусто — без авторизации
+	Password           string
+	FromEmail          string
+	FromName           string
+	TLS                string // starttls, tls или none
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// SMTPTransport отправляет письма через SMTP сервер
+type SMTPTransport struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPTransport проверяет настройки SMTP
+func NewSMTPTransport(cfg SMTPConfig) (*SMTPTransport, error) {
+	if cfg.Host == "" || cfg.Port == 0 {
+		return nil, errors.New("smtp: не заданы host и port")
+	}
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = SMTPStartTLS
+	case SMTPStartTLS, SMTPTLS, SMTPNone:
+	default:
+		return nil, fmt.Errorf("smtp: неизвестный режим tls %q (starttls, tls, none)", cfg.TLS)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &SMTPTransport{cfg: cfg}, nil
+}
+
+// SendMessage собирает MIME письмо и отправляет его через SMTP
+func (t *SMTPTransport) SendMessage(to string, msg Message) error {
+	raw, err := BuildMIME(t.cfg.FromName, t.cfg.FromEmail, to, msg)
+	if err != nil {
+		return err
+	}
+
+	c, err := t.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", t.cfg.Host, err)
+	}
+	defer c.Close()
+
+	if t.cfg.Username != "" {
+		// PlainAuth сам откажется передавать пароль по нешифрованному соединению к внешнему серверу
+		if err := c.Auth(smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(t.cfg.FromEmail); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp RCPT TO %s: %w", to, err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := c.Quit(); err != nil {
+		log.Printf("⚠️ SMTP QUIT: %v", err)
+	}
+
+	log.Printf("✅ Email отправлен через %s на %s", t.cfg.Host, to)
+	return nil
+}
+
+func (t *SMTPTransport) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+	dialer := &net.Dialer{Timeout: t.cfg.Timeout}
+	tlsConfig := &tls.Config{ServerName: t.cfg.Host, InsecureSkipVerify: t.cfg.InsecureSkipVerify}
+
+	var conn net.Conn
+	var err error
+	if t.cfg.TLS == SMTPTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Зависший сервер не должен держать отправителя очереди бесконечно
+	_ = conn.SetDeadline(time.Now().Add(t.cfg.Timeout))
+
+	c, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if t.cfg.TLS == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close()
+			return nil, ErrSTARTTLSUnsupported
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("starttls: %w", err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,211 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Типы писем; имя совпадает с файлом шаблона templates/<язык>/<тип>.tmpl
+const (
+	TemplateVerification         = "verification"
+	TemplateInvite               = "invite"
+	TemplateReset                = "reset"
+	TemplateReminder             = "reminder"
+	TemplateDigest               = "digest"
+	TemplateEmailChangeConfirm   = "email_change_confirm"
+	TemplateEmailChangeRequested = "email_change_requested"
+	TemplateEmailChanged         = "email_changed"
+
+	// Уведомления канала email; имя совпадает с типом события
+	TemplateDocumentPublished = "document_published"
+	TemplateCommentReply      = "comment_reply"
+	TemplateCommentMention    = "comment_mention"
+	TemplateRoleChanged       = "role_changed"
+)
+
+const (
+	LanguageRU = "ru"
+	LanguageEN = "en"
+)
+
+// SupportedLanguages — языки, для которых есть встроенные шаблоны
+var SupportedLanguages = []string{LanguageRU, LanguageEN}
+
+// ErrUnknownTemplate — шаблон с таким именем не найден ни для одного языка
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// Явный шаблон пути: при встраивании каталога целиком файлы на "_" (_base.tmpl) пропускаются
+//
+//go:embed templates/*/*.tmpl
+var embedded embed.FS
+
+// Каждый шаблон определяет блоки subject, text и html; общие блоки лежат в _base.tmpl языка
+const baseFile = "_base.tmpl"
+
+type compiled struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer рендерит письма из встроенных шаблонов; файлы из overrideDir/<язык>/ заменяют встроенные
+type Renderer struct {
+	defaultLang string
+	templates   map[string]map[string]compiled // язык → тип → шаблон
+}
+
+// NewRenderer загружает и компилирует все шаблоны при старте, чтобы ошибка в переопределении
+// проявилась сразу, а не при первой отправке
+func NewRenderer(overrideDir, defaultLang string) (*Renderer, error) {
+	defaultLang = NormalizeLanguage(defaultLang, LanguageRU)
+
+	r := &Renderer{defaultLang: defaultLang, templates: map[string]map[string]compiled{}}
+	for _, lang := range SupportedLanguages {
+		sources, err := loadSources(lang, overrideDir)
+		if err != nil {
+			return nil, err
+		}
+		base := sources[baseFile]
+
+		r.templates[lang] = map[string]compiled{}
+		for file, src := range sources {
+			if file == baseFile {
+				continue
+			}
+			name := strings.TrimSuffix(file, ".tmpl")
+			t, err := compile(lang+"/"+name, base, src)
+			if err != nil {
+				return nil, err
+			}
+			r.templates[lang][name] = t
+		}
+	}
+	return r, nil
+}
+
+// NormalizeLanguage приводит язык пользователя к поддерживаемому ("en-US" → "en"), иначе fallback
+func NormalizeLanguage(lang, fallback string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	for _, l := range SupportedLanguages {
+		if l == lang {
+			return l
+		}
+	}
+	return fallback
+}
+
+// Render возвращает письмо на языке пользователя; если шаблона на этом языке нет — на языке по умолчанию.
+// В data дополнительно доступны .Lang и .Year.
+func (r *Renderer) Render(name, lang string, data map[string]interface{}) (Message, error) {
+	lang = NormalizeLanguage(lang, r.defaultLang)
+	t, ok := r.templates[lang][name]
+	if !ok {
+		t, ok = r.templates[r.defaultLang][name]
+		lang = r.defaultLang
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	vars := map[string]interface{}{"Lang": lang, "Year": time.Now().Year()}
+	for k, v := range data {
+		vars[k] = v
+	}
+
+	var msg Message
+	var buf bytes.Buffer
+	if err := t.text.ExecuteTemplate(&buf, "subject", vars); err != nil {
+		return msg, fmt.Errorf("ошибка шаблона %s/%s (subject): %w", lang, name, err)
+	}
+	msg.Subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := t.text.ExecuteTemplate(&buf, "text", vars); err != nil {
+		return msg, fmt.Errorf("ошибка шаблона %s/%s (text): %w", lang, name, err)
+	}
+	msg.Text = strings.TrimSpace(buf.String()) + "\n"
+
+	if t.html.Lookup("html") != nil {
+		buf.Reset()
+		if err := t.html.ExecuteTemplate(&buf, "html", vars); err != nil {
+			return msg, fmt.Errorf("ошибка шаблона %s/%s (html): %w", lang, name, err)
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
+
+// loadSources читает встроенные шаблоны языка и накладывает поверх переопределения из каталога
+func loadSources(lang, overrideDir string) (map[string]string, error) {
+	sources := map[string]string{}
+
+	entries, err := fs.ReadDir(embedded, path.Join("templates", lang))
+	if err != nil {
+		return nil, fmt.Errorf("встроенные шаблоны %s: %w", lang, err)
+	}
+	for _, e := range entries {
+		raw, err := embedded.ReadFile(path.Join("templates", lang, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sources[e.Name()] = string(raw)
+	}
+
+	if overrideDir == "" {
+		return sources, nil
+	}
+	files, err := filepath.Glob(filepath.Join(overrideDir, lang, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("чтение шаблона %s: %w", file, err)
+		}
+		sources[filepath.Base(file)] = string(raw)
+	}
+	return sources, nil
+}
+
+// funcs — функции, доступные в шаблонах
+var funcs = map[string]interface{}{
+	"datetime": func(t time.Time) string { return t.Format("02.01.2006 15:04") },
+}
+
+// compile разбирает один и тот же исходник дважды: text/template для темы и текстовой части,
+// html/template — для HTML с автоматическим экранированием
+func compile(name, base, src string) (compiled, error) {
+	textT, err := texttemplate.New(name).Funcs(funcs).Parse(base)
+	if err == nil {
+		_, err = textT.Parse(src)
+	}
+	if err != nil {
+		return compiled{}, fmt.Errorf("ошибка разбора шаблона %s: %w", name, err)
+	}
+	htmlT, err := htmltemplate.New(name).Funcs(funcs).Parse(base)
+	if err == nil {
+		_, err = htmlT.Parse(src)
+	}
+	if err != nil {
+		return compiled{}, fmt.Errorf("ошибка разбора шаблона %s: %w", name, err)
+	}
+	for _, block := range []string{"subject", "text"} {
+		if textT.Lookup(block) == nil {
+			return compiled{}, fmt.Errorf("шаблон %s: нет блока %q", name, block)
+		}
+	}
+	return compiled{text: textT, html: htmlT}, nil
+}
@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Transport доставляет готовое письмо одному получателю
+type Transport interface {
+	SendMessage(to string, msg Message) error
+}
+
+// Виды транспорта для email.transport в конфиге
+const (
+	TransportSMTP   = "smtp"
+	TransportFile   = "file"   // maildir на диске, для локальной разработки
+	TransportMemory = "memory" // письма только в памяти процесса, для тестов
+	TransportHTTP   = "http"   // HTTP API транзакционного провайдера
+)
+
+var ErrUnknownTransport = errors.New("unknown email transport")
+
+// TransportConfig — выбор и настройки транспорта
+type TransportConfig struct {
+	Kind      string // smtp (по умолчанию), file, memory, http
+	FromEmail string
+	FromName  string
+	SMTP      SMTPConfig
+	Dir       string // каталог maildir для file
+	HTTPURL   string
+	HTTPKey   string
+	Timeout   time.Duration
+}
+
+// NewTransport создаёт транспорт по конфигу
+func NewTransport(cfg TransportConfig) (Transport, error) {
+	switch cfg.Kind {
+	case "", TransportSMTP:
+		smtpCfg := cfg.SMTP
+		smtpCfg.FromEmail, smtpCfg.FromName = cfg.FromEmail, cfg.FromName
+		if smtpCfg.Timeout == 0 {
+			smtpCfg.Timeout = cfg.Timeout
+		}
+		return NewSMTPTransport(smtpCfg)
+	case TransportFile:
+		return NewFileTransport(cfg.Dir, cfg.FromEmail, cfg.FromName)
+	case TransportMemory:
+		return NewMemoryTransport(), nil
+	case TransportHTTP:
+		client := NewJSONAPIClient(cfg.HTTPURL, cfg.HTTPKey, cfg.Timeout)
+		return NewHTTPTransport(client, cfg.FromEmail, cfg.FromName), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, cfg.Kind)
+}
+
+// ProviderName — имя провайдера для лимитов отправки: SMTP хост или вид транспорта
+func (cfg TransportConfig) ProviderName() string {
+	if (cfg.Kind == "" || cfg.Kind == TransportSMTP) && cfg.SMTP.Host != "" {
+		return cfg.SMTP.Host
+	}
+	if cfg.Kind == "" {
+		return TransportSMTP
+	}
+	return cfg.Kind
+}
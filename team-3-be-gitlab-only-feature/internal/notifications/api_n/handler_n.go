@@ -0,0 +1,147 @@
+package api_n
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/notifications/models_n"
+	"cmd/internal/notifications/repo_n"
+	"cmd/internal/notifications/service_n"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NotificationHandler struct {
+	service *service_n.NotificationService
+}
+
+func NewHandler(s *service_n.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: s}
+}
+
+// GET /notifications?unread=true&limit=20&offset=0
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	unreadOnly, _ := strconv.ParseBool(q.Get("unread"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	page, err := h.service.List(r.Context(), user.ID, unreadOnly, limit, offset)
+	if err != nil {
+		http.Error(w, "Ошибка получения уведомлений", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, page)
+}
+
+func (h *NotificationHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.service.UnreadCount(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Ошибка подсчёта уведомлений", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int{"unread": count})
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), user.ID, id); err != nil {
+		if errors.Is(err, repo_n.ErrNotFound) {
+			http.Error(w, "Уведомление не найдено", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Ошибка обновления уведомления", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	n, err := h.service.MarkAllRead(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Ошибка обновления уведомлений", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"updated": n})
+}
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := h.service.Preferences(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Ошибка получения настроек", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// PUT /notifications/preferences — [{"event_type": "comment_reply", "channel": "email"}]
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	var in []models_n.Preference
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(r.Context(), user.ID, in)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_n.ErrUnknownEvent):
+			http.Error(w, "Неизвестный тип события", http.StatusBadRequest)
+		case errors.Is(err, service_n.ErrUnknownChannel):
+			http.Error(w, "Канал должен быть in_app, email или none", http.StatusBadRequest)
+		default:
+			http.Error(w, "Ошибка сохранения настроек", http.StatusInternalServerError)
+		}
+		return
+	}
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+func respondJSON(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,56 @@
+package models_n
+
+import "time"
+
+// Типы событий; для писем имя совпадает с шаблоном mail
+const (
+	EventDocumentPublished = "document_published" // опубликован документ, доступный роли пользователя
+	EventCommentReply      = "comment_reply"      // новый комментарий к документу пользователя или к обсуждению, где он писал
+	EventCommentMention    = "comment_mention"    // пользователя упомянули в комментарии через @
+	EventRoleChanged       = "role_changed"       // администратор сменил роль пользователя
+)
+
+var EventTypes = []string{EventDocumentPublished, EventCommentReply, EventCommentMention, EventRoleChanged}
+
+// EventCommentWarning — предупреждение модератора; не настраивается и приходит только в центр уведомлений
+const EventCommentWarning = "comment_warning"
+
+// Каналы доставки
+const (
+	ChannelInApp = "in_app" // только центр уведомлений (по умолчанию)
+	ChannelEmail = "email"  // центр уведомлений и письмо
+	ChannelNone  = "none"   // не уведомлять
+)
+
+type Notification struct {
+	ID         int64      `json:"id"`
+	UserID     int        `json:"-"`
+	Type       string     `json:"type"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	DocumentID *int       `json:"document_id,omitempty"`
+	CommentID  *int       `json:"comment_id,omitempty"`
+	ActorID    *int       `json:"actor_id,omitempty"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type NotificationPage struct {
+	Items  []Notification `json:"items"`
+	Total  int            `json:"total"`
+	Unread int            `json:"unread"`
+}
+
+type Preference struct {
+	EventType string `json:"event_type"`
+	Channel   string `json:"channel"`
+}
+
+// Recipient — получатель события с выбранным для этого типа каналом
+type Recipient struct {
+	UserID    int
+	Email     string
+	FirstName string
+	Language  string
+	Channel   string
+}
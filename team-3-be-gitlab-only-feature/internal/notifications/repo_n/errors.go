@@ -0,0 +1,8 @@
+package repo_n
+
+import "errors"
+
+var (
+	ErrDBFailure = errors.New("database failure")
+	ErrNotFound  = errors.New("notification not found")
+)
@@ -0,0 +1,313 @@
+package repo_n
+
+import (
+	"cmd/internal/notifications/models_n"
+	userModels "cmd/internal/user/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type NotificationStorage struct {
+	DB *sql.DB
+}
+
+func NewNotificationStorage(db *sql.DB) *NotificationStorage {
+	return &NotificationStorage{DB: db}
+}
+
+// Получатели события: активные пользователи и их канал для типа $1 (нет настройки — in_app)
+const audienceQuery = `
+	SELECT u.id, u.email, u.first_name, u.language, COALESCE(p.channel, 'in_app')
+	FROM users u
+	LEFT JOIN notification_preferences p ON p.user_id = u.id AND p.event_type = $1
+	WHERE u.deleted_at IS NULL`
+
+// Документ $2 виден роли пользователя — те же правила, что и в списке документов
+const canSeeDocument = `
+	  AND (u.role_id IN (1, 2)
+	       OR NOT EXISTS (SELECT 1 FROM document_roles dr WHERE dr.document_id = $2)
+	       OR EXISTS (SELECT 1 FROM document_roles dr WHERE dr.document_id = $2 AND dr.role_id = u.role_id))`
+
+func (s *NotificationStorage) audience(ctx context.Context, where string, args ...interface{}) ([]models_n.Recipient, error) {
+	rows, err := s.DB.QueryContext(ctx, audienceQuery+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	var recipients []models_n.Recipient
+	for rows.Next() {
+		var r models_n.Recipient
+		if err := rows.Scan(&r.UserID, &r.Email, &r.FirstName, &r.Language, &r.Channel); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		recipients = append(recipients, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return recipients, nil
+}
+
+// 1. Все, кому виден новый документ, кроме автора
+func (s *NotificationStorage) DocumentAudience(ctx context.Context, docID, authorID int) ([]models_n.Recipient, error) {
+	return s.audience(ctx, canSeeDocument+`
+	  AND u.id <> $3`, models_n.EventDocumentPublished, docID, authorID)
+}
+
+// 2. Автор документа и участники обсуждения, кроме автора нового комментария и пользователей из exclude
+func (s *NotificationStorage) CommentAudience(ctx context.Context, docID, commenterID int, exclude []int) ([]models_n.Recipient, error) {
+	if exclude == nil {
+		exclude = []int{} // NULL в ANY отсёк бы всех
+	}
+	return s.audience(ctx, canSeeDocument+`
+	  AND u.id <> $3
+	  AND NOT (u.id = ANY($4))
+	  AND (u.id = (SELECT user_id FROM documents WHERE id = $2)
+	       OR u.id IN (SELECT c.user_id FROM comments c WHERE c.document_id = $2 AND c.is_deleted = FALSE))`,
+		models_n.EventCommentReply, docID, commenterID, exclude)
+}
+
+// 2a. Упомянутые в комментарии, кроме его автора
+func (s *NotificationStorage) MentionAudience(ctx context.Context, docID, commenterID int, userIDs []int) ([]models_n.Recipient, error) {
+	return s.audience(ctx, canSeeDocument+`
+	  AND u.id <> $3
+	  AND u.id = ANY($4)`,
+		models_n.EventCommentMention, docID, commenterID, userIDs)
+}
+
+// 3. Один пользователь
+func (s *NotificationStorage) UserAudience(ctx context.Context, eventType string, userID int) ([]models_n.Recipient, error) {
+	return s.audience(ctx, `
+	  AND u.id = $2`, eventType, userID)
+}
+
+// 4. Название документа для текста уведомления
+func (s *NotificationStorage) DocumentTitle(ctx context.Context, docID int) (string, error) {
+	var title string
+	err := s.DB.QueryRowContext(ctx, `SELECT title FROM documents WHERE id = $1`, docID).Scan(&title)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return title, nil
+}
+
+// 5. Имя и фамилия автора события
+func (s *NotificationStorage) UserName(ctx context.Context, userID int) (string, error) {
+	var first, last string
+	err := s.DB.QueryRowContext(ctx, `SELECT first_name, last_name FROM users WHERE id = $1`, userID).Scan(&first, &last)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return strings.TrimSpace(first + " " + last), nil
+}
+
+// 6. Название роли
+func (s *NotificationStorage) RoleName(ctx context.Context, roleID int) (string, error) {
+	var name string
+	err := s.DB.QueryRowContext(ctx, `SELECT name FROM roles WHERE id = $1`, roleID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return name, nil
+}
+
+// 7. Сохранить уведомления и письма канала email одной транзакцией; ID и время создания
+// проставляются в notes
+func (s *NotificationStorage) Create(ctx context.Context, notes []models_n.Notification, emails []userModels.OutboxEmail) error {
+	if len(notes) == 0 && len(emails) == 0 {
+		return nil
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range notes {
+		n := &notes[i]
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO notifications (user_id, type, title, body, document_id, comment_id, actor_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			n.UserID, n.Type, n.Title, n.Body, n.DocumentID, n.CommentID, n.ActorID, now,
+		).Scan(&n.ID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		n.CreatedAt = now
+	}
+	for _, e := range emails {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO email_outbox (user_id, recipient, template, subject, text_body, html_body, status, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $7)`,
+			e.UserID, e.Recipient, e.Template, e.Subject, e.Text, e.HTML, now,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+// 8. Уведомления пользователя, новые сверху
+func (s *NotificationStorage) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (*models_n.NotificationPage, error) {
+	page := &models_n.NotificationPage{Items: []models_n.Notification{}}
+
+	var total int
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE read_at IS NULL)
+		FROM notifications
+		WHERE user_id = $1`, userID).Scan(&total, &page.Unread)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	page.Total = total
+	if unreadOnly {
+		page.Total = page.Unread
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, user_id, type, title, body, document_id, comment_id, actor_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = FALSE OR read_at IS NULL)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n models_n.Notification
+		var docID, commentID, actorID sql.NullInt64
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body,
+			&docID, &commentID, &actorID, &readAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		n.DocumentID = nullableInt(docID)
+		n.CommentID = nullableInt(commentID)
+		n.ActorID = nullableInt(actorID)
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		page.Items = append(page.Items, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return page, nil
+}
+
+// 9. Количество непрочитанных
+func (s *NotificationStorage) UnreadCount(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := s.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return n, nil
+}
+
+// 10. Отметить прочитанным; повторная отметка не меняет время прочтения
+func (s *NotificationStorage) MarkRead(ctx context.Context, userID int, id int64) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// 11. Отметить прочитанными все
+func (s *NotificationStorage) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE notifications SET read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// 12. Настройки пользователя: тип события → канал
+func (s *NotificationStorage) GetPreferences(ctx context.Context, userID int) (map[string]string, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		`SELECT event_type, channel FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]string)
+	for rows.Next() {
+		var eventType, channel string
+		if err := rows.Scan(&eventType, &channel); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		prefs[eventType] = channel
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return prefs, nil
+}
+
+// 13. Сохранить настройки
+func (s *NotificationStorage) SavePreferences(ctx context.Context, userID int, prefs []models_n.Preference) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range prefs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, event_type, channel)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, event_type) DO UPDATE SET channel = EXCLUDED.channel`,
+			userID, p.EventType, p.Channel,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return nil
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
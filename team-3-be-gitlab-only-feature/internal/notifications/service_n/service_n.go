@@ -0,0 +1,297 @@
+package service_n
+
+import (
+	"cmd/internal/mail"
+	"cmd/internal/notifications/models_n"
+	"cmd/internal/notifications/repo_n"
+	"cmd/internal/realtime"
+	userModels "cmd/internal/user/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var (
+	ErrUnknownEvent   = errors.New("unknown notification event")
+	ErrUnknownChannel = errors.New("unknown notification channel")
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	excerptLength   = 200 // столько символов комментария попадает в текст уведомления
+)
+
+type NotificationService struct {
+	repo      *repo_n.NotificationStorage
+	templates *mail.Renderer
+	portalURL string
+	events    realtime.Publisher
+}
+
+// templates может быть nil — тогда канал email работает как in_app
+func NewNotificationService(r *repo_n.NotificationStorage, templates *mail.Renderer, portalURL string, events realtime.Publisher) *NotificationService {
+	return &NotificationService{repo: r, templates: templates, portalURL: portalURL, events: events}
+}
+
+func (s *NotificationService) documentURL(docID int) string {
+	return fmt.Sprintf("%s/documents/%d", s.portalURL, docID)
+}
+
+// deliver раскладывает событие по получателям согласно их настройкам: уведомление в центре,
+// а для канала email ещё и письмо в очередь исходящих (шаблон письма называется как событие)
+func (s *NotificationService) deliver(ctx context.Context, recipients []models_n.Recipient, note models_n.Notification, data map[string]interface{}) error {
+	var notes []models_n.Notification
+	var emails []userModels.OutboxEmail
+	for _, r := range recipients {
+		if r.Channel == models_n.ChannelNone {
+			continue
+		}
+		n := note
+		n.UserID = r.UserID
+		notes = append(notes, n)
+
+		if r.Channel != models_n.ChannelEmail || s.templates == nil {
+			continue
+		}
+		vars := map[string]interface{}{"FirstName": r.FirstName}
+		for k, v := range data {
+			vars[k] = v
+		}
+		msg, err := s.templates.Render(note.Type, r.Language, vars)
+		if err != nil {
+			log.Printf("⚠️ Не удалось подготовить письмо %s для пользователя %d: %v", note.Type, r.UserID, err)
+			continue
+		}
+		userID := r.UserID
+		emails = append(emails, userModels.OutboxEmail{
+			UserID:    &userID,
+			Recipient: r.Email,
+			Template:  note.Type,
+			Subject:   msg.Subject,
+			Text:      msg.Text,
+			HTML:      msg.HTML,
+		})
+	}
+
+	if err := s.repo.Create(ctx, notes, emails); err != nil {
+		return err
+	}
+	if len(notes) > 0 {
+		log.Printf("🔔 %s: уведомлений %d, писем %d", note.Type, len(notes), len(emails))
+	}
+	if s.events != nil {
+		for _, n := range notes {
+			s.events.Publish(ctx, realtime.UserEvent(realtime.EventNotification, n.UserID, n))
+		}
+	}
+	return nil
+}
+
+// DocumentPublished уведомляет всех, кому виден новый документ
+func (s *NotificationService) DocumentPublished(ctx context.Context, docID int, title string, authorID int) error {
+	recipients, err := s.repo.DocumentAudience(ctx, docID, authorID)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	actor, err := s.repo.UserName(ctx, authorID)
+	if err != nil {
+		return err
+	}
+
+	return s.deliver(ctx, recipients, models_n.Notification{
+		Type:       models_n.EventDocumentPublished,
+		Title:      "Новый документ",
+		Body:       fmt.Sprintf("%s опубликовал(а) документ «%s»", actor, title),
+		DocumentID: &docID,
+		ActorID:    &authorID,
+	}, map[string]interface{}{
+		"ActorName":     actor,
+		"DocumentTitle": title,
+		"URL":           s.documentURL(docID),
+	})
+}
+
+// commentData — название документа, автор и отрывок комментария для текста уведомления и письма
+func (s *NotificationService) commentData(ctx context.Context, docID, authorID int, text string) (map[string]interface{}, error) {
+	title, err := s.repo.DocumentTitle(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := s.repo.UserName(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	excerpt := []rune(text)
+	if len(excerpt) > excerptLength {
+		excerpt = append(excerpt[:excerptLength], '…')
+	}
+	return map[string]interface{}{
+		"ActorName":     actor,
+		"DocumentTitle": title,
+		"Text":          string(excerpt),
+		"URL":           s.documentURL(docID),
+	}, nil
+}
+
+// CommentCreated уведомляет упомянутых в комментарии, а автора документа и участников
+// обсуждения — если их не упомянули: одно уведомление на комментарий
+func (s *NotificationService) CommentCreated(ctx context.Context, docID, commentID, authorID int, text string, mentioned []int) error {
+	if err := s.CommentMentioned(ctx, docID, commentID, authorID, text, mentioned); err != nil {
+		return err
+	}
+
+	recipients, err := s.repo.CommentAudience(ctx, docID, authorID, mentioned)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	data, err := s.commentData(ctx, docID, authorID, text)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, recipients, models_n.Notification{
+		Type:       models_n.EventCommentReply,
+		Title:      "Новый комментарий",
+		Body:       fmt.Sprintf("%s к документу «%s»: %s", data["ActorName"], data["DocumentTitle"], data["Text"]),
+		DocumentID: &docID,
+		CommentID:  &commentID,
+		ActorID:    &authorID,
+	}, data)
+}
+
+// CommentMentioned уведомляет упомянутых в комментарии (кроме автора)
+func (s *NotificationService) CommentMentioned(ctx context.Context, docID, commentID, authorID int, text string, mentioned []int) error {
+	if len(mentioned) == 0 {
+		return nil
+	}
+	recipients, err := s.repo.MentionAudience(ctx, docID, authorID, mentioned)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	data, err := s.commentData(ctx, docID, authorID, text)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, recipients, models_n.Notification{
+		Type:       models_n.EventCommentMention,
+		Title:      "Вас упомянули",
+		Body:       fmt.Sprintf("%s упомянул(а) вас в обсуждении документа «%s»: %s", data["ActorName"], data["DocumentTitle"], data["Text"]),
+		DocumentID: &docID,
+		CommentID:  &commentID,
+		ActorID:    &authorID,
+	}, data)
+}
+
+// CommentWarning передаёт автору комментария предупреждение модератора
+func (s *NotificationService) CommentWarning(ctx context.Context, userID, docID, commentID, moderatorID int, note string) error {
+	title, err := s.repo.DocumentTitle(ctx, docID)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Ваш комментарий к документу «%s» нарушает правила обсуждений", title)
+	if note != "" {
+		body += ": " + note
+	}
+
+	warning := models_n.Notification{
+		Type:       models_n.EventCommentWarning,
+		Title:      "Предупреждение модератора",
+		Body:       body,
+		DocumentID: &docID,
+		CommentID:  &commentID,
+		ActorID:    &moderatorID,
+	}
+	return s.deliver(ctx, []models_n.Recipient{{UserID: userID, Channel: models_n.ChannelInApp}}, warning, nil)
+}
+
+// RoleChanged сообщает пользователю о новой роли
+func (s *NotificationService) RoleChanged(ctx context.Context, userID, roleID int) error {
+	recipients, err := s.repo.UserAudience(ctx, models_n.EventRoleChanged, userID)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	role, err := s.repo.RoleName(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	return s.deliver(ctx, recipients, models_n.Notification{
+		Type:  models_n.EventRoleChanged,
+		Title: "Роль изменена",
+		Body:  fmt.Sprintf("Ваша роль на портале изменена на «%s»", role),
+	}, map[string]interface{}{
+		"RoleName": role,
+		"URL":      s.portalURL,
+	})
+}
+
+func (s *NotificationService) List(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (*models_n.NotificationPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.List(ctx, userID, unreadOnly, limit, offset)
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID int) (int, error) {
+	return s.repo.UnreadCount(ctx, userID)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID int, id int64) error {
+	return s.repo.MarkRead(ctx, userID, id)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	return s.repo.MarkAllRead(ctx, userID)
+}
+
+// Preferences — настройки по всем типам событий, включая не заданные (in_app)
+func (s *NotificationService) Preferences(ctx context.Context, userID int) ([]models_n.Preference, error) {
+	saved, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make([]models_n.Preference, 0, len(models_n.EventTypes))
+	for _, event := range models_n.EventTypes {
+		channel, ok := saved[event]
+		if !ok {
+			channel = models_n.ChannelInApp
+		}
+		prefs = append(prefs, models_n.Preference{EventType: event, Channel: channel})
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences меняет только переданные типы событий
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID int, prefs []models_n.Preference) ([]models_n.Preference, error) {
+	for _, p := range prefs {
+		if !isEventType(p.EventType) {
+			return nil, ErrUnknownEvent
+		}
+		switch p.Channel {
+		case models_n.ChannelInApp, models_n.ChannelEmail, models_n.ChannelNone:
+		default:
+			return nil, ErrUnknownChannel
+		}
+	}
+	if err := s.repo.SavePreferences(ctx, userID, prefs); err != nil {
+		return nil, err
+	}
+	return s.Preferences(ctx, userID)
+}
+
+func isEventType(event string) bool {
+	for _, e := range models_n.EventTypes {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
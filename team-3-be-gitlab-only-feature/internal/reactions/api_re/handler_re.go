@@ -0,0 +1,99 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/reactions/repo_re"
+	"cmd/internal/reactions/service_re"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ReactionHandler struct {
+	service *service_re.ReactionService
+}
+
+func NewHandler(s *service_re.ReactionService) *ReactionHandler {
+	return &ReactionHandler{service: s}
+}
+
+// Allowed — набор реакций, которые можно поставить
+func (h *ReactionHandler) Allowed(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string][]string{"reactions": h.service.Allowed()})
+}
+
+// React — поставить реакцию на документ или, если в пути есть comment_id, на комментарий
+func (h *ReactionHandler) React(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	var in struct {
+		Emoji string `json:"emoji"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Emoji == "" {
+		http.Error(w, "Реакция обязательна", http.StatusBadRequest)
+		return
+	}
+
+	docID, commentID := targetIDs(r)
+	if err := h.service.React(r.Context(), user.ID, user.RoleID, docID, commentID, in.Emoji); err != nil {
+		switch {
+		case errors.Is(err, repo_re.ErrUnknownReaction):
+			http.Error(w, "Такой реакции нет в наборе", http.StatusBadRequest)
+		case errors.Is(err, repo_re.ErrTargetNotFound):
+			http.Error(w, "Документ или комментарий не найден", http.StatusNotFound)
+		case errors.Is(err, repo_re.ErrAlreadyReacted):
+			http.Error(w, "Реакция уже поставлена", http.StatusConflict)
+		default:
+			http.Error(w, "Ошибка реакции", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Unreact — снять реакцию; эмодзи передаётся в ?emoji=
+func (h *ReactionHandler) Unreact(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	emoji := r.URL.Query().Get("emoji")
+	if emoji == "" {
+		http.Error(w, "Реакция обязательна", http.StatusBadRequest)
+		return
+	}
+
+	docID, commentID := targetIDs(r)
+	if err := h.service.Unreact(r.Context(), user.ID, user.RoleID, docID, commentID, emoji); err != nil {
+		switch {
+		case errors.Is(err, repo_re.ErrTargetNotFound), errors.Is(err, repo_re.ErrNotFound):
+			http.Error(w, "Реакция не найдена", http.StatusNotFound)
+		default:
+			http.Error(w, "Ошибка снятия реакции", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// targetIDs — документ из пути и комментарий, если реакция на комментарий (иначе 0)
+func targetIDs(r *http.Request) (docID, commentID int) {
+	docID, _ = strconv.Atoi(chi.URLParam(r, "id"))
+	commentID, _ = strconv.Atoi(chi.URLParam(r, "comment_id"))
+	return docID, commentID
+}
+
+func respondJSON(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
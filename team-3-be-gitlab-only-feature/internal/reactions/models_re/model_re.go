@@ -0,0 +1,14 @@
+package models_re
+
+// Цели реакций
+const (
+	TargetDocument = "document"
+	TargetComment  = "comment"
+)
+
+// Reaction — сколько раз поставлена реакция и поставил ли её текущий пользователь
+type Reaction struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
@@ -0,0 +1,11 @@
+package repo_re
+
+import "errors"
+
+var (
+	ErrDBFailure       = errors.New("database error")
+	ErrNotFound        = errors.New("reaction not found")
+	ErrAlreadyReacted  = errors.New("user already reacted with this emoji")
+	ErrTargetNotFound  = errors.New("reaction target not found")
+	ErrUnknownReaction = errors.New("reaction is not in the allowed set")
+)
@@ -0,0 +1,114 @@
+package repo_re
+
+import (
+	"cmd/internal/access"
+	"cmd/internal/reactions/models_re"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type ReactionStorage struct {
+	DB *sql.DB
+}
+
+func NewReactionStorage(db *sql.DB) *ReactionStorage {
+	return &ReactionStorage{DB: db}
+}
+
+// Таблица и столбец цели; в запросы подставляются только эти константы
+var targets = map[string]struct{ table, column string }{
+	models_re.TargetDocument: {"document_reactions", "document_id"},
+	models_re.TargetComment:  {"comment_reactions", "comment_id"},
+}
+
+// 1. Поставить реакцию
+func (s *ReactionStorage) Add(ctx context.Context, target string, targetID, userID int, emoji string) error {
+	t := targets[target]
+	q := fmt.Sprintf(`
+		INSERT INTO %s (%s, user_id, emoji)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`, t.table, t.column)
+	res, err := s.DB.ExecContext(ctx, q, targetID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyReacted
+	}
+	return nil
+}
+
+// 2. Снять реакцию
+func (s *ReactionStorage) Remove(ctx context.Context, target string, targetID, userID int, emoji string) error {
+	t := targets[target]
+	q := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1 AND user_id = $2 AND emoji = $3`, t.table, t.column)
+	res, err := s.DB.ExecContext(ctx, q, targetID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// 3. Реакции сразу для нескольких целей одним запросом: число по каждому эмодзи
+// и поставил ли его viewerID
+func (s *ReactionStorage) Summaries(ctx context.Context, target string, ids []int, viewerID int) (map[int][]models_re.Reaction, error) {
+	result := make(map[int][]models_re.Reaction)
+	if len(ids) == 0 {
+		return result, nil
+	}
+	t := targets[target]
+	q := fmt.Sprintf(`
+		SELECT %[2]s, emoji, COUNT(*), BOOL_OR(user_id = $2)
+		FROM %[1]s
+		WHERE %[2]s = ANY($1)
+		GROUP BY %[2]s, emoji`, t.table, t.column)
+	rows, err := s.DB.QueryContext(ctx, q, ids, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var r models_re.Reaction
+		if err := rows.Scan(&id, &r.Emoji, &r.Count, &r.Reacted); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+		}
+		result[id] = append(result[id], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return result, nil
+}
+
+// 4. Состояние документа: роль, статус согласования, публикация, удалён ли
+func (s *ReactionStorage) Document(ctx context.Context, docID int) (access.DocumentState, error) {
+	doc, err := access.LoadDocument(ctx, s.DB, docID)
+	if errors.Is(err, access.ErrDocumentNotFound) {
+		return doc, ErrTargetNotFound
+	}
+	if err != nil {
+		return doc, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return doc, nil
+}
+
+// 5. Комментарий относится к документу и не удалён и не скрыт
+func (s *ReactionStorage) CommentVisible(ctx context.Context, docID, commentID int) (bool, error) {
+	var exists bool
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM comments
+		               WHERE id = $1 AND document_id = $2 AND is_deleted = FALSE AND is_hidden = FALSE)`,
+		commentID, docID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrDBFailure, err)
+	}
+	return exists, nil
+}
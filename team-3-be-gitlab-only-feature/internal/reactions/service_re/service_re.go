@@ -0,0 +1,150 @@
+package service_re
+
+import (
+	"cmd/internal/reactions/models_re"
+	"cmd/internal/reactions/repo_re"
+	"cmd/internal/realtime"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultReactions — набор реакций, если в конфигурации он не задан
+var DefaultReactions = []string{"👍", "❤️", "😂", "🎉", "🤔", "👀"}
+
+type ReactionService struct {
+	repo    *repo_re.ReactionStorage
+	events  realtime.Publisher
+	allowed []string
+	order   map[string]int
+}
+
+// allowed — разрешённые реакции в порядке показа
+func NewReactionService(r *repo_re.ReactionStorage, events realtime.Publisher, allowed []string) *ReactionService {
+	s := &ReactionService{repo: r, events: events, order: make(map[string]int)}
+	for _, e := range allowed {
+		e = strings.TrimSpace(e)
+		if _, dup := s.order[e]; e == "" || dup {
+			continue
+		}
+		s.order[e] = len(s.allowed)
+		s.allowed = append(s.allowed, e)
+	}
+	if len(s.allowed) == 0 {
+		return NewReactionService(r, events, DefaultReactions)
+	}
+	return s
+}
+
+// Allowed — разрешённые реакции в порядке показа
+func (s *ReactionService) Allowed() []string {
+	return s.allowed
+}
+
+// React ставит реакцию на документ (commentID = 0) или на комментарий документа
+func (s *ReactionService) React(ctx context.Context, userID, roleID, docID, commentID int, emoji string) error {
+	if _, ok := s.order[emoji]; !ok {
+		return repo_re.ErrUnknownReaction
+	}
+	target, targetID, err := s.target(ctx, userID, roleID, docID, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Add(ctx, target, targetID, userID, emoji); err != nil {
+		return err
+	}
+	s.publish(ctx, userID, docID, target, targetID)
+	return nil
+}
+
+// Unreact снимает реакцию с документа (commentID = 0) или с комментария
+func (s *ReactionService) Unreact(ctx context.Context, userID, roleID, docID, commentID int, emoji string) error {
+	// Снять реакцию можно и после того, как её убрали из набора
+	target, targetID, err := s.target(ctx, userID, roleID, docID, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Remove(ctx, target, targetID, userID, emoji); err != nil {
+		return err
+	}
+	s.publish(ctx, userID, docID, target, targetID)
+	return nil
+}
+
+// target — цель реакции: комментарий документа docID или сам документ. Документ должен быть
+// виден пользователю; неопубликованный чужой документ не отличить от несуществующего.
+func (s *ReactionService) target(ctx context.Context, userID, roleID, docID, commentID int) (string, int, error) {
+	doc, err := s.repo.Document(ctx, docID)
+	if err != nil {
+		return "", 0, err
+	}
+	if !doc.OpenTo(roleID, userID, time.Now()) {
+		return "", 0, repo_re.ErrTargetNotFound
+	}
+	if commentID != 0 {
+		ok, err := s.repo.CommentVisible(ctx, docID, commentID)
+		if err != nil {
+			return "", 0, err
+		}
+		if !ok {
+			return "", 0, repo_re.ErrTargetNotFound
+		}
+		return models_re.TargetComment, commentID, nil
+	}
+	return models_re.TargetDocument, docID, nil
+}
+
+// publish отправляет подписчикам документа новые счётчики реакций цели
+func (s *ReactionService) publish(ctx context.Context, userID, docID int, target string, targetID int) {
+	if s.events == nil {
+		return
+	}
+	summaries, err := s.summaries(ctx, target, []int{targetID}, 0)
+	if err != nil {
+		return
+	}
+	reactions := summaries[targetID]
+	if reactions == nil {
+		reactions = []models_re.Reaction{}
+	}
+	s.events.Publish(ctx, realtime.DocumentEvent(realtime.EventReactionChanged, docID, map[string]any{
+		"target":    target,
+		"target_id": targetID,
+		"user_id":   userID,
+		"reactions": reactions,
+	}))
+}
+
+// DocumentReactions — реакции документов одним запросом
+func (s *ReactionService) DocumentReactions(ctx context.Context, docIDs []int, viewerID int) (map[int][]models_re.Reaction, error) {
+	return s.summaries(ctx, models_re.TargetDocument, docIDs, viewerID)
+}
+
+// CommentReactions — реакции комментариев одним запросом
+func (s *ReactionService) CommentReactions(ctx context.Context, commentIDs []int, viewerID int) (map[int][]models_re.Reaction, error) {
+	return s.summaries(ctx, models_re.TargetComment, commentIDs, viewerID)
+}
+
+// summaries упорядочивает реакции как в наборе; убранные из набора — в конце, по убыванию числа
+func (s *ReactionService) summaries(ctx context.Context, target string, ids []int, viewerID int) (map[int][]models_re.Reaction, error) {
+	result, err := s.repo.Summaries(ctx, target, ids, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, reactions := range result {
+		sort.SliceStable(reactions, func(i, j int) bool {
+			oi, iKnown := s.order[reactions[i].Emoji]
+			oj, jKnown := s.order[reactions[j].Emoji]
+			switch {
+			case iKnown && jKnown:
+				return oi < oj
+			case iKnown != jKnown:
+				return iKnown
+			default:
+				return reactions[i].Count > reactions[j].Count
+			}
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,291 @@
+package realtime
+
+import (
+	"cmd/internal/access"
+	"cmd/internal/eventbus"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Типы событий потока
+const (
+	EventLikeAdded         = "like.added"
+	EventLikeRemoved       = "like.removed"
+	EventViewAdded         = "view.added"
+	EventReactionChanged   = "reaction.changed"
+	EventCommentCreated    = "comment.created"
+	EventCommentUpdated    = "comment.updated"
+	EventCommentDeleted    = "comment.deleted"
+	EventCommentHidden     = "comment.hidden"
+	EventAnnotationCreated = "annotation.created"
+	EventAnnotationUpdated = "annotation.updated"
+	EventAnnotationDeleted = "annotation.deleted"
+	EventDocumentCreated   = "document.created"
+	EventDocumentDeleted   = "document.deleted"
+	EventDocumentArchived  = "document.archived"
+	EventDocumentRestored  = "document.restored"
+	EventNotification      = "notification.created"
+	EventRoleChanged       = "role.changed" // поток закрывается: клиент переподключается с новым токеном
+	EventReset             = "reset"        // пропущенные события не восстановить — клиенту нужно перечитать данные
+)
+
+// FeedChannel — лента документов: создание и удаление, с учётом роли
+const FeedChannel = "documents"
+
+func DocumentChannel(docID int) string { return "document:" + strconv.Itoa(docID) }
+func UserChannel(userID int) string    { return "user:" + strconv.Itoa(userID) }
+
+const (
+	historySize   = 1024 // столько последних событий хранится для повтора по Last-Event-ID
+	subscriberBuf = 64   // не успевающий читать подписчик отключается и догоняет повтором
+)
+
+// TopicEvents — тема шины, через которую события доходят до потоков на всех инстансах
+const TopicEvents = "realtime.events"
+
+type Event struct {
+	ID         string      `json:"-"`
+	Type       string      `json:"type"`
+	Channel    string      `json:"channel"`
+	DocumentID int         `json:"document_id,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+
+	doc access.DocumentState // видимость документа, определяется при публикации
+}
+
+// DocumentEvent — событие канала документа (лайки, просмотры, комментарии)
+func DocumentEvent(eventType string, docID int, data interface{}) Event {
+	return Event{Type: eventType, Channel: DocumentChannel(docID), DocumentID: docID, Data: data}
+}
+
+// FeedEvent — событие ленты документов
+func FeedEvent(eventType string, docID int, data interface{}) Event {
+	return Event{Type: eventType, Channel: FeedChannel, DocumentID: docID, Data: data}
+}
+
+// UserEvent — событие, адресованное одному пользователю
+func UserEvent(eventType string, userID int, data interface{}) Event {
+	return Event{Type: eventType, Channel: UserChannel(userID), Data: data}
+}
+
+// Publisher — куда сервисы отправляют события; реализует Hub
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// DocumentAccess определяет, кому виден документ: роль, статус согласования, дата публикации
+type DocumentAccess interface {
+	DocumentState(ctx context.Context, docID int) (access.DocumentState, error)
+}
+
+// wireEvent — событие в шине между инстансами; видимость документа уже определена отправителем
+type wireEvent struct {
+	Type       string                `json:"type"`
+	Channel    string                `json:"channel"`
+	DocumentID int                   `json:"document_id,omitempty"`
+	Data       json.RawMessage       `json:"data,omitempty"`
+	Doc        *access.DocumentState `json:"doc,omitempty"`
+}
+
+// Hub раздаёт события подписчикам потоков SSE своего инстанса. С шиной событие уходит
+// во все инстансы, и каждый раздаёт его своим подписчикам; ID и повтор у каждого инстанса свои.
+type Hub struct {
+	access DocumentAccess
+	bus    eventbus.Bus
+
+	mu      sync.Mutex
+	epoch   string // меняется при перезапуске: ID прошлого процесса не перепутать с текущими
+	seq     uint64
+	history [historySize]Event
+	subs    map[*Subscription]struct{}
+}
+
+// bus может быть nil — тогда события видят только подписчики этого инстанса
+func NewHub(access DocumentAccess, bus eventbus.Bus) *Hub {
+	h := &Hub{
+		access: access,
+		bus:    bus,
+		epoch:  strconv.FormatInt(time.Now().UnixMilli(), 36),
+		subs:   make(map[*Subscription]struct{}),
+	}
+	if bus != nil {
+		bus.Subscribe(TopicEvents, h.receive)
+	}
+	return h
+}
+
+type Subscription struct {
+	UserID   int
+	RoleID   int
+	channels map[string]bool
+	events   chan Event
+}
+
+// Events закрывается, если подписчик не успевал читать и был отключён
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) matches(e Event) bool {
+	if !s.channels[e.Channel] {
+		return false
+	}
+	if e.DocumentID == 0 {
+		return true
+	}
+	return e.doc.VisibleTo(s.RoleID, s.UserID, time.Now())
+}
+
+// Publish определяет видимость документа и отправляет событие в шину (без шины — сразу подписчикам).
+// Ошибки только логируются: событие в реальном времени не должно ломать действие, которое его породило.
+func (h *Hub) Publish(ctx context.Context, e Event) {
+	if h == nil {
+		return
+	}
+	if e.DocumentID != 0 {
+		if h.access == nil {
+			return
+		}
+		doc, err := h.access.DocumentState(ctx, e.DocumentID)
+		if err != nil {
+			log.Printf("⚠️ Событие %s документа %d не отправлено: %v", e.Type, e.DocumentID, err)
+			return
+		}
+		e.doc = doc
+	}
+
+	if h.bus == nil {
+		h.deliver(e)
+		return
+	}
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		log.Printf("⚠️ Событие %s не отправлено: %v", e.Type, err)
+		return
+	}
+	w := wireEvent{Type: e.Type, Channel: e.Channel, DocumentID: e.DocumentID, Data: data}
+	if e.DocumentID != 0 {
+		w.Doc = &e.doc
+	}
+	if err := h.bus.Publish(ctx, TopicEvents, w); err != nil {
+		log.Printf("⚠️ Событие %s не отправлено в шину: %v", e.Type, err)
+	}
+}
+
+// receive — событие из шины (в том числе опубликованное этим инстансом)
+func (h *Hub) receive(msg eventbus.Message) {
+	var w wireEvent
+	if err := msg.Decode(&w); err != nil {
+		log.Printf("⚠️ Неверное событие в шине: %v", err)
+		return
+	}
+	e := Event{Type: w.Type, Channel: w.Channel, DocumentID: w.DocumentID, Data: w.Data}
+	if w.DocumentID != 0 {
+		if w.Doc == nil {
+			return // без видимости документа событие никому не отдаём
+		}
+		e.doc = *w.Doc
+	}
+	h.deliver(e)
+}
+
+// deliver сохраняет событие для повтора и раздаёт подписчикам этого инстанса
+func (h *Hub) deliver(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	e.ID = h.eventID(h.seq)
+	h.history[h.seq%historySize] = e
+
+	for s := range h.subs {
+		if !s.matches(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			log.Printf("⚠️ Поток пользователя %d не успевает читать события, отключаем", s.UserID)
+			h.drop(s)
+		}
+	}
+}
+
+// Subscribe регистрирует подписчика и возвращает события после lastEventID.
+// replayed=false, если lastEventID задан, но эти события уже вытеснены или принадлежат прошлому запуску.
+func (h *Hub) Subscribe(userID, roleID int, channels []string, lastEventID string) (sub *Subscription, missed []Event, replayed bool) {
+	sub = &Subscription{
+		UserID:   userID,
+		RoleID:   roleID,
+		channels: make(map[string]bool, len(channels)),
+		events:   make(chan Event, subscriberBuf),
+	}
+	for _, c := range channels {
+		sub.channels[c] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Регистрация под тем же замком, что и повтор: между ними событие не потеряется
+	h.subs[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	from, ok := h.parseEventID(lastEventID)
+	if !ok {
+		return sub, nil, false
+	}
+	var oldest uint64 = 1
+	if h.seq > historySize {
+		oldest = h.seq - historySize + 1
+	}
+	if from+1 < oldest {
+		return sub, nil, false
+	}
+	for seq := from + 1; seq <= h.seq; seq++ {
+		if e := h.history[seq%historySize]; sub.matches(e) {
+			missed = append(missed, e)
+		}
+	}
+	return sub, missed, true
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(sub)
+}
+
+// drop вызывается под h.mu
+func (h *Hub) drop(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+}
+
+func (h *Hub) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", h.epoch, seq)
+}
+
+// parseEventID вызывается под h.mu
+func (h *Hub) parseEventID(id string) (uint64, bool) {
+	epoch, seqStr, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > h.seq {
+		return 0, false
+	}
+	return seq, true
+}
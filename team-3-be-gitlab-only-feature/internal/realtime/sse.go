@@ -0,0 +1,151 @@
+package realtime
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHeartbeat = 25 * time.Second // чаще типичных таймаутов простоя прокси (30–60 с)
+	retryMillis      = 3000
+	maxDocuments     = 200
+)
+
+// SessionChecker — проверка, что access токен потока не отозван (смена роли, "выйти везде")
+type SessionChecker interface {
+	CheckTokenVersion(ctx context.Context, userID, tokenVersion int) error
+}
+
+// StreamHandler — поток событий Server-Sent Events.
+// GET /api/v1/stream?documents=1,2,3 — лента документов, свои события пользователя и события
+// перечисленных документов. При переподключении браузер сам присылает Last-Event-ID.
+type StreamHandler struct {
+	hub       *Hub
+	sessions  SessionChecker
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(hub *Hub, sessions SessionChecker, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	return &StreamHandler{hub: hub, sessions: sessions, heartbeat: heartbeat}
+}
+
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := custommiddleware.GetCurrentUser(r)
+	if !ok || user == nil {
+		http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+		return
+	}
+
+	channels := []string{FeedChannel, UserChannel(user.ID)}
+	if ids := r.URL.Query().Get("documents"); ids != "" {
+		parts := strings.Split(ids, ",")
+		if len(parts) > maxDocuments {
+			http.Error(w, fmt.Sprintf("Не более %d документов в одном потоке", maxDocuments), http.StatusBadRequest)
+			return
+		}
+		for _, p := range parts {
+			id, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || id <= 0 {
+				http.Error(w, "Неверный ID документа", http.StatusBadRequest)
+				return
+			}
+			channels = append(channels, DocumentChannel(id))
+		}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// Поток живёт дольше WriteTimeout сервера — снимаем дедлайн записи для этого соединения
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+
+	sub, missed, replayed := h.hub.Subscribe(user.ID, user.RoleID, channels, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	if !replayed {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", EventReset)
+	}
+	for _, e := range missed {
+		writeEvent(w, e)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			// Персональный токен несёт версию на момент подключения: отзыв сессий закрывает и его поток
+			if h.sessions != nil {
+				if err := h.sessions.CheckTokenVersion(r.Context(), user.ID, user.TokenVersion); err != nil {
+					return
+				}
+			}
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.Events():
+			if !ok {
+				return // отключены как медленный читатель; клиент переподключится и получит пропущенное
+			}
+			writeEvent(w, e)
+			if e.Type == EventRoleChanged {
+				rc.Flush()
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+// WithoutStreams применяет middleware ко всем запросам, кроме потоков событий:
+// таймаут запроса оборвал бы долгоживущее соединение SSE
+func WithoutStreams(mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsStreamRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsStreamRequest — запрос от EventSource
+func IsStreamRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
@@ -1,10 +1,12 @@
 package service_r
 
 import (
+	"cmd/internal/realtime"
 	"cmd/internal/roles/models_r"
 	"cmd/internal/roles/repo_r"
 	"context"
 	"errors"
+	"log"
 )
 
 var (
@@ -12,12 +14,25 @@ var (
 	ErrAccessDenied = errors.New("access denied")
 )
 
+// TokenRevoker отзывает выданные пользователю access токены, чтобы новая роль применилась сразу
+type TokenRevoker interface {
+	RevokeAccessTokens(ctx context.Context, userID int) error
+}
+
+// Notifier сообщает пользователю о смене роли
+type Notifier interface {
+	RoleChanged(ctx context.Context, userID, roleID int) error
+}
+
 type RoleService struct {
-	repo *repo_r.RoleStorage
+	repo     *repo_r.RoleStorage
+	revoker  TokenRevoker
+	notifier Notifier
+	events   realtime.Publisher
 }
 
-func NewRoleService(r *repo_r.RoleStorage) *RoleService {
-	return &RoleService{repo: r}
+func NewRoleService(r *repo_r.RoleStorage, revoker TokenRevoker, notifier Notifier, events realtime.Publisher) *RoleService {
+	return &RoleService{repo: r, revoker: revoker, notifier: notifier, events: events}
 }
 
 func (s *RoleService) GetAll(ctx context.Context) ([]models_r.Role, error) {
@@ -50,5 +65,25 @@ func (s *RoleService) ChangeUserRole(ctx context.Context, userID, newRoleID int,
 	}
 
 	// Меняем роль
-	return s.repo.UpdateUserRole(ctx, userID, newRoleID)
+	if err := s.repo.UpdateUserRole(ctx, userID, newRoleID); err != nil {
+		return err
+	}
+
+	// Старые токены несут прежнюю роль в claims — отзываем их
+	if s.revoker != nil {
+		if err := s.revoker.RevokeAccessTokens(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	if s.notifier != nil {
+		if err := s.notifier.RoleChanged(ctx, userID, newRoleID); err != nil {
+			log.Printf("⚠️ Не удалось уведомить пользователя %d о смене роли: %v", userID, err)
+		}
+	}
+	// Открытые потоки событий получили видимость документов по старой роли — закрываем их
+	if s.events != nil {
+		s.events.Publish(ctx, realtime.UserEvent(realtime.EventRoleChanged, userID, map[string]int{"role_id": newRoleID}))
+	}
+	return nil
 }
@@ -24,14 +24,17 @@ type Config struct {
 	SSLMode  string
 }
 
-// New создает новый экземпляр PostgresStorage
-func New(config Config) (*PostgresStorage, error) {
-	connStr := fmt.Sprintf(
+// DSN — строка подключения; нужна и отдельным соединениям pgx (LISTEN шины событий)
+func (c Config) DSN() string {
+	return fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		config.UserName, config.Password, config.Host, config.Port, config.DBName, config.SSLMode,
+		c.UserName, c.Password, c.Host, c.Port, c.DBName, c.SSLMode,
 	)
+}
 
-	db, err := sql.Open("pgx", connStr)
+// New создает новый экземпляр PostgresStorage
+func New(config Config) (*PostgresStorage, error) {
+	db, err := sql.Open("pgx", config.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -202,3 +202,26 @@ func (s *S3Storage) extractKeyFromPath(path string) string {
 
 	return path
 }
+
+// DeletePrefix удаляет все объекты с указанным префиксом и возвращает их количество
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.BucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	deleted := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("ошибка получения списка файлов S3: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if err := s.DeleteFile(ctx, aws.ToString(obj.Key)); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// SyncDirectoryHandler — запуск синхронизации с LDAP/AD вручную; ?dry_run=true только строит отчёт.
+func (h *Handler) SyncDirectoryHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		dryRun := false
+		if v := r.URL.Query().Get("dry_run"); v != "" {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "INVALID_DRY_RUN", "Параметр dry_run должен быть true или false")
+				return
+			}
+			dryRun = parsed
+		}
+
+		report, err := h.service.SyncDirectory(r.Context(), dryRun)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Синхронизация с каталогом выполнена",
+			Data:    report,
+		})
+	})(w, r)
+}
+
+// DirectorySyncReportHandler — отчёт последней синхронизации с каталогом.
+func (h *Handler) DirectorySyncReportHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		report, err := h.service.LastDirectorySyncReport(r.Context())
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Отчёт синхронизации",
+			Data:    report,
+		})
+	})(w, r)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/user/models"
+	"net/http"
+)
+
+// RequestEmailChangeHandler — запрос смены email (POST /user/email/change).
+// Ссылка подтверждения уходит на новый адрес, уведомление — на текущий.
+func (h *Handler) RequestEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		var req models.ChangeEmailRequest
+		if err := decodeJSON(w, r, &req, 1<<20); err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_JSON", "Неверный формат данных")
+			return
+		}
+		if err := h.validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Неверный email")
+			return
+		}
+
+		if err := h.service.RequestEmailChange(r.Context(), user.ID, req); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusAccepted, SuccessResponse{
+			Message: "Письмо для подтверждения отправлено на новый адрес",
+		})
+	})(w, r)
+}
+
+// ConfirmEmailChangeHandler — подтверждение нового email по токену из письма (POST /auth/email/confirm).
+// После смены все сессии завершаются, нужно войти заново.
+func (h *Handler) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		var req models.ConfirmEmailChangeRequest
+		if err := decodeJSON(w, r, &req, 1<<20); err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_JSON", "Неверный формат данных")
+			return
+		}
+		if err := h.validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Не указан токен")
+			return
+		}
+
+		if err := h.service.ConfirmEmailChange(r.Context(), req.Token); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Email изменён, войдите с новым адресом",
+		})
+	})(w, r)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListOutboxEmailsHandler — очередь исходящих писем (GET /admin/email-outbox?status=dead&limit=50&offset=0).
+// Тела писем не возвращаются: в них одноразовые ссылки пользователей.
+func (h *Handler) ListOutboxEmailsHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		limit, err := nonNegativeParam(q.Get("limit"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_LIMIT", "Параметр limit должен быть неотрицательным числом")
+			return
+		}
+		offset, err := nonNegativeParam(q.Get("offset"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_OFFSET", "Параметр offset должен быть неотрицательным числом")
+			return
+		}
+
+		page, err := h.service.ListOutboxEmails(r.Context(), q.Get("status"), limit, offset)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Очередь писем",
+			Data:    page,
+		})
+	})(w, r)
+}
+
+// ResendOutboxEmailHandler — повторная отправка недоставленного письма (POST /admin/email-outbox/{id}/resend).
+func (h *Handler) ResendOutboxEmailHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_ID", "Неверный формат ID письма")
+			return
+		}
+
+		if err := h.service.ResendOutboxEmail(r.Context(), id); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Письмо поставлено в очередь повторно"})
+	})(w, r)
+}
+
+// nonNegativeParam — необязательный числовой параметр запроса; пустой — 0
+func nonNegativeParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err == nil && n < 0 {
+		err = strconv.ErrRange
+	}
+	return n, err
+}
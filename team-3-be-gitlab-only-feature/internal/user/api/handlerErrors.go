@@ -44,6 +44,68 @@ func handleServiceError(w http.ResponseWriter, err error) {
 		respondWithError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Неверный email или пароль")
 	case service.ErrUserNotFound:
 		respondWithError(w, http.StatusNotFound, "USER_NOT_FOUND", "Пользователь не найден")
+	case service.ErrRefreshTokenInvalid:
+		respondWithError(w, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "Refresh токен недействителен или истёк")
+	case service.ErrTokenRevoked:
+		respondWithError(w, http.StatusUnauthorized, "TOKEN_REVOKED", "Сессия недействительна, войдите заново")
+	case service.ErrRefreshTokenReused:
+		respondWithError(w, http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "Сессия отозвана из соображений безопасности, войдите заново")
+	case service.ErrOIDCDisabled:
+		respondWithError(w, http.StatusNotFound, "SSO_DISABLED", "Вход через SSO не настроен")
+	case service.ErrOIDCStateInvalid:
+		respondWithError(w, http.StatusBadRequest, "INVALID_SSO_STATE", "Сессия входа через SSO истекла, начните вход заново")
+	case service.ErrOIDCAuthFailed:
+		respondWithError(w, http.StatusUnauthorized, "SSO_AUTH_FAILED", "Провайдер SSO не подтвердил вход")
+	case service.ErrOIDCEmailNotVerified:
+		respondWithError(w, http.StatusForbidden, "SSO_EMAIL_NOT_VERIFIED", "Email не подтверждён провайдером SSO")
+	case service.ErrAccountInactive:
+		respondWithError(w, http.StatusForbidden, "ACCOUNT_INACTIVE", "Учётная запись отключена")
+	case service.ErrDirectoryDisabled:
+		respondWithError(w, http.StatusNotFound, "DIRECTORY_SYNC_DISABLED", "Синхронизация с каталогом не настроена")
+	case service.ErrDirectorySyncRunning:
+		respondWithError(w, http.StatusConflict, "DIRECTORY_SYNC_RUNNING", "Синхронизация уже выполняется")
+	case service.ErrNoDirectorySyncRuns:
+		respondWithError(w, http.StatusNotFound, "NO_SYNC_REPORT", "Синхронизация ещё не запускалась")
+	case service.ErrPersonalTokenNotFound:
+		respondWithError(w, http.StatusNotFound, "TOKEN_NOT_FOUND", "Токен не найден")
+	case service.ErrInvalidTokenScope:
+		respondWithError(w, http.StatusBadRequest, "INVALID_SCOPE", "Неизвестная область действия токена")
+	case service.ErrTooManyPersonalTokens:
+		respondWithError(w, http.StatusConflict, "TOO_MANY_TOKENS", "Достигнут лимит персональных токенов, отзовите неиспользуемые")
+	case service.ErrRegistrationClosed:
+		respondWithError(w, http.StatusForbidden, "REGISTRATION_CLOSED", "Регистрация только по приглашению администратора")
+	case service.ErrUnknownRole:
+		respondWithError(w, http.StatusBadRequest, "UNKNOWN_ROLE", "Роль не найдена")
+	case service.ErrInvitationEmailFailed:
+		respondWithError(w, http.StatusBadGateway, "INVITATION_NOT_SENT", "Аккаунт создан, но письмо с приглашением не отправлено — повторите приглашение")
+	case service.ErrCannotModifySelf:
+		respondWithError(w, http.StatusBadRequest, "CANNOT_MODIFY_SELF", "Нельзя деактивировать или удалить собственную учётную запись")
+	case service.ErrInvalidReassignTarget:
+		respondWithError(w, http.StatusBadRequest, "INVALID_REASSIGN_TO", "Документы можно передать только другому активному пользователю")
+	case service.ErrUserAnonymized:
+		respondWithError(w, http.StatusConflict, "USER_ANONYMIZED", "Пользователь обезличен, восстановление невозможно")
+	case service.ErrUserFilesNotDeleted:
+		respondWithError(w, http.StatusBadGateway, "FILES_NOT_DELETED", "Не удалось удалить файлы пользователя, данные не изменены — повторите операцию")
+	case service.ErrInvalidCursor:
+		respondWithError(w, http.StatusBadRequest, "INVALID_CURSOR", "Курсор недействителен, начните с первой страницы")
+	case service.ErrOutboxEmailNotFound:
+		respondWithError(w, http.StatusNotFound, "EMAIL_NOT_FOUND", "Письмо не найдено в очереди")
+	case service.ErrOutboxEmailSent:
+		respondWithError(w, http.StatusConflict, "EMAIL_ALREADY_SENT", "Письмо уже доставлено")
+	case service.ErrInvalidOutboxStatus:
+		respondWithError(w, http.StatusBadRequest, "INVALID_STATUS", "Статус: pending, sent или dead")
+	case service.ErrUnsupportedLanguage:
+		respondWithError(w, http.StatusBadRequest, "UNSUPPORTED_LANGUAGE", "Поддерживаются языки: ru, en")
+	case service.ErrEmailChangeInvalid:
+		respondWithError(w, http.StatusBadRequest, "INVALID_EMAIL_CHANGE_TOKEN", "Ссылка подтверждения недействительна или истекла")
+	case service.ErrSameEmail:
+		respondWithError(w, http.StatusBadRequest, "SAME_EMAIL", "Новый email совпадает с текущим")
+	case service.ErrWrongPassword:
+		respondWithError(w, http.StatusForbidden, "WRONG_PASSWORD", "Неверный пароль")
+	case service.ErrEmailChangeUnavailable:
+		respondWithError(w, http.StatusServiceUnavailable, "EMAIL_UNAVAILABLE", "Отправка писем не настроена, смена email недоступна")
+	case service.ErrEmailChangeNotSent:
+		respondWithError(w, http.StatusBadGateway, "EMAIL_NOT_SENT", "Не удалось отправить письмо на новый адрес")
 	default:
 		respondWithError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Внутренняя ошибка сервера")
 	}
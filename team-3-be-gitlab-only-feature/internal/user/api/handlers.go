@@ -7,32 +7,42 @@ import (
 	"cmd/internal/user/service"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"runtime/debug"
 	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
 // Handler отвечает за HTTP-эндпоинты.
 type Handler struct {
 	service  service.Service
+	jwt      *service.JWTService
 	validate *validator.Validate
+	// ssoFrontendURL — куда вернуть пользователя после входа через SSO; пусто — ответ JSON
+	ssoFrontendURL string
 }
 
 // HandlerConfig — зависимости.
 type HandlerConfig struct {
 	Service service.Service
+	JWT     *service.JWTService
+	// SSOFrontendURL — адрес фронтенда, на который callback SSO передаёт токены во фрагменте URL
+	SSOFrontendURL string
 }
 
 // New — конструктор.
 func New(config HandlerConfig) *Handler {
 	validate := validators.NewAppValidator()
 	return &Handler{
-		service:  config.Service,
-		validate: validate,
+		service:        config.Service,
+		jwt:            config.JWT,
+		validate:       validate,
+		ssoFrontendURL: strings.TrimRight(config.SSOFrontendURL, "/"),
 	}
 }
 
@@ -381,7 +391,9 @@ func (h *Handler) GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 	})(w, r)
 }
 
-// GetUsersHandler — список пользователей.
+// GetUsersHandler — список пользователей для администратора.
+// Параметры: q, role_id, verified, department, status (active|inactive|all), sort (created_at|name|email),
+// order (asc|desc), limit, cursor.
 func (h *Handler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 	withRecover(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -389,19 +401,101 @@ func (h *Handler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		users, err := h.service.GetAllUsers(r.Context())
+		filter, err := parseUserFilter(r)
 		if err != nil {
-			log.Printf("❌ Ошибка получения пользователей: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "FETCH_ERROR", "Не удалось получить список пользователей")
+			respondWithError(w, http.StatusBadRequest, "INVALID_FILTER", err.Error())
+			return
+		}
+
+		page, err := h.service.SearchUsers(r.Context(), filter)
+		if err != nil {
+			handleServiceError(w, err)
 			return
 		}
 		respondWithJSON(w, http.StatusOK, SuccessResponse{
 			Message: "Список пользователей",
-			Data:    users,
+			Data:    page,
+		})
+	})(w, r)
+}
+
+// EmployeeDirectoryHandler — справочник сотрудников (GET /user/directory): имя, фото, роль и отдел.
+// Параметры те же, что у списка администратора, кроме verified и status; q ищет только по имени, sort=email не поддерживается.
+func (h *Handler) EmployeeDirectoryHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseUserFilter(r)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_FILTER", err.Error())
+			return
+		}
+
+		page, err := h.service.EmployeeDirectory(r.Context(), filter)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Справочник сотрудников",
+			Data:    page,
 		})
 	})(w, r)
 }
 
+// parseUserFilter разбирает параметры поиска пользователей из query
+func parseUserFilter(r *http.Request) (models.UserFilter, error) {
+	q := r.URL.Query()
+	filter := models.UserFilter{
+		Search:     strings.TrimSpace(q.Get("q")),
+		Department: strings.TrimSpace(q.Get("department")),
+		Cursor:     q.Get("cursor"),
+	}
+
+	if v := q.Get("role_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return filter, errors.New("Неверный role_id")
+		}
+		filter.RoleID = id
+	}
+	if v := q.Get("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("Параметр verified должен быть true или false")
+		}
+		filter.Verified = &verified
+	}
+	switch status := q.Get("status"); status {
+	case "", "active", "inactive", "all":
+		filter.Status = status
+	default:
+		return filter, errors.New("Параметр status: active, inactive или all")
+	}
+	switch sort := q.Get("sort"); sort {
+	case "", "created_at", "name", "email":
+		filter.Sort = sort
+	default:
+		return filter, errors.New("Параметр sort: created_at, name или email")
+	}
+	switch order := q.Get("order"); order {
+	case "asc":
+	case "desc":
+		filter.Desc = true
+	case "":
+		// Новые пользователи сверху, по имени и email — по алфавиту
+		filter.Desc = filter.Sort == "" || filter.Sort == "created_at"
+	default:
+		return filter, errors.New("Параметр order: asc или desc")
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("Неверный limit")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
 // ConfirmRegistrationHandler — основной флоу подтверждения: токен+пароль → verify+access/refresh.
 func (h *Handler) ConfirmRegistrationHandler(w http.ResponseWriter, r *http.Request) {
 	withRecover(func(w http.ResponseWriter, r *http.Request) {
@@ -514,7 +608,9 @@ func (h *Handler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
 	})(w, r)
 }
 
-// DeleteUserHandler реализует удаление пользователя
+// DeleteUserHandler мягко удаляет пользователя (?id=...): учётная запись деактивируется,
+// данные сохраняются. Окончательное удаление — PurgeUserHandler.
+// Необязательный reassign_to передаёт его документы другому пользователю.
 func (h *Handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	withRecover(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
@@ -522,14 +618,24 @@ func (h *Handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		admin, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
 		userIDStr := r.URL.Query().Get("id")
 		userID, err := strconv.Atoi(userIDStr)
 		if err != nil || userID <= 0 {
 			respondWithError(w, http.StatusBadRequest, "INVALID_USER_ID", "Неверный формат ID пользователя")
 			return
 		}
+		reassignTo, ok := reassignToParam(w, r)
+		if !ok {
+			return
+		}
 
-		if err := h.service.DeleteUser(r.Context(), userID); err != nil {
+		if err := h.service.DeactivateUser(r.Context(), admin.ID, userID, reassignTo); err != nil {
 			handleServiceError(w, err)
 			return
 		}
@@ -540,6 +646,153 @@ func (h *Handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	})(w, r)
 }
 
+// PurgeUserHandler окончательно удаляет пользователя (DELETE /admin/users/{id}/purge).
+// Комментарии, лайки и отметки об ознакомлении остаются без автора;
+// необязательный reassign_to передаёт его документы другому пользователю.
+func (h *Handler) PurgeUserHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil || userID <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_USER_ID", "Неверный формат ID пользователя")
+			return
+		}
+		reassignTo, ok := reassignToParam(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.DeleteUser(r.Context(), admin.ID, userID, reassignTo); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Пользователь удалён окончательно"})
+	})(w, r)
+}
+
+// DeactivateUserHandler отключает учётную запись без удаления данных (POST /admin/users/{id}/deactivate).
+// Необязательный reassign_to передаёт документы пользователя другому автору.
+func (h *Handler) DeactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil || userID <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_USER_ID", "Неверный формат ID пользователя")
+			return
+		}
+		reassignTo, ok := reassignToParam(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.DeactivateUser(r.Context(), admin.ID, userID, reassignTo); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Пользователь деактивирован"})
+	})(w, r)
+}
+
+// ReactivateUserHandler возвращает доступ деактивированному пользователю (POST /admin/users/{id}/reactivate).
+func (h *Handler) ReactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil || userID <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_USER_ID", "Неверный формат ID пользователя")
+			return
+		}
+
+		if err := h.service.ReactivateUser(r.Context(), userID); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Пользователь активирован"})
+	})(w, r)
+}
+
+// AnonymizeUserHandler необратимо обезличивает пользователя (POST /admin/users/{id}/anonymize).
+func (h *Handler) AnonymizeUserHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil || userID <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_USER_ID", "Неверный формат ID пользователя")
+			return
+		}
+
+		result, err := h.service.AnonymizeUser(r.Context(), admin.ID, userID)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Пользователь обезличен", Data: result})
+	})(w, r)
+}
+
+// ExportUserDataHandler отдаёт ZIP с персональными данными текущего пользователя (GET /user/me/export).
+func (h *Handler) ExportUserDataHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		archive, err := h.service.ExportUserData(r.Context(), user.ID)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dochub-export-user-%d.zip"`, user.ID))
+		w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(archive); err != nil {
+			log.Printf("⚠️ Ошибка отправки архива: %v", err)
+		}
+	})(w, r)
+}
+
+// reassignToParam читает необязательный параметр reassign_to; 0 — документы не передаются
+func reassignToParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("reassign_to")
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil || id <= 0 {
+		respondWithError(w, http.StatusBadRequest, "INVALID_REASSIGN_TO", "Неверный формат ID пользователя для передачи документов")
+		return 0, false
+	}
+	return id, true
+}
+
+// JWKSHandler — публичные ключи подписи для проверки токенов dochub другими сервисами.
+func (h *Handler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		// Кэш короче интервала перечитывания ключей, чтобы клиенты быстро увидели новый kid
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		respondWithJSON(w, http.StatusOK, h.jwt.JWKS())
+	})(w, r)
+}
+
 // -----------------------------------------------------------------------------
 // (опционально) Пример middleware с таймаутом контекста на хендлер
 // -----------------------------------------------------------------------------
@@ -0,0 +1,130 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/user/models"
+	"cmd/internal/user/service"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxInviteCSVSize — ограничение размера загружаемого CSV
+const maxInviteCSVSize = 2 << 20
+
+// InviteUserHandler — приглашение одного пользователя администратором.
+func (h *Handler) InviteUserHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		var req models.InviteUserRequest
+		if err := decodeJSON(w, r, &req, 1<<20); err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_JSON", "Неверный формат данных")
+			return
+		}
+		if err := h.validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Неверные данные в запросе")
+			return
+		}
+
+		user, reinvited, err := h.service.InviteUser(r.Context(), admin.ID, req)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		message := "Приглашение отправлено"
+		status := http.StatusCreated
+		if reinvited {
+			message = "Пользователь ещё не принял приглашение, ссылка отправлена повторно"
+			status = http.StatusOK
+		}
+		respondWithJSON(w, status, SuccessResponse{Message: message, Data: user})
+	})(w, r)
+}
+
+// ImportInvitationsHandler — массовое приглашение из CSV (multipart поле file или тело text/csv).
+// Возвращает отчёт по каждой строке; ошибки отдельных строк не прерывают импорт.
+func (h *Handler) ImportInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxInviteCSVSize)
+		var src io.Reader = r.Body
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "MISSING_FILE", "Приложите CSV файл в поле file")
+				return
+			}
+			defer file.Close()
+			src = file
+		}
+
+		rows, err := service.ParseInviteCSV(src)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_CSV", "CSV должен содержать заголовок с колонками name, email, role, department (не более 1000 строк)")
+			return
+		}
+
+		report := models.InviteImportReport{Total: len(rows), Rows: make([]models.InviteImportRow, 0, len(rows))}
+		for i, row := range rows {
+			result := models.InviteImportRow{Row: i + 2, Email: row.Email} // строка 1 — заголовок
+
+			if err := h.validate.Struct(row); err != nil {
+				result.Status = "invalid"
+				result.Error = "не заполнены имя/фамилия или неверный email"
+			} else {
+				user, reinvited, err := h.service.InviteUser(r.Context(), admin.ID, row)
+				if user != nil {
+					result.UserID = user.ID
+				}
+				switch {
+				case err == service.ErrUserAlreadyExists:
+					result.Status = "exists"
+					result.Error = "пользователь уже зарегистрирован"
+				case err == service.ErrUnknownRole:
+					result.Status = "invalid"
+					result.Error = "неизвестная роль"
+				case err == service.ErrAccountInactive:
+					result.Status = "inactive"
+					result.Error = "учётная запись деактивирована"
+				case err == service.ErrInvitationEmailFailed:
+					result.Status = "error"
+					result.Error = "письмо-приглашение не отправлено"
+				case err != nil:
+					// Подробности (в том числе ошибки базы) остаются в логе
+					log.Printf("❌ Импорт приглашений, строка %d: %v", result.Row, err)
+					result.Status = "error"
+					result.Error = "внутренняя ошибка, попробуйте позже"
+				case reinvited:
+					result.Status = "reinvited"
+				default:
+					result.Status = "invited"
+				}
+			}
+
+			if result.Status == "invited" || result.Status == "reinvited" {
+				report.Invited++
+			} else {
+				report.Failed++
+			}
+			report.Rows = append(report.Rows, result)
+		}
+
+		log.Printf("✅ Импорт приглашений: всего=%d приглашено=%d ошибок=%d", report.Total, report.Invited, report.Failed)
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Импорт приглашений завершён",
+			Data:    report,
+		})
+	})(w, r)
+}
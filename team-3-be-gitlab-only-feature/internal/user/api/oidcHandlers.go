@@ -0,0 +1,92 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OIDCLoginHandler — начало входа через SSO: редирект на страницу входа провайдера.
+// redirect_to — относительный путь фронтенда, куда вернуть пользователя после входа.
+func (h *Handler) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		if !h.service.OIDCEnabled() {
+			respondWithError(w, http.StatusNotFound, "SSO_DISABLED", "Вход через SSO не настроен")
+			return
+		}
+
+		redirectTo := safeRedirectPath(r.URL.Query().Get("redirect_to"))
+		authURL, err := h.service.BeginOIDCLogin(r.Context(), redirectTo)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})(w, r)
+}
+
+// OIDCCallbackHandler — возврат от провайдера с authorization code.
+// Если настроен адрес фронтенда, токены и redirect_to передаются ему во фрагменте URL (не попадают в логи серверов),
+// иначе возвращаются JSON как у обычного логина.
+func (h *Handler) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if providerErr := q.Get("error"); providerErr != "" {
+			log.Printf("⚠️ SSO: провайдер вернул ошибку %s: %s", providerErr, q.Get("error_description"))
+			h.ssoFailure(w, r, "SSO_AUTH_FAILED")
+			return
+		}
+
+		state, code := q.Get("state"), q.Get("code")
+		if state == "" || code == "" {
+			respondWithError(w, http.StatusBadRequest, "MISSING_PARAMS", "Отсутствуют обязательные параметры: state и code")
+			return
+		}
+
+		authResponse, redirectTo, err := h.service.CompleteOIDCLogin(r.Context(), state, code)
+		if err != nil {
+			if h.ssoFrontendURL == "" {
+				handleServiceError(w, err)
+				return
+			}
+			log.Printf("❌ SSO вход не удался: %v", err)
+			h.ssoFailure(w, r, "SSO_AUTH_FAILED")
+			return
+		}
+
+		if h.ssoFrontendURL == "" {
+			respondWithJSON(w, http.StatusOK, SuccessResponse{
+				Message: "Успешный вход",
+				Data:    authResponse,
+			})
+			return
+		}
+
+		fragment := url.Values{}
+		fragment.Set("access_token", authResponse.AccessToken)
+		fragment.Set("refresh_token", authResponse.RefreshToken)
+		fragment.Set("redirect_to", redirectTo)
+		http.Redirect(w, r, h.ssoFrontendURL+"#"+fragment.Encode(), http.StatusFound)
+	})(w, r)
+}
+
+func (h *Handler) ssoFailure(w http.ResponseWriter, r *http.Request, code string) {
+	if h.ssoFrontendURL == "" {
+		respondWithError(w, http.StatusUnauthorized, code, "Провайдер SSO не подтвердил вход")
+		return
+	}
+	http.Redirect(w, r, h.ssoFrontendURL+"?sso_error="+url.QueryEscape(code), http.StatusFound)
+}
+
+// safeRedirectPath пропускает только относительные пути, чтобы callback нельзя было использовать как open redirect
+func safeRedirectPath(p string) string {
+	if p == "" || !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, "\\") {
+		return "/"
+	}
+	if u, err := url.Parse(p); err != nil || u.Host != "" || u.Scheme != "" {
+		return "/"
+	}
+	return p
+}
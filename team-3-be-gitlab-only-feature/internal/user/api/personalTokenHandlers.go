@@ -0,0 +1,88 @@
+package api
+
+import (
+	custommiddleware "cmd/internal/custom_middleware"
+	"cmd/internal/user/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListPersonalTokensHandler — персональные токены текущего пользователя (без самих значений).
+func (h *Handler) ListPersonalTokensHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		tokens, err := h.service.ListPersonalTokens(r.Context(), user.ID)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, SuccessResponse{
+			Message: "Персональные токены",
+			Data:    tokens,
+		})
+	})(w, r)
+}
+
+// CreatePersonalTokenHandler — выпуск токена; значение возвращается только в этом ответе.
+func (h *Handler) CreatePersonalTokenHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		var req models.CreatePersonalTokenRequest
+		if err := decodeJSON(w, r, &req, 1<<20); err != nil {
+			respondWithError(w, http.StatusBadRequest, "INVALID_JSON", "Неверный формат данных")
+			return
+		}
+		if err := h.validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Неверные данные в запросе")
+			return
+		}
+
+		created, err := h.service.CreatePersonalToken(r.Context(), user.ID, req)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusCreated, SuccessResponse{
+			Message: "Токен создан. Сохраните его сейчас — повторно он показан не будет.",
+			Data:    created,
+		})
+	})(w, r)
+}
+
+// RevokePersonalTokenHandler — отзыв токена текущего пользователя.
+func (h *Handler) RevokePersonalTokenHandler(w http.ResponseWriter, r *http.Request) {
+	withRecover(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := custommiddleware.GetUserFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Пользователь не авторизован")
+			return
+		}
+
+		tokenID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil || tokenID <= 0 {
+			respondWithError(w, http.StatusBadRequest, "INVALID_TOKEN_ID", "Неверный формат ID токена")
+			return
+		}
+
+		if err := h.service.RevokePersonalToken(r.Context(), user.ID, tokenID); err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Токен отозван"})
+	})(w, r)
+}
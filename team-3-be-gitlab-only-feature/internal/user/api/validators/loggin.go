@@ -37,7 +37,7 @@ func IsValidEmail(email string) bool {
 
 	// Разделение email на 2 части
 	parts := strings.Split(email, "@")
-	if len(parts) > 2 {
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		return false
 	}
 	// Проверка local part
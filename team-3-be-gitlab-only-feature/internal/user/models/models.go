@@ -3,27 +3,103 @@ package models
 import "time"
 
 type User struct {
-	ID           int       `json:"id" db:"id"`
-	FirstName    string    `json:"first_name" db:"first_name"`
-	LastName     string    `json:"last_name" db:"last_name"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	Email        string    `json:"email" db:"email"`
-	PhotoPath    string    `json:"photoPath" db:"photo_path"`
-	IsVerified   bool      `json:"isVerified" db:"email_confirmed"`
-	DocumentsID  string    `json:"documentsID" db:"documents_id"`
-	RoleID       int       `json:"role_id" db:"role_id"`
-	RoleName     string    `json:"role" db:"role"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	ChangedAt    time.Time `json:"changed_at" db:"updated_at"`
-}
-
-// ✅ принимаем новый формат (role_id), и оставляем старый (role) на время миграции
+	ID           int        `json:"id" db:"id"`
+	FirstName    string     `json:"first_name" db:"first_name"`
+	LastName     string     `json:"last_name" db:"last_name"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	Email        string     `json:"email" db:"email"`
+	PhotoPath    string     `json:"photoPath" db:"photo_path"`
+	IsVerified   bool       `json:"isVerified" db:"email_confirmed"`
+	DocumentsID  string     `json:"documentsID" db:"documents_id"`
+	RoleID       int        `json:"role_id" db:"role_id"`
+	RoleName     string     `json:"role" db:"role"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	ChangedAt    time.Time  `json:"changed_at" db:"updated_at"`
+	Department   string     `json:"department" db:"department"`
+	Language     string     `json:"language" db:"language"` // язык писем: ru или en
+	InvitedBy    *int       `json:"-" db:"invited_by"`
+	InvitedAt    *time.Time `json:"-" db:"invited_at"`
+	// DeactivatedAt — пользователь деактивирован (users.deleted_at): не может войти и скрыт из списков
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty" db:"deleted_at"`
+	DeactivatedBy *int       `json:"-" db:"deactivated_by"`
+	AnonymizedAt  *time.Time `json:"anonymized_at,omitempty" db:"anonymized_at"`
+	TokenVersion  int        `json:"-" db:"token_version"`
+}
+
+// Роль при самостоятельной регистрации не выбирается: role_id и role принимаются ради старых клиентов и игнорируются
 type RegisterRequest struct {
 	Email     string `json:"email"       validate:"required,custom_email"`
 	FirstName string `json:"first_name"  validate:"required"`
 	LastName  string `json:"last_name"   validate:"required"`
 	RoleID    int    `json:"role_id,omitempty"`
 	RoleName  string `json:"role,omitempty"`
+	Language  string `json:"language,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+// InviteUserRequest — приглашение сотрудника администратором; роль задаётся id или названием
+type InviteUserRequest struct {
+	Email      string `json:"email"       validate:"required,custom_email"`
+	FirstName  string `json:"first_name"  validate:"required,max=100"`
+	LastName   string `json:"last_name"   validate:"required,max=100"`
+	RoleID     int    `json:"role_id,omitempty"`
+	RoleName   string `json:"role,omitempty"`
+	Department string `json:"department,omitempty" validate:"max=100"`
+	Language   string `json:"language,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+// InviteImportRow — результат обработки одной строки CSV
+type InviteImportRow struct {
+	Row    int    `json:"row"`
+	Email  string `json:"email"`
+	Status string `json:"status"` // invited, reinvited, exists, inactive, invalid, error
+	UserID int    `json:"user_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// InviteImportReport — отчёт импорта приглашений
+type InviteImportReport struct {
+	Total   int               `json:"total"`
+	Invited int               `json:"invited"`
+	Failed  int               `json:"failed"`
+	Rows    []InviteImportRow `json:"rows"`
+}
+
+// UserFilter — поиск, фильтры и сортировка списка пользователей с курсорной пагинацией
+type UserFilter struct {
+	Search     string // подстрока имени, фамилии или email
+	HideEmail  bool   // справочник сотрудников: email не участвует ни в поиске, ни в сортировке
+	RoleID     int
+	Verified   *bool
+	Department string
+	Status     string // active (по умолчанию), inactive, all
+	Sort       string // created_at (по умолчанию), name, email
+	Desc       bool
+	Limit      int
+	Cursor     string
+}
+
+// UserPage — страница пользователей; NextCursor пустой на последней странице
+type UserPage struct {
+	Items      []*User `json:"items"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// DirectoryEntry — карточка сотрудника в справочнике, только безопасные поля
+type DirectoryEntry struct {
+	ID         int    `json:"id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	PhotoPath  string `json:"photoPath"`
+	RoleName   string `json:"role"`
+	Department string `json:"department"`
+}
+
+// DirectoryPage — страница справочника сотрудников
+type DirectoryPage struct {
+	Items      []DirectoryEntry `json:"items"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
 }
 
 type LoginRequest struct {
@@ -39,7 +115,114 @@ type AuthResponse struct {
 	Message      string `json:"message"`
 }
 
+// ChangeEmailRequest — смена email; пароль обязателен, если он задан у пользователя
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,custom_email"`
+	Password string `json:"password"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 type VerifyEmailRequest struct {
 	UserID int    `json:"user_id" validate:"required"`
 	Token  string `json:"token"    validate:"required"`
 }
+
+// PersonalToken — персональный токен доступа (API) пользователя; сам токен показывается только при создании
+type PersonalToken struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Prefix     string     `json:"prefix" db:"token_prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name          string   `json:"name"            validate:"required,max=100"`
+	Scopes        []string `json:"scopes"          validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+}
+
+// CreatedPersonalToken — ответ на создание: Token виден один раз
+type CreatedPersonalToken struct {
+	PersonalToken
+	Token string `json:"token"`
+}
+
+// ---------------------- ЭКСПОРТ ПЕРСОНАЛЬНЫХ ДАННЫХ ----------------------
+
+type ExportComment struct {
+	ID            int       `json:"id"`
+	DocumentID    int       `json:"document_id"`
+	DocumentTitle string    `json:"document_title"`
+	Text          string    `json:"text"`
+	CreatedAt     time.Time `json:"created_at"`
+	IsDeleted     bool      `json:"is_deleted"`
+}
+
+type ExportLike struct {
+	DocumentID    int    `json:"document_id"`
+	DocumentTitle string `json:"document_title"`
+}
+
+type ExportView struct {
+	DocumentID    int        `json:"document_id"`
+	DocumentTitle string     `json:"document_title"`
+	ViewedAt      *time.Time `json:"viewed_at"`
+}
+
+// ExportDocument — метаданные загруженного пользователем документа (без самого файла)
+type ExportDocument struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	CategoryID *int       `json:"category_id"`
+	RoleID     *int       `json:"role_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// AnonymizeResult — итог обезличивания пользователя
+type AnonymizeResult struct {
+	UserID       int `json:"user_id"`
+	DeletedFiles int `json:"deleted_files"`
+}
+
+// ---------------------- ОЧЕРЕДЬ ПИСЕМ ----------------------
+
+// Статусы письма в очереди
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead" // попытки исчерпаны, нужна ручная повторная отправка
+)
+
+// OutboxEmail — письмо в очереди исходящих. Тела писем содержат одноразовые ссылки,
+// поэтому в ответы API не попадают.
+type OutboxEmail struct {
+	ID            int64      `json:"id"`
+	UserID        *int       `json:"user_id,omitempty"`
+	Recipient     string     `json:"recipient"`
+	Template      string     `json:"template"`
+	Subject       string     `json:"subject"`
+	Text          string     `json:"-"`
+	HTML          string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// OutboxPage — страница очереди писем для администратора
+type OutboxPage struct {
+	Items []OutboxEmail `json:"items"`
+	Total int           `json:"total"`
+}
@@ -3,9 +3,25 @@ package repo
 import (
 	"cmd/internal/user/models"
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrUserNotFound — пользователя с таким id или email нет
+	ErrUserNotFound = errors.New("пользователь не найден")
+	// ErrInvalidCursor — курсор пагинации повреждён или выдан для другой сортировки
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrEmailChangeNotFound — запрос смены email не найден или истёк
+	ErrEmailChangeNotFound = errors.New("email change request not found")
+	// ErrEmailTaken — адрес уже занят другим пользователем
+	ErrEmailTaken = errors.New("email is taken")
+	// ErrOutboxEmailNotFound — письма с таким id нет в очереди
+	ErrOutboxEmailNotFound = errors.New("outbox email not found")
+	// ErrOutboxEmailSent — письмо уже доставлено, повторная отправка не нужна
+	ErrOutboxEmailSent = errors.New("outbox email already sent")
+)
+
 // ---------------------- USERS STORAGE ----------------------
 type Storage interface {
 	// Технические методы управления соединениями
@@ -14,31 +30,88 @@ type Storage interface {
 	// Users
 	UserExists(ctx context.Context, email string) (bool, error)
 	CreateUser(ctx context.Context, u *models.User) error
-	DeleteUser(ctx context.Context, userID int) error
+	// CreateUserWithVerification создаёт пользователя с токеном верификации и ставит письмо в очередь
+	// в одной транзакции; письмо собирается после вставки, так как ссылка содержит id пользователя
+	CreateUserWithVerification(ctx context.Context, u *models.User, token string, expiresAt time.Time, email func(userID int) (models.OutboxEmail, error)) error
+	DeactivateUser(ctx context.Context, userID int, deactivatedBy *int, reassignTo int) error
+	DeleteUser(ctx context.Context, userID int, reassignTo int) error
+	AnonymizeUser(ctx context.Context, userID int, placeholderEmail string) error
+
+	// Экспорт персональных данных
+	ListUserComments(ctx context.Context, userID int) ([]models.ExportComment, error)
+	ListUserLikes(ctx context.Context, userID int) ([]models.ExportLike, error)
+	ListUserViews(ctx context.Context, userID int) ([]models.ExportView, error)
+	ListUserDocuments(ctx context.Context, userID int) ([]models.ExportDocument, error)
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetAllUsers(ctx context.Context) ([]*models.User, error)
+	SearchUsers(ctx context.Context, filter models.UserFilter) (*models.UserPage, error)
 	UpdatePassword(ctx context.Context, userID int, hashedPassword string) error
 	UpdatePasswordAndVerify(ctx context.Context, userID int, hashedPassword string) error
 	MarkUserAsVerified(ctx context.Context, userID int) error
 	UpdateUserPhoto(ctx context.Context, userID int, photoPath string) error
 	UpdateUserProfile(ctx context.Context, userID int, updateReq UpdateProfileRequest) error
+	UpdateUserRole(ctx context.Context, userID int, roleID int) error
+	UpdateUserEmail(ctx context.Context, userID int, email string) error
+	ReactivateUser(ctx context.Context, userID int) error
+	// UpdateInvitedUser применяет данные повторного приглашения к ещё не подтверждённому активному пользователю
+	UpdateInvitedUser(ctx context.Context, u *models.User) error
+
+	// Смена email с подтверждением нового адреса
+	SaveEmailChangeRequest(ctx context.Context, req EmailChangeRequest, emails ...models.OutboxEmail) error
+	ConfirmEmailChange(ctx context.Context, tokenHash string) (EmailChange, error)
+
+	// Версия токенов (мгновенный отзыв access токенов)
+	GetTokenVersion(ctx context.Context, userID int) (version int, active bool, err error)
+	IncrementTokenVersion(ctx context.Context, userID int) (int, error)
 
 	// Roles
 	GetRoleIDByName(ctx context.Context, roleName string) (int, error)
+	RoleExists(ctx context.Context, roleID int) (bool, error)
 
 	// Verification tokens
-	SaveVerificationToken(ctx context.Context, userID int, token string, expiresAt time.Time) error
+	SaveVerificationToken(ctx context.Context, userID int, token string, expiresAt time.Time, emails ...models.OutboxEmail) error
 	ValidateVerificationToken(ctx context.Context, userID int, token string) (bool, error)
 	GetVerificationToken(ctx context.Context, userID int) (string, time.Time, error)
 	DeleteVerificationToken(ctx context.Context, userID int) error
 	DeleteExpiredVerificationTokens(ctx context.Context) error
 
-	// Refresh tokens
+	// Refresh tokens (в базе хранится только SHA-256 хэш токена)
 	SaveRefreshToken(ctx context.Context, rt RefreshToken) (int64, error)
-	GetRefreshToken(ctx context.Context, token string) (RefreshToken, error)
-	DeleteRefreshToken(ctx context.Context, token string) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int64) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	DeleteRefreshToken(ctx context.Context, tokenHash string) error
 	DeleteAllUserRefreshTokens(ctx context.Context, userID int) error
+
+	// Персональные токены доступа (в базе хранится только SHA-256 хэш)
+	CreatePersonalToken(ctx context.Context, t *models.PersonalToken) error
+	ListPersonalTokens(ctx context.Context, userID int) ([]*models.PersonalToken, error)
+	GetPersonalTokenByHash(ctx context.Context, tokenHash string) (*models.PersonalToken, *models.User, error)
+	RevokePersonalToken(ctx context.Context, userID, tokenID int) (bool, error)
+	RevokeAllPersonalTokens(ctx context.Context, userID int) (int64, error)
+	TouchPersonalToken(ctx context.Context, tokenID int) error
+
+	// SSO: внешние учётные записи и незавершённые входы через OIDC
+	FindIdentity(ctx context.Context, provider, subject string) (userID int, found bool, err error)
+	LinkIdentity(ctx context.Context, identity UserIdentity) error
+	ListIdentities(ctx context.Context, provider string) ([]UserIdentity, error)
+	SaveOIDCLoginState(ctx context.Context, st OIDCLoginState) error
+	ConsumeOIDCLoginState(ctx context.Context, state string) (OIDCLoginState, error)
+	DeleteExpiredOIDCLoginStates(ctx context.Context) error
+
+	// Отчёты синхронизации с каталогом (LDAP/AD)
+	SaveDirectorySyncRun(ctx context.Context, run DirectorySyncRun) (int64, error)
+	GetLastDirectorySyncRun(ctx context.Context) (DirectorySyncRun, error)
+
+	// Очередь исходящих писем (письма, связанные с изменением, передаются в сам метод изменения)
+	EnqueueEmails(ctx context.Context, emails ...models.OutboxEmail) error
+	ClaimOutboxEmails(ctx context.Context, limit, perMinute int, lease time.Duration) ([]models.OutboxEmail, error)
+	MarkOutboxEmailSent(ctx context.Context, id int64) error
+	MarkOutboxEmailFailed(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error
+	ListOutboxEmails(ctx context.Context, status string, limit, offset int) (*models.OutboxPage, error)
+	ResendOutboxEmail(ctx context.Context, id int64) error
+	DeleteSentOutboxEmails(ctx context.Context, before time.Time) (int64, error)
 }
 
 // ---------------------- REFRESH TOKEN STORAGE ----------------------
@@ -46,13 +119,83 @@ type Storage interface {
 type RefreshToken struct {
 	ID        int64
 	UserID    int
-	Token     string
+	TokenHash string
+	FamilyID  string
 	ExpiresAt time.Time
 	CreatedAt time.Time
+	UsedAt    *time.Time // токен уже обменян на новый (ротация)
+	RevokedAt *time.Time // семейство отозвано (logout или подозрение на кражу)
+}
+
+// ---------------------- SSO IDENTITIES ----------------------
+
+// UserIdentity — учётная запись пользователя у внешнего провайдера (provider + subject уникальны)
+type UserIdentity struct {
+	UserID   int
+	Provider string
+	Subject  string
+	Email    string
+}
+
+// OIDCLoginState — данные начатого входа через OIDC, одноразовые
+type OIDCLoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	RedirectTo   string
+	ExpiresAt    time.Time
+}
+
+// DirectorySyncRun — сохранённый отчёт синхронизации; сам отчёт хранится JSON
+type DirectorySyncRun struct {
+	ID         int64
+	Source     string
+	DryRun     bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Report     []byte
+}
+
+// ---------------------- EMAIL CHANGE ----------------------
+
+// EmailChangeRequest — ожидающая подтверждения смена email (один запрос на пользователя)
+type EmailChangeRequest struct {
+	UserID    int
+	NewEmail  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// EmailChange — применённая смена email
+type EmailChange struct {
+	UserID   int
+	OldEmail string
+	NewEmail string
+	Language string
 }
 
 type UpdateProfileRequest struct {
 	FirstName *string `json:"first_name,omitempty"`
 	LastName  *string `json:"last_name,omitempty"`
 	PhotoPath *string `json:"photo_path,omitempty"`
+	Language  *string `json:"language,omitempty"`
+}
+
+// ---------------------- JWT SIGNING KEYS STORAGE ----------------------
+
+type SigningKeyStorage interface {
+	ListSigningKeys(ctx context.Context) ([]SigningKey, error)
+	RotateSigningKey(ctx context.Context, key SigningKey, notBefore, retiredAt, expiresAt time.Time) (bool, error)
+	DeleteExpiredSigningKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+// SigningKey — ключ подписи JWT в сериализованном виде (PEM или зашифрованный PKCS#8)
+type SigningKey struct {
+	KID        string
+	Algorithm  string
+	PrivateKey string
+	PublicKey  string
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+	ExpiresAt  *time.Time
 }
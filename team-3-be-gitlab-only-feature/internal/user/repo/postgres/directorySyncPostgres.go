@@ -0,0 +1,38 @@
+package repo
+
+import (
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"fmt"
+)
+
+func (s *PostgresStorage) SaveDirectorySyncRun(ctx context.Context, run repoUser.DirectorySyncRun) (int64, error) {
+	query := `
+        INSERT INTO directory_sync_runs (source, dry_run, started_at, finished_at, report)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+
+	var id int64
+	err := s.storage.DB.QueryRowContext(ctx, query, run.Source, run.DryRun, run.StartedAt, run.FinishedAt, run.Report).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения отчёта синхронизации: %w", err)
+	}
+	return id, nil
+}
+
+func (s *PostgresStorage) GetLastDirectorySyncRun(ctx context.Context) (repoUser.DirectorySyncRun, error) {
+	query := `
+        SELECT id, source, dry_run, started_at, finished_at, report
+        FROM directory_sync_runs
+        ORDER BY started_at DESC
+        LIMIT 1
+    `
+
+	var run repoUser.DirectorySyncRun
+	err := s.storage.DB.QueryRowContext(ctx, query).Scan(&run.ID, &run.Source, &run.DryRun, &run.StartedAt, &run.FinishedAt, &run.Report)
+	if err != nil {
+		return repoUser.DirectorySyncRun{}, fmt.Errorf("ошибка получения отчёта синхронизации: %w", err)
+	}
+	return run, nil
+}
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SaveEmailChangeRequest сохраняет запрос смены email; предыдущий неподтверждённый запрос пользователя заменяется.
+// Письма (подтверждение и уведомление) ставятся в очередь той же транзакцией.
+func (s *PostgresStorage) SaveEmailChangeRequest(ctx context.Context, req repoUser.EmailChangeRequest, emails ...models.OutboxEmail) error {
+	query := `
+        INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id)
+        DO UPDATE SET new_email = EXCLUDED.new_email,
+                      token_hash = EXCLUDED.token_hash,
+                      expires_at = EXCLUDED.expires_at,
+                      created_at = EXCLUDED.created_at
+    `
+
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, query, req.UserID, req.NewEmail, req.TokenHash, req.ExpiresAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения запроса смены email: %w", err)
+	}
+	if err := enqueueEmails(ctx, tx, emails); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange применяет смену email по токену в одной транзакции: запрос удаляется,
+// адрес проверяется на занятость без учёта регистра, строка пользователя блокируется на время обновления
+func (s *PostgresStorage) ConfirmEmailChange(ctx context.Context, tokenHash string) (repoUser.EmailChange, error) {
+	var change repoUser.EmailChange
+
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return change, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var expiresAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM email_change_requests WHERE token_hash = $1 RETURNING user_id, new_email, expires_at`,
+		tokenHash,
+	).Scan(&change.UserID, &change.NewEmail, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return change, repoUser.ErrEmailChangeNotFound
+		}
+		return change, fmt.Errorf("ошибка получения запроса смены email: %w", err)
+	}
+	if time.Now().After(expiresAt) {
+		// Удаление просроченного запроса фиксируем, ссылка больше не нужна
+		if err := tx.Commit(); err != nil {
+			return change, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+		}
+		return change, repoUser.ErrEmailChangeNotFound
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT email, language FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		change.UserID,
+	).Scan(&change.OldEmail, &change.Language)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return change, repoUser.ErrEmailChangeNotFound
+		}
+		return change, fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(TRIM(email)) = LOWER(TRIM($1)) AND id <> $2)`,
+		change.NewEmail, change.UserID,
+	).Scan(&taken)
+	if err != nil {
+		return change, fmt.Errorf("ошибка проверки email: %w", err)
+	}
+	if taken {
+		return change, repoUser.ErrEmailTaken
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET email = $1, email_confirmed = TRUE, updated_at = $2 WHERE id = $3`,
+		change.NewEmail, time.Now(), change.UserID,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return change, repoUser.ErrEmailTaken
+		}
+		return change, fmt.Errorf("ошибка обновления email: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return change, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return change, nil
+}
@@ -0,0 +1,254 @@
+package repo
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// execer — общее у *sql.DB и *sql.Tx: письма ставятся в очередь той же транзакцией, что и изменение
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const outboxColumns = `id, user_id, recipient, template, subject, text_body, html_body,
+        status, attempts, next_attempt_at, last_error, created_at, sent_at`
+
+func enqueueEmails(ctx context.Context, q execer, emails []models.OutboxEmail) error {
+	now := time.Now()
+	for _, e := range emails {
+		_, err := q.ExecContext(ctx, `
+            INSERT INTO email_outbox (user_id, recipient, template, subject, text_body, html_body, status, next_attempt_at, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $7)`,
+			e.UserID, e.Recipient, e.Template, e.Subject, e.Text, e.HTML, now,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка постановки письма в очередь: %w", err)
+		}
+	}
+	return nil
+}
+
+// rowScanner — *sql.Row или *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOutboxEmail(row rowScanner) (models.OutboxEmail, error) {
+	var e models.OutboxEmail
+	var userID sql.NullInt64
+	var sentAt sql.NullTime
+	err := row.Scan(&e.ID, &userID, &e.Recipient, &e.Template, &e.Subject, &e.Text, &e.HTML,
+		&e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.CreatedAt, &sentAt)
+	if err != nil {
+		return e, err
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		e.UserID = &id
+	}
+	if sentAt.Valid {
+		e.SentAt = &sentAt.Time
+	}
+	return e, nil
+}
+
+// EnqueueEmails ставит письма в очередь без связанного изменения (уведомления)
+func (s *PostgresStorage) EnqueueEmails(ctx context.Context, emails ...models.OutboxEmail) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := enqueueEmails(ctx, tx, emails); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutboxEmails забирает готовые к отправке письма. Попытка засчитывается сразу, а следующая
+// назначается через lease: если отправитель упадёт, письмо вернётся в работу само.
+// SKIP LOCKED позволяет запускать несколько экземпляров сервиса; perMinute (0 — без ограничения)
+// считается по попыткам всех экземпляров за последнюю минуту, подсчёт и резервирование идут под
+// advisory lock, чтобы два экземпляра не заняли один и тот же остаток лимита.
+func (s *PostgresStorage) ClaimOutboxEmails(ctx context.Context, limit, perMinute int, lease time.Duration) ([]models.OutboxEmail, error) {
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if perMinute > 0 {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('email_outbox_claim'))`); err != nil {
+			return nil, fmt.Errorf("ошибка блокировки очереди писем: %w", err)
+		}
+		var recent int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM email_outbox WHERE claimed_at > $1`, now.Add(-time.Minute),
+		).Scan(&recent); err != nil {
+			return nil, fmt.Errorf("ошибка подсчёта отправленных писем: %w", err)
+		}
+		if left := perMinute - recent; left < limit {
+			limit = left
+		}
+		if limit <= 0 {
+			return nil, nil
+		}
+	}
+
+	query := `
+        UPDATE email_outbox
+        SET attempts = attempts + 1,
+            next_attempt_at = $2,
+            claimed_at = $3
+        WHERE id IN (
+            SELECT id FROM email_outbox
+            WHERE status = 'pending' AND next_attempt_at <= $3
+            ORDER BY next_attempt_at, id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + outboxColumns
+
+	rows, err := tx.QueryContext(ctx, query, limit, now.Add(lease), now)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки писем из очереди: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []models.OutboxEmail
+	for rows.Next() {
+		e, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения письма из очереди: %w", err)
+		}
+		emails = append(emails, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения письма из очереди: %w", err)
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return emails, nil
+}
+
+// MarkOutboxEmailSent отмечает письмо доставленным
+func (s *PostgresStorage) MarkOutboxEmailSent(ctx context.Context, id int64) error {
+	_, err := s.storage.DB.ExecContext(ctx,
+		`UPDATE email_outbox SET status = 'sent', sent_at = $1, last_error = '' WHERE id = $2`,
+		time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления письма в очереди: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboxEmailFailed сохраняет ошибку отправки; nextAttemptAt == nil — попытки исчерпаны (dead)
+func (s *PostgresStorage) MarkOutboxEmailFailed(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error {
+	var err error
+	if nextAttemptAt == nil {
+		_, err = s.storage.DB.ExecContext(ctx,
+			`UPDATE email_outbox SET status = 'dead', last_error = $1 WHERE id = $2`,
+			lastError, id,
+		)
+	} else {
+		_, err = s.storage.DB.ExecContext(ctx,
+			`UPDATE email_outbox SET last_error = $1, next_attempt_at = $2 WHERE id = $3`,
+			lastError, *nextAttemptAt, id,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления письма в очереди: %w", err)
+	}
+	return nil
+}
+
+// ListOutboxEmails возвращает письма очереди, новые сверху; пустой status — все
+func (s *PostgresStorage) ListOutboxEmails(ctx context.Context, status string, limit, offset int) (*models.OutboxPage, error) {
+	page := &models.OutboxPage{Items: []models.OutboxEmail{}}
+
+	err := s.storage.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM email_outbox WHERE ($1 = '' OR status = $1)`, status,
+	).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчёта писем в очереди: %w", err)
+	}
+
+	rows, err := s.storage.DB.QueryContext(ctx, `
+        SELECT `+outboxColumns+`
+        FROM email_outbox
+        WHERE ($1 = '' OR status = $1)
+        ORDER BY id DESC
+        LIMIT $2 OFFSET $3`,
+		status, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения писем из очереди: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения письма из очереди: %w", err)
+		}
+		page.Items = append(page.Items, e)
+	}
+	return page, rows.Err()
+}
+
+// ResendOutboxEmail возвращает недоставленное письмо в очередь с обнулённым счётчиком попыток
+func (s *PostgresStorage) ResendOutboxEmail(ctx context.Context, id int64) error {
+	var status string
+	err := s.storage.DB.QueryRowContext(ctx,
+		`SELECT status FROM email_outbox WHERE id = $1`, id,
+	).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repoUser.ErrOutboxEmailNotFound
+		}
+		return fmt.Errorf("ошибка поиска письма в очереди: %w", err)
+	}
+	if status == models.OutboxSent {
+		return repoUser.ErrOutboxEmailSent
+	}
+
+	_, err = s.storage.DB.ExecContext(ctx, `
+        UPDATE email_outbox
+        SET status = 'pending', attempts = 0, next_attempt_at = $1
+        WHERE id = $2 AND status <> 'sent'`,
+		time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка повторной постановки письма в очередь: %w", err)
+	}
+	return nil
+}
+
+// DeleteSentOutboxEmails удаляет доставленные письма старше before
+func (s *PostgresStorage) DeleteSentOutboxEmails(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.storage.DB.ExecContext(ctx,
+		`DELETE FROM email_outbox WHERE status = 'sent' AND sent_at < $1`, before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки очереди писем: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,186 @@
+package repo
+
+import (
+	"cmd/internal/user/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ListUserComments возвращает все комментарии пользователя, включая удалённые
+func (s *PostgresStorage) ListUserComments(ctx context.Context, userID int) ([]models.ExportComment, error) {
+	query := `
+        SELECT c.id, c.document_id, COALESCE(d.title, ''), c.text, c.created_at, COALESCE(c.is_deleted, false)
+        FROM comments c
+        LEFT JOIN documents d ON d.id = c.document_id
+        WHERE c.user_id = $1
+        ORDER BY c.created_at
+    `
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения комментариев пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []models.ExportComment{}
+	for rows.Next() {
+		var c models.ExportComment
+		if err := rows.Scan(&c.ID, &c.DocumentID, &c.DocumentTitle, &c.Text, &c.CreatedAt, &c.IsDeleted); err != nil {
+			return nil, fmt.Errorf("ошибка чтения комментария: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// ListUserLikes возвращает документы, отмеченные пользователем
+func (s *PostgresStorage) ListUserLikes(ctx context.Context, userID int) ([]models.ExportLike, error) {
+	query := `
+        SELECT l.document_id, COALESCE(d.title, '')
+        FROM document_likes l
+        LEFT JOIN documents d ON d.id = l.document_id
+        WHERE l.user_id = $1
+        ORDER BY l.document_id
+    `
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения лайков пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	likes := []models.ExportLike{}
+	for rows.Next() {
+		var l models.ExportLike
+		if err := rows.Scan(&l.DocumentID, &l.DocumentTitle); err != nil {
+			return nil, fmt.Errorf("ошибка чтения лайка: %w", err)
+		}
+		likes = append(likes, l)
+	}
+	return likes, rows.Err()
+}
+
+// ListUserViews возвращает отметки об ознакомлении пользователя
+func (s *PostgresStorage) ListUserViews(ctx context.Context, userID int) ([]models.ExportView, error) {
+	query := `
+        SELECT v.document_id, COALESCE(d.title, ''), v.viewed_at
+        FROM document_views v
+        LEFT JOIN documents d ON d.id = v.document_id
+        WHERE v.user_id = $1
+        ORDER BY v.viewed_at
+    `
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения просмотров пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	views := []models.ExportView{}
+	for rows.Next() {
+		var v models.ExportView
+		if err := rows.Scan(&v.DocumentID, &v.DocumentTitle, &v.ViewedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения просмотра: %w", err)
+		}
+		views = append(views, v)
+	}
+	return views, rows.Err()
+}
+
+// ListUserDocuments возвращает метаданные документов, загруженных пользователем
+func (s *PostgresStorage) ListUserDocuments(ctx context.Context, userID int) ([]models.ExportDocument, error) {
+	query := `
+        SELECT id, title, category_id, role_id, created_at, deleted_at
+        FROM documents
+        WHERE user_id = $1
+        ORDER BY created_at
+    `
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения документов пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	docs := []models.ExportDocument{}
+	for rows.Next() {
+		var d models.ExportDocument
+		if err := rows.Scan(&d.ID, &d.Title, &d.CategoryID, &d.RoleID, &d.CreatedAt, &d.DeletedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения документа: %w", err)
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// AnonymizeUser стирает имя, email, фото и пароль пользователя, его письма в очереди и деактивирует его.
+// Комментарии, лайки и просмотры остаются — на них строится статистика документов
+func (s *PostgresStorage) AnonymizeUser(ctx context.Context, userID int, placeholderEmail string) error {
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Старый адрес нужен, чтобы найти письма, отправленные на него до обезличивания
+	var oldEmail string
+	err = tx.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&oldEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("пользователь не найден")
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	now := time.Now()
+	query := `
+        UPDATE users
+        SET first_name = 'Удалённый',
+            last_name = 'пользователь',
+            email = $1,
+            photo_path = NULL,
+            password_hash = NULL,
+            department = NULL,
+            email_verification_token = NULL,
+            deleted_at = COALESCE(deleted_at, $2),
+            anonymized_at = COALESCE(anonymized_at, $2),
+            updated_at = $2
+        WHERE id = $3
+    `
+	result, err := tx.ExecContext(ctx, query, placeholderEmail, now, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка обезличивания пользователя: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New("пользователь не найден")
+	}
+
+	// Внешние учётные записи хранят email и идентификатор в каталоге
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_identities WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("ошибка удаления внешних учётных записей: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM personal_access_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("ошибка удаления персональных токенов: %w", err)
+	}
+	// Запрос смены email хранит новый адрес и удаляется только вместе со строкой users
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_change_requests WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("ошибка удаления запроса смены email: %w", err)
+	}
+	// Письма хранят адрес, имя и ссылки в теле; отправленные живут KeepSent, недоставленные — без срока
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM email_outbox WHERE user_id = $1 OR LOWER(recipient) = LOWER($2)`, userID, oldEmail); err != nil {
+		return fmt.Errorf("ошибка удаления писем пользователя: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
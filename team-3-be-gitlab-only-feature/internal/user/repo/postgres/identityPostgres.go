@@ -0,0 +1,94 @@
+package repo
+
+import (
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FindIdentity ищет пользователя, привязанного к subject внешнего провайдера
+func (s *PostgresStorage) FindIdentity(ctx context.Context, provider, subject string) (int, bool, error) {
+	query := `SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	var userID int
+	err := s.storage.DB.QueryRowContext(ctx, query, provider, subject).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("ошибка поиска внешней учётной записи: %w", err)
+	}
+
+	return userID, true, nil
+}
+
+// LinkIdentity привязывает внешнюю учётную запись к пользователю; при повторном входе обновляет email и время входа
+func (s *PostgresStorage) LinkIdentity(ctx context.Context, identity repoUser.UserIdentity) error {
+	query := `
+        INSERT INTO user_identities (user_id, provider, subject, email, created_at, last_login_at)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (provider, subject)
+        DO UPDATE SET email = EXCLUDED.email, last_login_at = EXCLUDED.last_login_at
+    `
+
+	_, err := s.storage.DB.ExecContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка привязки внешней учётной записи: %w", err)
+	}
+	return nil
+}
+
+// ListIdentities возвращает все учётные записи провайдера (для поиска пропавших из каталога)
+func (s *PostgresStorage) ListIdentities(ctx context.Context, provider string) ([]repoUser.UserIdentity, error) {
+	query := `SELECT user_id, provider, subject, COALESCE(email, '') FROM user_identities WHERE provider = $1`
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, provider)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения внешних учётных записей: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []repoUser.UserIdentity
+	for rows.Next() {
+		var identity repoUser.UserIdentity
+		if err := rows.Scan(&identity.UserID, &identity.Provider, &identity.Subject, &identity.Email); err != nil {
+			return nil, fmt.Errorf("ошибка чтения внешней учётной записи: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+func (s *PostgresStorage) SaveOIDCLoginState(ctx context.Context, st repoUser.OIDCLoginState) error {
+	query := `INSERT INTO oidc_login_states (state, nonce, code_verifier, redirect_to, expires_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.storage.DB.ExecContext(ctx, query, st.State, st.Nonce, st.CodeVerifier, st.RedirectTo, st.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния OIDC входа: %w", err)
+	}
+	return nil
+}
+
+// ConsumeOIDCLoginState возвращает и сразу удаляет состояние входа — повторный callback с тем же state не пройдёт
+func (s *PostgresStorage) ConsumeOIDCLoginState(ctx context.Context, state string) (repoUser.OIDCLoginState, error) {
+	query := `DELETE FROM oidc_login_states WHERE state = $1 RETURNING state, nonce, code_verifier, redirect_to, expires_at`
+
+	var st repoUser.OIDCLoginState
+	err := s.storage.DB.QueryRowContext(ctx, query, state).Scan(&st.State, &st.Nonce, &st.CodeVerifier, &st.RedirectTo, &st.ExpiresAt)
+	if err != nil {
+		return repoUser.OIDCLoginState{}, fmt.Errorf("ошибка получения состояния OIDC входа: %w", err)
+	}
+	return st, nil
+}
+
+func (s *PostgresStorage) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	query := `DELETE FROM oidc_login_states WHERE expires_at < $1`
+	_, err := s.storage.DB.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка удаления устаревших состояний OIDC входа: %w", err)
+	}
+	return nil
+}
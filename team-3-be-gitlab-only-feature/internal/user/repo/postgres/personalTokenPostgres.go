@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"cmd/internal/user/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (s *PostgresStorage) CreatePersonalToken(ctx context.Context, t *models.PersonalToken) error {
+	query := `
+        INSERT INTO personal_access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	err := s.storage.DB.QueryRowContext(ctx, query,
+		t.UserID, t.Name, t.TokenHash, t.Prefix, strings.Join(t.Scopes, ","), t.ExpiresAt, t.CreatedAt,
+	).Scan(&t.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения персонального токена: %w", err)
+	}
+	return nil
+}
+
+// ListPersonalTokens возвращает неотозванные токены пользователя, от новых к старым
+func (s *PostgresStorage) ListPersonalTokens(ctx context.Context, userID int) ([]*models.PersonalToken, error) {
+	query := `
+        SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+        FROM personal_access_tokens
+        WHERE user_id = $1 AND revoked_at IS NULL
+        ORDER BY created_at DESC
+    `
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения персональных токенов: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.PersonalToken{}
+	for rows.Next() {
+		t := &models.PersonalToken{}
+		var scopes string
+		var expiresAt, lastUsedAt sql.NullTime
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &scopes, &expiresAt, &lastUsedAt, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения персонального токена: %w", err)
+		}
+		t.Scopes = splitScopes(scopes)
+		if expiresAt.Valid {
+			t.ExpiresAt = &expiresAt.Time
+		}
+		if lastUsedAt.Valid {
+			t.LastUsedAt = &lastUsedAt.Time
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// GetPersonalTokenByHash возвращает токен вместе с владельцем (id, email, роль) одним запросом
+func (s *PostgresStorage) GetPersonalTokenByHash(ctx context.Context, tokenHash string) (*models.PersonalToken, *models.User, error) {
+	query := `
+        SELECT t.id, t.user_id, t.name, t.token_prefix, t.scopes, t.expires_at, t.last_used_at, t.created_at, t.revoked_at,
+               u.email, COALESCE(u.role_id, 0), u.token_version
+        FROM personal_access_tokens t
+        JOIN users u ON u.id = t.user_id
+        WHERE t.token_hash = $1
+    `
+
+	t := &models.PersonalToken{}
+	u := &models.User{}
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := s.storage.DB.QueryRowContext(ctx, query, tokenHash).Scan(
+		&t.ID, &t.UserID, &t.Name, &t.Prefix, &scopes, &expiresAt, &lastUsedAt, &t.CreatedAt, &revokedAt,
+		&u.Email, &u.RoleID, &u.TokenVersion,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка поиска персонального токена: %w", err)
+	}
+	t.Scopes = splitScopes(scopes)
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	u.ID = t.UserID
+	return t, u, nil
+}
+
+// RevokePersonalToken отзывает токен; false — токена нет или он чужой
+func (s *PostgresStorage) RevokePersonalToken(ctx context.Context, userID, tokenID int) (bool, error) {
+	query := `UPDATE personal_access_tokens SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+
+	result, err := s.storage.DB.ExecContext(ctx, query, time.Now(), tokenID, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка отзыва персонального токена: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// RevokeAllPersonalTokens отзывает все действующие персональные токены пользователя
+func (s *PostgresStorage) RevokeAllPersonalTokens(ctx context.Context, userID int) (int64, error) {
+	query := `UPDATE personal_access_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	result, err := s.storage.DB.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка отзыва персональных токенов: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+	return rows, nil
+}
+
+// TouchPersonalToken обновляет время последнего использования не чаще раза в минуту,
+// чтобы частые запросы скриптов не превращались в запись на каждый вызов
+func (s *PostgresStorage) TouchPersonalToken(ctx context.Context, tokenID int) error {
+	query := `
+        UPDATE personal_access_tokens SET last_used_at = $1
+        WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)
+    `
+
+	now := time.Now()
+	_, err := s.storage.DB.ExecContext(ctx, query, now, tokenID, now.Add(-time.Minute))
+	if err != nil {
+		return fmt.Errorf("ошибка обновления времени использования токена: %w", err)
+	}
+	return nil
+}
+
+func splitScopes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
@@ -3,41 +3,81 @@ package repo
 import (
 	repoUser "cmd/internal/user/repo"
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 )
 
 type RefresgToken interface {
 	SaveRefreshToken(ctx context.Context, rt repoUser.RefreshToken) (int64, error)
-	GetRefreshToken(ctx context.Context, token string) (repoUser.RefreshToken, error)
-	DeleteRefreshToken(ctx context.Context, token string) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (repoUser.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int64) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	DeleteRefreshToken(ctx context.Context, tokenHash string) error
 	DeleteAllUserRefreshTokens(ctx context.Context, userID int) error
 }
 
 func (s *PostgresStorage) SaveRefreshToken(ctx context.Context, rt repoUser.RefreshToken) (int64, error) {
-	query := `INSERT INTO refresh_tokens (user_id, token, expires_at, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	query := `INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	createdAt := rt.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
 	var id int64
-	err := s.storage.DB.QueryRowContext(ctx, query, rt.UserID, rt.Token, rt.ExpiresAt, rt.CreatedAt).Scan(&id)
+	err := s.storage.DB.QueryRowContext(ctx, query, rt.UserID, rt.TokenHash, rt.FamilyID, rt.ExpiresAt, createdAt).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("ошибка сохранения refresh токена: %w", err)
 	}
 	return id, nil
 }
 
-func (s *PostgresStorage) GetRefreshToken(ctx context.Context, token string) (repoUser.RefreshToken, error) {
-	query := `SELECT id, user_id, token, expires_at, created_at FROM refresh_tokens WHERE token = $1`
+func (s *PostgresStorage) GetRefreshToken(ctx context.Context, tokenHash string) (repoUser.RefreshToken, error) {
+	query := `SELECT id, user_id, token_hash, family_id, expires_at, created_at, used_at, revoked_at FROM refresh_tokens WHERE token_hash = $1`
 	var rt repoUser.RefreshToken
-	err := s.storage.DB.QueryRowContext(ctx, query, token).Scan(
-		&rt.ID, &rt.UserID, &rt.Token, &rt.ExpiresAt, &rt.CreatedAt,
+	var usedAt, revokedAt sql.NullTime
+	err := s.storage.DB.QueryRowContext(ctx, query, tokenHash).Scan(
+		&rt.ID, &rt.UserID, &rt.TokenHash, &rt.FamilyID, &rt.ExpiresAt, &rt.CreatedAt, &usedAt, &revokedAt,
 	)
 	if err != nil {
 		return repoUser.RefreshToken{}, fmt.Errorf("ошибка возвращения refresh токена: %w", err)
 	}
+	if usedAt.Valid {
+		rt.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		rt.RevokedAt = &revokedAt.Time
+	}
 	return rt, nil
 }
 
-func (s *PostgresStorage) DeleteRefreshToken(ctx context.Context, token string) error {
-	query := `DELETE FROM refresh_tokens WHERE token = $1`
-	_, err := s.storage.DB.ExecContext(ctx, query, token)
+// MarkRefreshTokenUsed атомарно помечает токен как обменянный.
+// Возвращает false, если токен уже был использован или отозван (например, параллельным запросом).
+func (s *PostgresStorage) MarkRefreshTokenUsed(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE refresh_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL AND revoked_at IS NULL`
+	result, err := s.storage.DB.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return false, fmt.Errorf("ошибка ротации refresh токена: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// RevokeRefreshTokenFamily отзывает все токены семейства ротации
+func (s *PostgresStorage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	_, err := s.storage.DB.ExecContext(ctx, query, time.Now(), familyID)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва семейства refresh токенов: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) DeleteRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `DELETE FROM refresh_tokens WHERE token_hash = $1`
+	_, err := s.storage.DB.ExecContext(ctx, query, tokenHash)
 	if err != nil {
 		return fmt.Errorf("ошибка удаления refresh токена: %w", err)
 	}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ListSigningKeys возвращает все ключи подписи, которые ещё не истекли, от новых к старым
+func (s *PostgresStorage) ListSigningKeys(ctx context.Context) ([]repoUser.SigningKey, error) {
+	query := `
+        SELECT kid, algorithm, private_key, public_key, created_at, retired_at, expires_at
+        FROM jwt_signing_keys
+        WHERE expires_at IS NULL OR expires_at > $1
+        ORDER BY created_at DESC
+    `
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ключей подписи: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []repoUser.SigningKey
+	for rows.Next() {
+		var k repoUser.SigningKey
+		var retiredAt, expiresAt sql.NullTime
+		if err := rows.Scan(&k.KID, &k.Algorithm, &k.PrivateKey, &k.PublicKey, &k.CreatedAt, &retiredAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения ключа подписи: %w", err)
+		}
+		if retiredAt.Valid {
+			k.RetiredAt = &retiredAt.Time
+		}
+		if expiresAt.Valid {
+			k.ExpiresAt = &expiresAt.Time
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка строки: %w", err)
+	}
+	return keys, nil
+}
+
+// RotateSigningKey сохраняет новый ключ и выводит все остальные активные. Проверка и запись идут
+// под транзакционной advisory-блокировкой, поэтому инстансы, стартующие одновременно, не выпустят
+// по ключу каждый. Если активный ключ создан после notBefore, ротацию уже выполнил другой инстанс —
+// ключ не сохраняется и возвращается false.
+func (s *PostgresStorage) RotateSigningKey(ctx context.Context, key repoUser.SigningKey, notBefore, retiredAt, expiresAt time.Time) (bool, error) {
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('jwt_signing_keys'))`); err != nil {
+		return false, fmt.Errorf("ошибка блокировки ключей подписи: %w", err)
+	}
+
+	var fresh bool
+	err = tx.QueryRowContext(ctx, `
+        SELECT EXISTS (SELECT 1 FROM jwt_signing_keys WHERE retired_at IS NULL AND created_at > $1)
+    `, notBefore).Scan(&fresh)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки активного ключа подписи: %w", err)
+	}
+	if fresh {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO jwt_signing_keys (kid, algorithm, private_key, public_key, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, key.KID, key.Algorithm, key.PrivateKey, key.PublicKey, key.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения ключа подписи: %w", err)
+	}
+
+	// Выводятся все прежние активные ключи, а не только известный этому инстансу;
+	// проверка токенов с ними продолжается до expiresAt
+	_, err = tx.ExecContext(ctx, `
+        UPDATE jwt_signing_keys SET retired_at = $1, expires_at = $2
+        WHERE retired_at IS NULL AND kid <> $3
+    `, retiredAt, expiresAt, key.KID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка вывода ключа подписи: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return true, nil
+}
+
+func (s *PostgresStorage) DeleteExpiredSigningKeys(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM jwt_signing_keys WHERE expires_at IS NOT NULL AND expires_at <= $1`
+	result, err := s.storage.DB.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка удаления истекших ключей подписи: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// userSortColumns — выражение сортировки и тип для приведения значения из курсора
+var userSortColumns = map[string]struct {
+	expr string
+	cast string
+}{
+	"created_at": {"u.created_at", "timestamp"},
+	"name":       {"LOWER(u.last_name || ' ' || u.first_name)", "text"},
+	"email":      {"LOWER(u.email)", "text"},
+}
+
+// userCursor — позиция последней строки страницы (keyset-пагинация по значению сортировки и id)
+type userCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// SearchUsers возвращает страницу пользователей по фильтру
+func (s *PostgresStorage) SearchUsers(ctx context.Context, filter models.UserFilter) (*models.UserPage, error) {
+	sort, ok := userSortColumns[filter.Sort]
+	if filter.HideEmail && filter.Sort == "email" {
+		filter.Sort, sort, ok = "name", userSortColumns["name"], true
+	}
+	if !ok {
+		filter.Sort = "created_at"
+		sort = userSortColumns[filter.Sort]
+	}
+
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch filter.Status {
+	case "inactive":
+		where = append(where, "u.deleted_at IS NOT NULL")
+	case "all":
+	default:
+		where = append(where, "u.deleted_at IS NULL")
+	}
+	if filter.Search != "" {
+		p := arg("%" + escapeLike(filter.Search) + "%")
+		byEmail := fmt.Sprintf(" OR u.email ILIKE %s", p)
+		if filter.HideEmail {
+			// Иначе по совпадениям подстроки можно восстановить скрытый адрес
+			byEmail = ""
+		}
+		where = append(where, fmt.Sprintf(
+			"(u.first_name ILIKE %[1]s OR u.last_name ILIKE %[1]s%[2]s OR (u.first_name || ' ' || u.last_name) ILIKE %[1]s OR (u.last_name || ' ' || u.first_name) ILIKE %[1]s)", p, byEmail))
+	}
+	if filter.RoleID != 0 {
+		where = append(where, "u.role_id = "+arg(filter.RoleID))
+	}
+	if filter.Verified != nil {
+		where = append(where, "COALESCE(u.email_confirmed, false) = "+arg(*filter.Verified))
+	}
+	if filter.Department != "" {
+		where = append(where, "LOWER(u.department) = LOWER("+arg(filter.Department)+")")
+	}
+
+	// Общее количество — без учёта курсора
+	countQuery := "SELECT COUNT(*) FROM users u" + whereClause(where)
+	var total int
+	if err := s.storage.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("ошибка подсчёта пользователей: %w", err)
+	}
+
+	cmp, order := ">", "ASC"
+	if filter.Desc {
+		cmp, order = "<", "DESC"
+	}
+	if filter.Cursor != "" {
+		c, err := decodeUserCursor(filter.Cursor)
+		if err != nil || c.Sort != filter.Sort || c.Desc != filter.Desc {
+			return nil, repoUser.ErrInvalidCursor
+		}
+		where = append(where, fmt.Sprintf("(%s, u.id) %s (%s::%s, %s)", sort.expr, cmp, arg(c.Value), sort.cast, arg(c.ID)))
+	}
+
+	query := fmt.Sprintf(`
+        SELECT
+            u.id,
+            u.email,
+            COALESCE(u.first_name, ''),
+            COALESCE(u.last_name, ''),
+            COALESCE(u.photo_path, ''),
+            COALESCE(u.email_confirmed, false),
+            u.created_at,
+            u.updated_at,
+            COALESCE(u.role_id, 0),
+            COALESCE(r.name, ''),
+            COALESCE(u.department, ''),
+            u.deleted_at,
+            u.anonymized_at,
+            (%s)::text AS sort_key
+        FROM users u
+        LEFT JOIN roles r ON r.id = u.role_id
+        %s
+        ORDER BY %s %s, u.id %s
+        LIMIT %s
+    `, sort.expr, whereClause(where), sort.expr, order, order, arg(filter.Limit+1))
+
+	rows, err := s.storage.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователей: %w", err)
+	}
+	defer rows.Close()
+
+	page := &models.UserPage{Items: []*models.User{}, Total: total}
+	var lastKey string
+	for rows.Next() {
+		user := &models.User{}
+		var sortKey string
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.FirstName,
+			&user.LastName,
+			&user.PhotoPath,
+			&user.IsVerified,
+			&user.CreatedAt,
+			&user.ChangedAt,
+			&user.RoleID,
+			&user.RoleName,
+			&user.Department,
+			&user.DeactivatedAt,
+			&user.AnonymizedAt,
+			&sortKey,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка чтения пользователя: %w", err)
+		}
+		if len(page.Items) == filter.Limit {
+			// Лишняя строка означает, что есть следующая страница
+			page.NextCursor = encodeUserCursor(userCursor{Sort: filter.Sort, Desc: filter.Desc, Value: lastKey, ID: page.Items[len(page.Items)-1].ID})
+			break
+		}
+		page.Items = append(page.Items, user)
+		lastKey = sortKey
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка строки: %w", err)
+	}
+
+	return page, nil
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// escapeLike экранирует спецсимволы ILIKE, чтобы поиск шёл по подстроке буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func encodeUserCursor(c userCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeUserCursor(s string) (userCursor, error) {
+	var c userCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(raw, &c)
+	return c, err
+}
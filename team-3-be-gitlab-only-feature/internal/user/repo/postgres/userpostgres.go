@@ -3,6 +3,7 @@ package repo
 import (
 	"cmd/internal/storage"
 	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
 	"cmd/internal/user/service"
 	"context"
 	"database/sql"
@@ -56,22 +57,63 @@ func NewFromConnectionString(connString string) (*PostgresStorage, error) {
 
 // CreateUser создает нового пользователя в базе данных
 func (s *PostgresStorage) CreateUser(ctx context.Context, user *models.User) error {
+	return insertUser(ctx, s.storage.DB, user, "", time.Time{})
+}
+
+// CreateUserWithVerification создаёт пользователя сразу с токеном верификации и ставит письмо со ссылкой
+// в очередь в одной транзакции: пользователь без письма не остаётся
+func (s *PostgresStorage) CreateUserWithVerification(ctx context.Context, user *models.User, token string, expiresAt time.Time, email func(userID int) (models.OutboxEmail, error)) error {
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertUser(ctx, tx, user, token, expiresAt); err != nil {
+		return err
+	}
+	msg, err := email(user.ID)
+	if err != nil {
+		return err
+	}
+	if err := enqueueEmails(ctx, tx, []models.OutboxEmail{msg}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func insertUser(ctx context.Context, q execer, user *models.User, token string, expiresAt time.Time) error {
 	query := `
-        INSERT INTO users (email, first_name, last_name, role_id, created_at, updated_at) 
-        VALUES ($1, $2, $3, $4, $5, $6) 
+        INSERT INTO users (email, first_name, last_name, role_id, department, invited_by, invited_at, created_at, updated_at, language,
+                           email_verification_token, expires_at) 
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, COALESCE(NULLIF($10, ''), 'ru'), NULLIF($11, ''), COALESCE($12::timestamp, CURRENT_TIMESTAMP)) 
         RETURNING id
     `
 
+	var tokenExpiresAt *time.Time
+	if token != "" {
+		tokenExpiresAt = &expiresAt
+	}
+
 	now := time.Now()
-	err := s.storage.DB.QueryRowContext(
+	err := q.QueryRowContext(
 		ctx,
 		query,
 		user.Email,
 		user.FirstName,
 		user.LastName,
 		user.RoleID,
+		user.Department,
+		user.InvitedBy,
+		user.InvitedAt,
 		now,
 		now,
+		user.Language,
+		token,
+		tokenExpiresAt,
 	).Scan(&user.ID)
 
 	if err != nil {
@@ -100,7 +142,13 @@ func (s *PostgresStorage) GetUserByID(ctx context.Context, userID int) (*models.
             u.created_at, 
             u.updated_at,
             COALESCE(u.role_id, 0)               AS role_id,
-            COALESCE(r.name, '')                 AS role_name
+            COALESCE(r.name, '')                 AS role_name,
+            COALESCE(u.department, '')           AS department,
+            u.token_version,
+            u.deleted_at,
+            u.deactivated_by,
+            u.anonymized_at,
+            u.language
         FROM users u
         LEFT JOIN roles r ON r.id = u.role_id
         WHERE u.id = $1 
@@ -119,6 +167,12 @@ func (s *PostgresStorage) GetUserByID(ctx context.Context, userID int) (*models.
 		&user.ChangedAt,
 		&user.RoleID,
 		&user.RoleName,
+		&user.Department,
+		&user.TokenVersion,
+		&user.DeactivatedAt,
+		&user.DeactivatedBy,
+		&user.AnonymizedAt,
+		&user.Language,
 	)
 
 	if err != nil {
@@ -145,7 +199,13 @@ func (s *PostgresStorage) GetUserByEmail(ctx context.Context, email string) (*mo
             u.created_at, 
             u.updated_at,
             COALESCE(u.role_id, 0)               AS role_id,
-            COALESCE(r.name, '')                 AS role_name
+            COALESCE(r.name, '')                 AS role_name,
+            COALESCE(u.department, '')           AS department,
+            u.token_version,
+            u.deleted_at,
+            u.deactivated_by,
+            u.anonymized_at,
+            u.language
         FROM users u
         LEFT JOIN roles r ON r.id = u.role_id
         WHERE LOWER(TRIM(u.email)) = LOWER(TRIM($1))
@@ -164,11 +224,17 @@ func (s *PostgresStorage) GetUserByEmail(ctx context.Context, email string) (*mo
 		&user.ChangedAt,
 		&user.RoleID,
 		&user.RoleName,
+		&user.Department,
+		&user.TokenVersion,
+		&user.DeactivatedAt,
+		&user.DeactivatedBy,
+		&user.AnonymizedAt,
+		&user.Language,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("пользователь не найден")
+			return nil, repoUser.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("ошибка поиска пользователя по email: %w", err)
 	}
@@ -209,17 +275,25 @@ func (s *PostgresStorage) UpdateUser(ctx context.Context, user *models.User) err
 	return nil
 }
 
-// DeleteUser мягкое удаление пользователя
-func (s *PostgresStorage) DeleteUser(ctx context.Context, userID int) error {
+// DeactivateUser мягкое удаление: пользователь не может войти и скрыт из списков, данные сохраняются.
+// deactivatedBy — администратор; nil, если пользователь отключён синхронизацией с каталогом.
+// Документы передаются reassignTo, если он указан, в той же транзакции
+func (s *PostgresStorage) DeactivateUser(ctx context.Context, userID int, deactivatedBy *int, reassignTo int) error {
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE users 
-		SET deleted_at = $1 
-		WHERE id = $2 AND deleted_at IS NULL
+		SET deleted_at = $1, deactivated_by = $2
+		WHERE id = $3 AND deleted_at IS NULL
 	`
 
-	result, err := s.storage.DB.ExecContext(ctx, query, time.Now(), userID)
+	result, err := tx.ExecContext(ctx, query, time.Now(), deactivatedBy, userID)
 	if err != nil {
-		return fmt.Errorf("ошибка удаления пользователя: %w", err)
+		return fmt.Errorf("ошибка деактивации пользователя: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -231,6 +305,48 @@ func (s *PostgresStorage) DeleteUser(ctx context.Context, userID int) error {
 		return errors.New("пользователь не найден")
 	}
 
+	if reassignTo > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE documents SET user_id = $1 WHERE user_id = $2`, reassignTo, userID); err != nil {
+			return fmt.Errorf("ошибка передачи документов: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser окончательно удаляет пользователя. Комментарии, лайки и отметки об ознакомлении
+// остаются без автора (ON DELETE SET NULL); документы передаются reassignTo, если он указан
+func (s *PostgresStorage) DeleteUser(ctx context.Context, userID int, reassignTo int) error {
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if reassignTo > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE documents SET user_id = $1 WHERE user_id = $2`, reassignTo, userID); err != nil {
+			return fmt.Errorf("ошибка передачи документов: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления пользователя: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось получить количество удалённых строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New("пользователь не найден")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
 	return nil
 }
 
@@ -264,7 +380,8 @@ func (s *PostgresStorage) GetAllUsers(ctx context.Context) ([]*models.User, erro
   			u.created_at,
   			u.updated_at,
   			COALESCE(u.role_id, 0)       AS role_id,
-  			COALESCE(r.name, '')         AS role_name
+  			COALESCE(r.name, '')         AS role_name,
+  			COALESCE(u.department, '')   AS department
         FROM users AS u
   		LEFT JOIN roles AS r ON r.id = u.role_id
  		WHERE u.deleted_at IS NULL
@@ -291,6 +408,7 @@ func (s *PostgresStorage) GetAllUsers(ctx context.Context) ([]*models.User, erro
 			&user.ChangedAt,
 			&user.RoleID,
 			&user.RoleName,
+			&user.Department,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка вызова данных пользователя: %w", err)
@@ -307,7 +425,7 @@ func (s *PostgresStorage) GetAllUsers(ctx context.Context) ([]*models.User, erro
 
 // GetRoleIDByName возвращает ID роли по имени
 func (s *PostgresStorage) GetRoleIDByName(ctx context.Context, roleName string) (int, error) {
-	query := `SELECT id FROM role WHERE name = $1`
+	query := `SELECT id FROM roles WHERE name = $1`
 
 	var roleID int
 	err := s.storage.DB.QueryRowContext(ctx, query, roleName).Scan(&roleID)
@@ -321,6 +439,16 @@ func (s *PostgresStorage) GetRoleIDByName(ctx context.Context, roleName string)
 	return roleID, nil
 }
 
+// RoleExists проверяет, что роль с таким ID есть в справочнике
+func (s *PostgresStorage) RoleExists(ctx context.Context, roleID int) (bool, error) {
+	var exists bool
+	err := s.storage.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)`, roleID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки роли: %w", err)
+	}
+	return exists, nil
+}
+
 // MarkUserAsVerified помечает пользователя как верифицированного
 func (s *PostgresStorage) MarkUserAsVerified(ctx context.Context, userID int) error {
 	log.Printf("✅ Верификация пользователя: userID=%d", userID)
@@ -395,19 +523,142 @@ func (s *PostgresStorage) UpdateUserProfile(ctx context.Context, userID int, upd
               first_name = COALESCE($1, first_name), 
               last_name = COALESCE($2, last_name), 
               photo_path = COALESCE($3, photo_path),
-              updated_at = $4 
-              WHERE id = $5`
+              language = COALESCE($4, language),
+              updated_at = $5 
+              WHERE id = $6`
 
 	_, err := s.storage.DB.ExecContext(ctx, query,
 		updateReq.FirstName,
 		updateReq.LastName,
 		updateReq.PhotoPath,
+		updateReq.Language,
 		time.Now(),
 		userID,
 	)
 	return err
 }
 
+// UpdateUserRole меняет роль пользователя (роль из групп SSO провайдера)
+func (s *PostgresStorage) UpdateUserRole(ctx context.Context, userID int, roleID int) error {
+	query := `UPDATE users SET role_id = $1, updated_at = $2 WHERE id = $3`
+
+	result, err := s.storage.DB.ExecContext(ctx, query, roleID, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления роли пользователя: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("пользователь не найден")
+	}
+
+	return nil
+}
+
+// UpdateInvitedUser обновляет роль, имя, отдел и язык пользователя, который ещё не принял приглашение;
+// пустые поля оставляют прежние значения
+func (s *PostgresStorage) UpdateInvitedUser(ctx context.Context, u *models.User) error {
+	query := `
+        UPDATE users
+        SET first_name = COALESCE(NULLIF($1, ''), first_name),
+            last_name = COALESCE(NULLIF($2, ''), last_name),
+            role_id = $3,
+            department = COALESCE(NULLIF($4, ''), department),
+            language = COALESCE(NULLIF($5, ''), language),
+            invited_by = $6,
+            invited_at = $7,
+            updated_at = $7
+        WHERE id = $8 AND COALESCE(email_confirmed, false) = false AND deleted_at IS NULL
+    `
+	result, err := s.storage.DB.ExecContext(ctx, query,
+		u.FirstName, u.LastName, u.RoleID, u.Department, u.Language, u.InvitedBy, time.Now(), u.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления приглашения: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return repoUser.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateUserEmail меняет email пользователя; занятый адрес возвращает "user already exists"
+func (s *PostgresStorage) UpdateUserEmail(ctx context.Context, userID int, email string) error {
+	query := `UPDATE users SET email = $1, updated_at = $2 WHERE id = $3`
+
+	result, err := s.storage.DB.ExecContext(ctx, query, email, time.Now(), userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("user already exists")
+		}
+		return fmt.Errorf("ошибка обновления email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось получить количество обновленных строк: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("пользователь не найден")
+	}
+
+	return nil
+}
+
+// ReactivateUser снимает пометку о деактивации (пользователь снова может войти)
+func (s *PostgresStorage) ReactivateUser(ctx context.Context, userID int) error {
+	query := `UPDATE users SET deleted_at = NULL, deactivated_by = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL`
+
+	_, err := s.storage.DB.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("ошибка восстановления пользователя: %w", err)
+	}
+	return nil
+}
+
+// GetTokenVersion возвращает текущую версию токенов пользователя и признак активности (не удалён)
+func (s *PostgresStorage) GetTokenVersion(ctx context.Context, userID int) (int, bool, error) {
+	query := `SELECT token_version, deleted_at IS NULL FROM users WHERE id = $1`
+
+	var version int
+	var active bool
+	err := s.storage.DB.QueryRowContext(ctx, query, userID).Scan(&version, &active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Пользователя нет — считаем его неактивным, токены не принимаются
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("ошибка получения версии токенов: %w", err)
+	}
+
+	return version, active, nil
+}
+
+// IncrementTokenVersion увеличивает версию токенов, делая все выданные access токены недействительными
+func (s *PostgresStorage) IncrementTokenVersion(ctx context.Context, userID int) (int, error) {
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1 RETURNING token_version`
+
+	var version int
+	err := s.storage.DB.QueryRowContext(ctx, query, userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("пользователь не найден")
+		}
+		return 0, fmt.Errorf("ошибка обновления версии токенов: %w", err)
+	}
+
+	return version, nil
+}
+
 func (s *PostgresStorage) Ping(ctx context.Context) error {
 	if s.storage == nil || s.storage.DB == nil {
 		return errors.New("database connection not initialized")
@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"cmd/internal/user/models"
 	"context"
 	"database/sql"
 	"errors"
@@ -11,8 +12,8 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-// SaveVerificationToken сохраняет токен верификации
-func (s *PostgresStorage) SaveVerificationToken(ctx context.Context, userID int, token string, expiresAt time.Time) error {
+// SaveVerificationToken сохраняет токен верификации; письма со ссылкой ставятся в очередь той же транзакцией
+func (s *PostgresStorage) SaveVerificationToken(ctx context.Context, userID int, token string, expiresAt time.Time, emails ...models.OutboxEmail) error {
 	log.Printf("Сохранение токена верификации: userID=%d, token=%s, expiresAt=%v", userID, token, expiresAt)
 
 	query := `
@@ -23,11 +24,23 @@ func (s *PostgresStorage) SaveVerificationToken(ctx context.Context, userID int,
         WHERE id = $4
     `
 
-	result, err := s.storage.DB.ExecContext(ctx, query, token, expiresAt, time.Now(), userID)
+	tx, err := s.storage.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, token, expiresAt, time.Now(), userID)
 	if err != nil {
 		log.Printf("❌ Сохранение токена верификации ошибка: %v", err)
 		return fmt.Errorf("ошибка сохранения токена верификации: %w", err)
 	}
+	if err := enqueueEmails(ctx, tx, emails); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
 
 	rows, _ := result.RowsAffected()
 	log.Printf("✅ Токен верификации сохранен: строка обновлена = %d", rows)
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrDirectoryDisabled    = errors.New("directory sync is not configured")
+	ErrDirectorySyncRunning = errors.New("directory sync is already running")
+	ErrNoDirectorySyncRuns  = errors.New("directory sync has not run yet")
+)
+
+// defaultRoleID — роль новых пользователей из внешних источников, если группы не сопоставлены (Все пользователи)
+const defaultRoleID = 5
+
+const (
+	SyncActionCreated     = "created"
+	SyncActionUpdated     = "updated"
+	SyncActionDeactivated = "deactivated"
+	SyncActionReactivated = "reactivated"
+	SyncActionUnchanged   = "unchanged"
+	SyncActionSkipped     = "skipped"
+)
+
+// DirectorySyncReport — итог синхронизации пользователей с каталогом
+type DirectorySyncReport struct {
+	ID          int64                 `json:"id"`
+	Source      string                `json:"source"`
+	DryRun      bool                  `json:"dry_run"`
+	StartedAt   time.Time             `json:"started_at"`
+	FinishedAt  time.Time             `json:"finished_at"`
+	Total       int                   `json:"total"`
+	Created     int                   `json:"created"`
+	Updated     int                   `json:"updated"`
+	Deactivated int                   `json:"deactivated"`
+	Reactivated int                   `json:"reactivated"`
+	Unchanged   int                   `json:"unchanged"`
+	Changes     []DirectorySyncChange `json:"changes"`
+	Errors      []string              `json:"errors"`
+}
+
+// DirectorySyncChange — что произошло (или произошло бы в dry-run) с одним пользователем
+type DirectorySyncChange struct {
+	UserID  int      `json:"user_id,omitempty"`
+	Email   string   `json:"email"`
+	Action  string   `json:"action"`
+	Details []string `json:"details,omitempty"`
+}
+
+func (r *DirectorySyncReport) add(change DirectorySyncChange) {
+	switch change.Action {
+	case SyncActionCreated:
+		r.Created++
+	case SyncActionUpdated:
+		r.Updated++
+	case SyncActionDeactivated:
+		r.Deactivated++
+	case SyncActionReactivated:
+		r.Reactivated++
+	default:
+		r.Unchanged++
+		return // неизменённых в отчёт не пишем, только считаем
+	}
+	r.Changes = append(r.Changes, change)
+}
+
+// SyncDirectory сверяет пользователей портала с каталогом: создаёт новых, обновляет имя/email/роль,
+// деактивирует отключённых и пропавших. В dry-run ничего не меняет, только строит отчёт.
+func (s *service) SyncDirectory(ctx context.Context, dryRun bool) (*DirectorySyncReport, error) {
+	if s.directory == nil {
+		return nil, ErrDirectoryDisabled
+	}
+	if !s.syncMu.TryLock() {
+		return nil, ErrDirectorySyncRunning
+	}
+	defer s.syncMu.Unlock()
+
+	source := s.directory.Name()
+	report := &DirectorySyncReport{Source: source, DryRun: dryRun, StartedAt: time.Now()}
+	log.Printf("🔄 Синхронизация с каталогом %s начата (dry_run=%t)", source, dryRun)
+
+	entries, err := s.directory.ListUsers(ctx)
+	if err != nil {
+		log.Printf("❌ Ошибка выгрузки каталога: %v", err)
+		return nil, err
+	}
+	// Пустая выгрузка почти всегда означает неверный фильтр или BaseDN — не деактивируем всех разом
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("каталог %s вернул пустой список пользователей, синхронизация прервана", source)
+	}
+	report.Total = len(entries)
+
+	seen := make(map[string]bool, len(entries))
+	for _, ext := range entries {
+		seen[ext.Subject] = true
+		_, change, err := s.applyExternalUser(ctx, source, ext, dryRun)
+		if err != nil {
+			log.Printf("⚠️ Синхронизация %s: %v", ext.Email, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", ext.Email, err))
+			continue
+		}
+		report.add(change)
+	}
+
+	// Пользователи, привязанные к каталогу, но пропавшие из него — деактивируем
+	identities, err := s.storage.ListIdentities(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if seen[identity.Subject] {
+			continue
+		}
+		change, err := s.deactivateDirectoryUser(ctx, identity.UserID, identity.Email, "нет в каталоге", dryRun)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", identity.Email, err))
+			continue
+		}
+		report.add(change)
+	}
+
+	report.FinishedAt = time.Now()
+	if err := s.saveDirectorySyncReport(ctx, report); err != nil {
+		log.Printf("⚠️ Не удалось сохранить отчёт синхронизации: %v", err)
+	}
+
+	log.Printf("✅ Синхронизация с каталогом %s: всего=%d создано=%d обновлено=%d деактивировано=%d восстановлено=%d ошибок=%d",
+		source, report.Total, report.Created, report.Updated, report.Deactivated, report.Reactivated, len(report.Errors))
+	return report, nil
+}
+
+// LastDirectorySyncReport возвращает отчёт последнего запуска синхронизации
+func (s *service) LastDirectorySyncReport(ctx context.Context) (*DirectorySyncReport, error) {
+	run, err := s.storage.GetLastDirectorySyncRun(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoDirectorySyncRuns
+		}
+		return nil, err
+	}
+
+	var report DirectorySyncReport
+	if err := json.Unmarshal(run.Report, &report); err != nil {
+		return nil, fmt.Errorf("повреждённый отчёт синхронизации: %w", err)
+	}
+	report.ID = run.ID
+	return &report, nil
+}
+
+// RunDirectorySyncJob периодически запускает синхронизацию до отмены ctx
+func RunDirectorySyncJob(ctx context.Context, svc Service, interval time.Duration, dryRun bool) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.SyncDirectory(ctx, dryRun); err != nil {
+				log.Printf("❌ Плановая синхронизация с каталогом: %v", err)
+			}
+		}
+	}
+}
+
+// applyExternalUser приводит пользователя портала к состоянию учётной записи каталога.
+// Используется и синхронизацией, и входом по паролю каталога.
+func (s *service) applyExternalUser(ctx context.Context, provider string, ext ExternalUser, dryRun bool) (*models.User, DirectorySyncChange, error) {
+	change := DirectorySyncChange{Email: ext.Email}
+
+	var user *models.User
+	userID, linked, err := s.storage.FindIdentity(ctx, provider, ext.Subject)
+	if err != nil {
+		return nil, change, err
+	}
+	if linked {
+		if user, err = s.storage.GetUserByID(ctx, userID); err != nil {
+			return nil, change, err
+		}
+	} else if existing, err := s.storage.GetUserByEmail(ctx, ext.Email); err == nil {
+		user = existing
+	}
+
+	if user == nil {
+		if ext.Disabled {
+			change.Action = SyncActionSkipped
+			return nil, change, nil
+		}
+		change.Action = SyncActionCreated
+		if dryRun {
+			return nil, change, nil
+		}
+		user, err = s.createExternalUser(ctx, provider, ext)
+		if err != nil {
+			return nil, change, err
+		}
+		change.UserID = user.ID
+		return user, change, nil
+	}
+	change.UserID = user.ID
+
+	_, active, err := s.storage.GetTokenVersion(ctx, user.ID)
+	if err != nil {
+		return nil, change, err
+	}
+
+	if !linked && !dryRun {
+		if err := s.storage.LinkIdentity(ctx, repoUser.UserIdentity{
+			UserID: user.ID, Provider: provider, Subject: ext.Subject, Email: ext.Email,
+		}); err != nil {
+			return nil, change, err
+		}
+	}
+
+	if ext.Disabled {
+		if !active {
+			change.Action = SyncActionUnchanged
+			return user, change, nil
+		}
+		change, err = s.deactivateDirectoryUser(ctx, user.ID, user.Email, "отключён в каталоге", dryRun)
+		return user, change, err
+	}
+
+	if !active && user.DeactivatedBy != nil {
+		// Деактивацию администратором каталог не отменяет
+		change.Action = SyncActionUnchanged
+		change.Details = []string{"деактивирован администратором"}
+		return nil, change, nil
+	}
+	if !active {
+		change.Action = SyncActionReactivated
+		if !dryRun {
+			if err := s.storage.ReactivateUser(ctx, user.ID); err != nil {
+				return nil, change, err
+			}
+			s.tokenVersions.Invalidate(user.ID)
+		}
+	}
+	if !linked {
+		change.Details = append(change.Details, "привязан к каталогу")
+	}
+
+	if (ext.FirstName != "" && ext.FirstName != user.FirstName) || (ext.LastName != "" && ext.LastName != user.LastName) {
+		change.Details = append(change.Details, fmt.Sprintf("имя: %s %s → %s %s", user.FirstName, user.LastName, ext.FirstName, ext.LastName))
+		if !dryRun {
+			upd := UpdateProfileRequest{}
+			if ext.FirstName != "" {
+				upd.FirstName = &ext.FirstName
+			}
+			if ext.LastName != "" {
+				upd.LastName = &ext.LastName
+			}
+			if err := s.storage.UpdateUserProfile(ctx, user.ID, upd); err != nil {
+				return nil, change, err
+			}
+		}
+	}
+
+	if ext.Email != "" && ext.Email != user.Email {
+		change.Details = append(change.Details, fmt.Sprintf("email: %s → %s", user.Email, ext.Email))
+		if !dryRun {
+			if err := s.storage.UpdateUserEmail(ctx, user.ID, ext.Email); err != nil {
+				return nil, change, err
+			}
+		}
+	}
+
+	// Роль из каталога меняем только при сопоставленной группе — иначе сохраняется роль, назначенная в портале
+	if ext.RoleID != 0 && ext.RoleID != user.RoleID {
+		change.Details = append(change.Details, fmt.Sprintf("роль: %d → %d", user.RoleID, ext.RoleID))
+		if !dryRun {
+			if err := s.storage.UpdateUserRole(ctx, user.ID, ext.RoleID); err != nil {
+				return nil, change, err
+			}
+			if err := s.RevokeAccessTokens(ctx, user.ID); err != nil {
+				return nil, change, err
+			}
+		}
+	}
+
+	if change.Action == "" {
+		change.Action = SyncActionUnchanged
+		if len(change.Details) > 0 {
+			change.Action = SyncActionUpdated
+		}
+	}
+	if dryRun || change.Action == SyncActionUnchanged {
+		return user, change, nil
+	}
+
+	user, err = s.storage.GetUserByID(ctx, user.ID)
+	return user, change, err
+}
+
+func (s *service) createExternalUser(ctx context.Context, provider string, ext ExternalUser) (*models.User, error) {
+	roleID := ext.RoleID
+	if roleID == 0 {
+		roleID = defaultRoleID
+	}
+	firstName := ext.FirstName
+	if firstName == "" {
+		firstName = ext.Email
+	}
+
+	user := &models.User{
+		Email:      ext.Email,
+		FirstName:  firstName,
+		LastName:   ext.LastName,
+		RoleID:     roleID,
+		IsVerified: true,
+		CreatedAt:  time.Now(),
+		ChangedAt:  time.Now(),
+	}
+	if err := s.storage.CreateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("создание пользователя: %w", err)
+	}
+	// Email подтверждён каталогом, пароль проверяет каталог
+	if err := s.storage.MarkUserAsVerified(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.storage.LinkIdentity(ctx, repoUser.UserIdentity{
+		UserID: user.ID, Provider: provider, Subject: ext.Subject, Email: ext.Email,
+	}); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Пользователь из каталога %s создан: id=%d email=%s role_id=%d", provider, user.ID, user.Email, roleID)
+
+	if ext.LastName != "" {
+		s.generateAvatarAsync(user.ID)
+	}
+
+	return s.storage.GetUserByID(ctx, user.ID)
+}
+
+func (s *service) deactivateDirectoryUser(ctx context.Context, userID int, email, reason string, dryRun bool) (DirectorySyncChange, error) {
+	change := DirectorySyncChange{UserID: userID, Email: email, Action: SyncActionUnchanged}
+
+	_, active, err := s.storage.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return change, err
+	}
+	if !active {
+		return change, nil
+	}
+
+	change.Action = SyncActionDeactivated
+	change.Details = []string{reason}
+	if dryRun {
+		return change, nil
+	}
+
+	if err := s.storage.DeactivateUser(ctx, userID, nil, 0); err != nil {
+		return change, err
+	}
+	if err := s.endUserSessions(ctx, userID); err != nil {
+		return change, err
+	}
+	return change, nil
+}
+
+func (s *service) saveDirectorySyncReport(ctx context.Context, report *DirectorySyncReport) error {
+	raw, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	id, err := s.storage.SaveDirectorySyncRun(ctx, repoUser.DirectorySyncRun{
+		Source:     report.Source,
+		DryRun:     report.DryRun,
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+		Report:     raw,
+	})
+	if err != nil {
+		return err
+	}
+	report.ID = id
+	return nil
+}
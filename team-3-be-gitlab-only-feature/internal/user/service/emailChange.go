@@ -0,0 +1,124 @@
+package service
+
+import (
+	"cmd/internal/mail"
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmailChangeInvalid     = errors.New("invalid or expired email change token")
+	ErrSameEmail              = errors.New("new email equals current email")
+	ErrWrongPassword          = errors.New("wrong password")
+	ErrEmailChangeUnavailable = errors.New("email delivery is not configured")
+	ErrEmailChangeNotSent     = errors.New("email change confirmation was not sent")
+)
+
+// emailChangeTTL — срок действия ссылки подтверждения нового адреса
+const emailChangeTTL = 24 * time.Hour
+
+// RequestEmailChange отправляет ссылку подтверждения на новый адрес и уведомление на старый.
+// Email меняется только после перехода по ссылке (ConfirmEmailChange).
+func (s *service) RequestEmailChange(ctx context.Context, userID int, req models.ChangeEmailRequest) error {
+	newEmail := strings.ToLower(strings.TrimSpace(req.NewEmail))
+	log.Printf("➡️ Запрос смены email: user_id=%d new_email=%s", userID, newEmail)
+
+	if s.emailService == nil {
+		return ErrEmailChangeUnavailable
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserByID error: %v", err)
+		return ErrUserNotFound
+	}
+	if strings.EqualFold(strings.TrimSpace(user.Email), newEmail) {
+		return ErrSameEmail
+	}
+	// Пользователи SSO без пароля подтверждают смену только доступом к новому ящику
+	if user.PasswordHash != "" && !s.checkPasswordHash(req.Password, user.PasswordHash) {
+		log.Printf("⚠️ Смена email: неверный пароль user_id=%d", userID)
+		return ErrWrongPassword
+	}
+	if _, err := s.storage.GetUserByEmail(ctx, newEmail); err == nil {
+		return ErrUserAlreadyExists
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(emailChangeTTL)
+
+	confirm, err := s.verificationSvc.renderEmail(userID, newEmail, user.Language, mail.TemplateEmailChangeConfirm, map[string]interface{}{
+		"FirstName": user.FirstName,
+		"URL":       s.verificationSvc.confirmEmailChangeURL(token),
+		"ExpiresAt": expiresAt,
+	})
+	if err != nil {
+		log.Printf("❌ Не удалось подготовить подтверждение для %s: %v", newEmail, err)
+		return ErrEmailChangeNotSent
+	}
+	notice, err := s.verificationSvc.renderEmail(userID, user.Email, user.Language, mail.TemplateEmailChangeRequested, map[string]interface{}{
+		"FirstName": user.FirstName,
+		"NewEmail":  newEmail,
+	})
+	if err != nil {
+		log.Printf("❌ Не удалось подготовить уведомление для %s: %v", user.Email, err)
+		return ErrEmailChangeNotSent
+	}
+
+	// Запрос и оба письма сохраняются одной транзакцией, отправит их фоновый процесс
+	if err := s.storage.SaveEmailChangeRequest(ctx, repoUser.EmailChangeRequest{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: HashRefreshToken(token),
+		ExpiresAt: expiresAt,
+	}, confirm, notice); err != nil {
+		log.Printf("❌ SaveEmailChangeRequest error: %v", err)
+		return err
+	}
+
+	log.Printf("✅ Ссылка подтверждения смены email поставлена в очередь: user_id=%d", userID)
+	return nil
+}
+
+// ConfirmEmailChange применяет новый email по токену из письма и завершает все сессии пользователя
+func (s *service) ConfirmEmailChange(ctx context.Context, token string) error {
+	log.Printf("➡️ Подтверждение смены email")
+
+	change, err := s.storage.ConfirmEmailChange(ctx, HashRefreshToken(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, repoUser.ErrEmailChangeNotFound):
+			return ErrEmailChangeInvalid
+		case errors.Is(err, repoUser.ErrEmailTaken):
+			return ErrUserAlreadyExists
+		}
+		log.Printf("❌ ConfirmEmailChange error: %v", err)
+		return err
+	}
+
+	// Старые сессии выданы на прежний адрес — пользователь входит заново с новым
+	if err := s.endUserSessions(ctx, change.UserID); err != nil {
+		return err
+	}
+
+	notice, err := s.verificationSvc.renderEmail(change.UserID, change.OldEmail, change.Language, mail.TemplateEmailChanged, map[string]interface{}{
+		"NewEmail": change.NewEmail,
+	})
+	if err == nil {
+		err = s.storage.EnqueueEmails(ctx, notice)
+	}
+	if err != nil {
+		log.Printf("⚠️ Не удалось уведомить старый адрес %s: %v", change.OldEmail, err)
+	}
+
+	log.Printf("✅ Email изменён: user_id=%d %s → %s", change.UserID, change.OldEmail, change.NewEmail)
+	return nil
+}
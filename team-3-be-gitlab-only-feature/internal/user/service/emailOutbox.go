@@ -0,0 +1,199 @@
+package service
+
+import (
+	"cmd/internal/mail"
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+)
+
+var (
+	ErrOutboxEmailNotFound = errors.New("outbox email not found")
+	ErrOutboxEmailSent     = errors.New("outbox email already sent")
+	ErrInvalidOutboxStatus = errors.New("invalid outbox status")
+)
+
+// OutboxConfig — настройки фоновой отправки писем из очереди
+type OutboxConfig struct {
+	// Provider — SMTP сервер (или другой провайдер), через который идёт отправка
+	Provider string
+	// RateLimits — писем в минуту по провайдерам; ключ "default" — для остальных, 0 — без ограничения.
+	// Лимит общий для всех экземпляров сервиса: попытки считаются по email_outbox
+	RateLimits   map[string]int
+	PollInterval time.Duration // как часто проверять очередь (по умолчанию 5 секунд)
+	BatchSize    int           // сколько писем забирать за раз (по умолчанию 20)
+	MaxAttempts  int           // после стольких неудач письмо переходит в dead (по умолчанию 8)
+	BaseBackoff  time.Duration // пауза после первой неудачи, дальше удваивается (по умолчанию 30 секунд)
+	MaxBackoff   time.Duration // потолок паузы (по умолчанию 1 час)
+	KeepSent     time.Duration // сколько хранить доставленные письма (по умолчанию 30 дней)
+}
+
+// outboxLease — на сколько письмо резервируется за отправителем; после падения процесса оно вернётся в очередь
+const outboxLease = 10 * time.Minute
+
+// OutboxSender отправляет письма из email_outbox с повторами и экспоненциальной паузой
+type OutboxSender struct {
+	storage   repoUser.Storage
+	transport EmailService
+	cfg       OutboxConfig
+	perMinute int
+}
+
+// NewOutboxSender создаёт отправителя очереди писем
+func NewOutboxSender(storage repoUser.Storage, transport EmailService, cfg OutboxConfig) *OutboxSender {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+	if cfg.KeepSent <= 0 {
+		cfg.KeepSent = 30 * 24 * time.Hour
+	}
+
+	perMinute, ok := cfg.RateLimits[cfg.Provider]
+	if !ok {
+		perMinute = cfg.RateLimits["default"]
+	}
+	log.Printf("📬 Очередь писем: провайдер=%s лимит=%d/мин попыток=%d", cfg.Provider, perMinute, cfg.MaxAttempts)
+
+	return &OutboxSender{
+		storage:   storage,
+		transport: transport,
+		cfg:       cfg,
+		perMinute: perMinute,
+	}
+}
+
+// Run отправляет письма до отмены контекста; раз в сутки удаляет старые доставленные письма
+func (o *OutboxSender) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.cfg.PollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(24 * time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Пока очередь не пуста и лимит позволяет, разбираем её без ожидания следующего тика
+			for o.processBatch(ctx) {
+			}
+		case <-cleanup.C:
+			n, err := o.storage.DeleteSentOutboxEmails(ctx, time.Now().Add(-o.cfg.KeepSent))
+			if err != nil {
+				log.Printf("❌ Очистка очереди писем: %v", err)
+			} else if n > 0 {
+				log.Printf("🧹 Удалено доставленных писем: %d", n)
+			}
+		}
+	}
+}
+
+// processBatch отправляет одну порцию; true — порция была полной, стоит сразу взять следующую
+func (o *OutboxSender) processBatch(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	emails, err := o.storage.ClaimOutboxEmails(ctx, o.cfg.BatchSize, o.perMinute, outboxLease)
+	if err != nil {
+		log.Printf("❌ Очередь писем: %v", err)
+		return false
+	}
+
+	for _, e := range emails {
+		o.deliver(ctx, e)
+	}
+	return len(emails) == o.cfg.BatchSize
+}
+
+func (o *OutboxSender) deliver(ctx context.Context, e models.OutboxEmail) {
+	err := o.transport.SendMessage(e.Recipient, mail.Message{Subject: e.Subject, Text: e.Text, HTML: e.HTML})
+	if err == nil {
+		if err := o.storage.MarkOutboxEmailSent(ctx, e.ID); err != nil {
+			log.Printf("❌ Письмо %d отправлено, но статус не сохранён: %v", e.ID, err)
+		}
+		return
+	}
+
+	// attempts уже учитывает текущую попытку (увеличен при резервировании)
+	if e.Attempts >= o.cfg.MaxAttempts {
+		log.Printf("❌ Письмо %d на %s не доставлено после %d попыток: %v", e.ID, e.Recipient, e.Attempts, err)
+		if err := o.storage.MarkOutboxEmailFailed(ctx, e.ID, err.Error(), nil); err != nil {
+			log.Printf("❌ Очередь писем: %v", err)
+		}
+		return
+	}
+
+	next := time.Now().Add(o.backoff(e.Attempts))
+	log.Printf("⚠️ Письмо %d на %s: попытка %d не удалась, следующая в %s: %v",
+		e.ID, e.Recipient, e.Attempts, next.Format("15:04:05"), err)
+	if err := o.storage.MarkOutboxEmailFailed(ctx, e.ID, err.Error(), &next); err != nil {
+		log.Printf("❌ Очередь писем: %v", err)
+	}
+}
+
+// backoff — BaseBackoff·2^(attempt-1) с разбросом ±20%, не больше MaxBackoff
+func (o *OutboxSender) backoff(attempt int) time.Duration {
+	d := o.cfg.BaseBackoff
+	for i := 1; i < attempt && d < o.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.cfg.MaxBackoff {
+		d = o.cfg.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(d)/5*2+1)) - d/5
+	return d + jitter
+}
+
+// ListOutboxEmails — письма очереди для администратора; status пустой или pending/sent/dead
+func (s *service) ListOutboxEmails(ctx context.Context, status string, limit, offset int) (*models.OutboxPage, error) {
+	switch status {
+	case "", models.OutboxPending, models.OutboxSent, models.OutboxDead:
+	default:
+		return nil, ErrInvalidOutboxStatus
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.storage.ListOutboxEmails(ctx, status, limit, offset)
+}
+
+// ResendOutboxEmail возвращает недоставленное письмо в очередь, счётчик попыток обнуляется
+func (s *service) ResendOutboxEmail(ctx context.Context, id int64) error {
+	log.Printf("➡️ Повторная отправка письма id=%d", id)
+
+	err := s.storage.ResendOutboxEmail(ctx, id)
+	switch {
+	case errors.Is(err, repoUser.ErrOutboxEmailNotFound):
+		return ErrOutboxEmailNotFound
+	case errors.Is(err, repoUser.ErrOutboxEmailSent):
+		return ErrOutboxEmailSent
+	case err != nil:
+		log.Printf("❌ ResendOutboxEmail error: %v", err)
+		return err
+	}
+
+	log.Printf("✅ Письмо id=%d снова в очереди", id)
+	return nil
+}
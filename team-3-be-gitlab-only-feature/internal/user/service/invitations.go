@@ -0,0 +1,242 @@
+package service
+
+import (
+	"cmd/internal/mail"
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRegistrationClosed    = errors.New("self-registration is disabled")
+	ErrUnknownRole           = errors.New("unknown role")
+	ErrInvitationEmailFailed = errors.New("invitation email was not sent")
+	ErrInvalidInviteCSV      = errors.New("invalid invitation csv")
+)
+
+// maxInviteRows — ограничение размера одного импорта
+const maxInviteRows = 1000
+
+// InviteUser создаёт неподтверждённый аккаунт и отправляет приглашение со ссылкой установки пароля.
+// Повторное приглашение ещё не подтверждённого пользователя перевыпускает ссылку (второе значение — true).
+func (s *service) InviteUser(ctx context.Context, invitedBy int, req models.InviteUserRequest) (*models.User, bool, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	log.Printf("➡️ Приглашение пользователя: email=%s invited_by=%d", email, invitedBy)
+
+	existing, err := s.storage.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repoUser.ErrUserNotFound) {
+		log.Printf("❌ GetUserByEmail error: %v", err)
+		return nil, false, err
+	}
+	if existing != nil && existing.IsVerified {
+		return existing, false, ErrUserAlreadyExists
+	}
+	if existing != nil && existing.DeactivatedAt != nil {
+		// Деактивированный аккаунт возвращается только через reactivate, а не новой ссылкой
+		log.Printf("⚠️ Пользователь %d деактивирован, приглашение не отправлено", existing.ID)
+		return nil, false, ErrAccountInactive
+	}
+
+	roleID, err := s.resolveRole(ctx, req.RoleID, req.RoleName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing != nil {
+		return s.reinvite(ctx, existing.ID, invitedBy, roleID, req)
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:      email,
+		FirstName:  strings.TrimSpace(req.FirstName),
+		LastName:   strings.TrimSpace(req.LastName),
+		RoleID:     roleID,
+		Department: strings.TrimSpace(req.Department),
+		Language:   req.Language,
+		InvitedBy:  &invitedBy,
+		InvitedAt:  &now,
+		IsVerified: false,
+		CreatedAt:  now,
+		ChangedAt:  now,
+	}
+	// Аккаунт и письмо-приглашение сохраняются одной транзакцией
+	if err := s.verificationSvc.CreateUserWithVerification(ctx, user, mail.TemplateInvite, s.inviteTTL); err != nil {
+		log.Printf("❌ CreateUser error: %v", err)
+		if err.Error() == "user already exists" {
+			return nil, false, ErrUserAlreadyExists
+		}
+		return nil, false, fmt.Errorf("создание пользователя: %w", err)
+	}
+	log.Printf("✅ Приглашённый пользователь создан: id=%d email=%s role_id=%d", user.ID, user.Email, roleID)
+
+	s.generateAvatarAsync(user.ID)
+
+	return user, false, nil
+}
+
+// reinvite применяет роль и данные нового приглашения к неподтверждённому аккаунту и перевыпускает ссылку
+func (s *service) reinvite(ctx context.Context, userID, invitedBy, roleID int, req models.InviteUserRequest) (*models.User, bool, error) {
+	err := s.storage.UpdateInvitedUser(ctx, &models.User{
+		ID:         userID,
+		FirstName:  strings.TrimSpace(req.FirstName),
+		LastName:   strings.TrimSpace(req.LastName),
+		RoleID:     roleID,
+		Department: strings.TrimSpace(req.Department),
+		Language:   req.Language,
+		InvitedBy:  &invitedBy,
+	})
+	if errors.Is(err, repoUser.ErrUserNotFound) {
+		// Пользователь успел подтвердить email или был деактивирован
+		return nil, false, ErrUserAlreadyExists
+	}
+	if err != nil {
+		log.Printf("❌ UpdateInvitedUser error: %v", err)
+		return nil, false, err
+	}
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.sendInvitation(ctx, user); err != nil {
+		return user, true, err
+	}
+	log.Printf("✅ Приглашение отправлено повторно: user_id=%d role_id=%d", user.ID, roleID)
+	return user, true, nil
+}
+
+func (s *service) sendInvitation(ctx context.Context, user *models.User) error {
+	if err := s.verificationSvc.CreateInvitation(ctx, user.ID, user.Email, user.FirstName, user.Language, s.inviteTTL); err != nil {
+		log.Printf("❌ Не удалось отправить приглашение user_id=%d: %v", user.ID, err)
+		return ErrInvitationEmailFailed
+	}
+	return nil
+}
+
+// resolveRole возвращает роль по id или названию; без указания — роль по умолчанию
+func (s *service) resolveRole(ctx context.Context, roleID int, roleName string) (int, error) {
+	if roleID != 0 {
+		exists, err := s.storage.RoleExists(ctx, roleID)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			log.Printf("⚠️ Роль id=%d не найдена", roleID)
+			return 0, ErrUnknownRole
+		}
+		return roleID, nil
+	}
+	roleName = strings.TrimSpace(roleName)
+	if roleName == "" {
+		return defaultRoleID, nil
+	}
+	rid, err := s.storage.GetRoleIDByName(ctx, roleName)
+	if err != nil {
+		log.Printf("⚠️ Роль '%s' не найдена: %v", roleName, err)
+		return 0, ErrUnknownRole
+	}
+	return rid, nil
+}
+
+// ParseInviteCSV разбирает CSV приглашений. Первая строка — заголовок с колонками
+// name (или first_name + last_name), email, role, department, language; допускаются русские названия колонок
+// и разделитель ";" (выгрузка из Excel).
+func ParseInviteCSV(r io.Reader) ([]models.InviteUserRequest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff") // BOM из Excel
+
+	reader := csv.NewReader(strings.NewReader(text))
+	firstLine, _, _ := strings.Cut(text, "\n")
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidInviteCSV
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "name", "full_name", "имя", "фио":
+			columns["name"] = i
+		case "first_name":
+			columns["first_name"] = i
+		case "last_name", "фамилия":
+			columns["last_name"] = i
+		case "email", "e-mail", "почта":
+			columns["email"] = i
+		case "role", "role_id", "роль":
+			columns["role"] = i
+		case "department", "отдел":
+			columns["department"] = i
+		case "language", "lang", "язык":
+			columns["language"] = i
+		}
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, ErrInvalidInviteCSV
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []models.InviteUserRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidInviteCSV
+		}
+		if len(rows) >= maxInviteRows {
+			return nil, ErrInvalidInviteCSV
+		}
+
+		row := models.InviteUserRequest{
+			Email:      field(record, "email"),
+			FirstName:  field(record, "first_name"),
+			LastName:   field(record, "last_name"),
+			Department: field(record, "department"),
+			Language:   strings.ToLower(field(record, "language")),
+		}
+		// "Иван Петров" → имя и фамилия
+		if full := field(record, "name"); full != "" && row.FirstName == "" {
+			parts := strings.Fields(full)
+			row.FirstName = parts[0]
+			if row.LastName == "" && len(parts) > 1 {
+				row.LastName = strings.Join(parts[1:], " ")
+			}
+		}
+		if role := field(record, "role"); role != "" {
+			if id, err := strconv.Atoi(role); err == nil {
+				row.RoleID = id
+			} else {
+				row.RoleName = role
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
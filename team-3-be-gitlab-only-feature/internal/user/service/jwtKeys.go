@@ -0,0 +1,440 @@
+package service
+
+import (
+	"cmd/internal/eventbus"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SigningAlgEdDSA = "EdDSA"
+	SigningAlgRS256 = "RS256"
+
+	encryptedKeyPrefix = "enc:"
+)
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// KeyManagerConfig — параметры ротации ключей подписи
+type KeyManagerConfig struct {
+	Algorithm        string        // EdDSA (по умолчанию) или RS256
+	RotationInterval time.Duration // как часто выпускать новый ключ
+	GracePeriod      time.Duration // сколько принимать токены, подписанные выведенным ключом
+	EncryptionSecret string        // если задан, приватные ключи хранятся в базе зашифрованными
+	Events           eventbus.Bus  // оповещение других инстансов о ротации; nil — ключи подхватываются при проверке
+}
+
+// topicSigningKeys — набор ключей подписи изменился, его нужно перечитать
+const topicSigningKeys = "user.signing_keys"
+
+// signingKey — ключ подписи в памяти
+type signingKey struct {
+	kid       string
+	algorithm string
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+	retiredAt *time.Time
+	expiresAt *time.Time
+}
+
+// KeyManager хранит набор ключей подписи JWT: один активный для подписи и выведенные на период grace для проверки.
+// Ключи лежат в базе, поэтому все инстансы подписывают и проверяют одинаковым набором.
+type KeyManager struct {
+	mu         sync.RWMutex
+	storage    repoUser.SigningKeyStorage
+	cfg        KeyManagerConfig
+	keys       map[string]*signingKey
+	active     *signingKey
+	lastReload time.Time
+}
+
+// NewKeyManager создает менеджер ключей; перед использованием нужно вызвать Init
+func NewKeyManager(storage repoUser.SigningKeyStorage, cfg KeyManagerConfig) *KeyManager {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = SigningAlgEdDSA
+	}
+	if cfg.RotationInterval <= 0 {
+		cfg.RotationInterval = 30 * 24 * time.Hour
+	}
+	if cfg.GracePeriod <= 0 {
+		cfg.GracePeriod = 7 * 24 * time.Hour
+	}
+	m := &KeyManager{
+		storage: storage,
+		cfg:     cfg,
+		keys:    make(map[string]*signingKey),
+	}
+	if cfg.Events != nil {
+		cfg.Events.Subscribe(topicSigningKeys, m.reloadAsync)
+		cfg.Events.Subscribe(eventbus.TopicResync, m.reloadAsync)
+	}
+	return m
+}
+
+// reloadAsync — обработчик шины: перечитывание ходит в базу, поэтому не задерживает слушателя
+func (m *KeyManager) reloadAsync(eventbus.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := m.Reload(ctx); err != nil {
+			log.Printf("⚠️ Ошибка перечитывания ключей подписи: %v", err)
+		}
+	}()
+}
+
+// Init загружает ключи из базы и выпускает первый ключ, если их ещё нет
+func (m *KeyManager) Init(ctx context.Context) error {
+	if m.cfg.Algorithm != SigningAlgEdDSA && m.cfg.Algorithm != SigningAlgRS256 {
+		return fmt.Errorf("неподдерживаемый алгоритм подписи: %s", m.cfg.Algorithm)
+	}
+	if err := m.Reload(ctx); err != nil {
+		return err
+	}
+	return m.RotateIfDue(ctx)
+}
+
+// Reload перечитывает ключи из базы (подхватывает ротацию, выполненную другим инстансом)
+func (m *KeyManager) Reload(ctx context.Context) error {
+	records, err := m.storage.ListSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*signingKey, len(records))
+	var active *signingKey
+	for _, rec := range records {
+		key, err := m.decodeKey(rec)
+		if err != nil {
+			log.Printf("⚠️ Ключ подписи %s пропущен: %v", rec.KID, err)
+			continue
+		}
+		keys[key.kid] = key
+		// Записи отсортированы от новых к старым: активный — самый новый невыведенный
+		if active == nil && key.retiredAt == nil {
+			active = key
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.active = active
+	m.lastReload = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// RotateIfDue выпускает новый ключ, если активного нет или он старше RotationInterval.
+// Срок проверяется повторно под блокировкой в базе: другой инстанс мог уже выпустить ключ.
+func (m *KeyManager) RotateIfDue(ctx context.Context) error {
+	m.mu.RLock()
+	active := m.active
+	m.mu.RUnlock()
+
+	if active != nil && time.Since(active.createdAt) < m.cfg.RotationInterval {
+		return nil
+	}
+	return m.rotate(ctx, time.Now().Add(-m.cfg.RotationInterval))
+}
+
+// Rotate выпускает новый активный ключ; прежние продолжают проверять токены ещё GracePeriod
+func (m *KeyManager) Rotate(ctx context.Context) error {
+	return m.rotate(ctx, time.Now())
+}
+
+// rotate выпускает ключ, если в базе нет активного ключа, созданного после notBefore
+func (m *KeyManager) rotate(ctx context.Context, notBefore time.Time) error {
+	rec, err := m.generateKey()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	rotated, err := m.storage.RotateSigningKey(ctx, rec, notBefore, now, now.Add(m.cfg.GracePeriod))
+	if err != nil {
+		return err
+	}
+	if !rotated {
+		log.Printf("🔑 Ключ подписи уже выпущен другим инстансом, перечитываем")
+		return m.Reload(ctx)
+	}
+
+	if _, err := m.storage.DeleteExpiredSigningKeys(ctx, time.Now()); err != nil {
+		log.Printf("⚠️ Не удалось удалить истекшие ключи подписи: %v", err)
+	}
+
+	log.Printf("🔑 Выпущен новый ключ подписи JWT: kid=%s alg=%s", rec.KID, rec.Algorithm)
+	if err := m.Reload(ctx); err != nil {
+		return err
+	}
+	if m.cfg.Events != nil {
+		if err := m.cfg.Events.Publish(ctx, topicSigningKeys, rec.KID); err != nil {
+			log.Printf("⚠️ Ротация ключа не разослана другим инстансам: %v", err)
+		}
+	}
+	return nil
+}
+
+// Run периодически перечитывает ключи и выполняет плановую ротацию, пока не отменён ctx
+func (m *KeyManager) Run(ctx context.Context, checkInterval time.Duration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Reload(ctx); err != nil {
+				log.Printf("⚠️ Ошибка перечитывания ключей подписи: %v", err)
+				continue
+			}
+			if err := m.RotateIfDue(ctx); err != nil {
+				log.Printf("❌ Ошибка ротации ключа подписи: %v", err)
+			}
+		}
+	}
+}
+
+// activeKey возвращает ключ для подписи новых токенов
+func (m *KeyManager) activeKey() (*signingKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.active == nil {
+		return nil, errors.New("нет активного ключа подписи")
+	}
+	return m.active, nil
+}
+
+// lookup ищет ключ проверки по kid. Неизвестный kid может означать ротацию на другом инстансе —
+// тогда ключи перечитываются, но не чаще раза в 10 секунд.
+func (m *KeyManager) lookup(kid string) (*signingKey, error) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	lastReload := m.lastReload
+	m.mu.RUnlock()
+
+	if !ok && time.Since(lastReload) > 10*time.Second {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := m.Reload(ctx); err != nil {
+			log.Printf("⚠️ Ошибка перечитывания ключей подписи: %v", err)
+		}
+		m.mu.RLock()
+		key, ok = m.keys[kid]
+		m.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	if key.expiresAt != nil && time.Now().After(*key.expiresAt) {
+		return nil, ErrUnknownSigningKey
+	}
+	return key, nil
+}
+
+// ---------------------- JWKS ----------------------
+
+// JWK — публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet — содержимое /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные ключи всех неистекших ключей, включая выведенные на период grace
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	now := time.Now()
+	for _, key := range m.keys {
+		if key.expiresAt != nil && now.After(*key.expiresAt) {
+			continue
+		}
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.algorithm}
+		switch pub := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// ---------------------- генерация и сериализация ----------------------
+
+func (m *KeyManager) generateKey() (repoUser.SigningKey, error) {
+	var signer crypto.Signer
+	switch m.cfg.Algorithm {
+	case SigningAlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return repoUser.SigningKey{}, fmt.Errorf("ошибка генерации RSA ключа: %w", err)
+		}
+		signer = key
+	default:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return repoUser.SigningKey{}, fmt.Errorf("ошибка генерации Ed25519 ключа: %w", err)
+		}
+		signer = key
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return repoUser.SigningKey{}, fmt.Errorf("ошибка сериализации приватного ключа: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return repoUser.SigningKey{}, fmt.Errorf("ошибка сериализации публичного ключа: %w", err)
+	}
+
+	private, err := m.sealPrivateKey(privDER)
+	if err != nil {
+		return repoUser.SigningKey{}, err
+	}
+
+	// kid — отпечаток публичного ключа, стабилен и не раскрывает ничего лишнего
+	sum := sha256.Sum256(pubDER)
+
+	return repoUser.SigningKey{
+		KID:        hex.EncodeToString(sum[:8]),
+		Algorithm:  m.cfg.Algorithm,
+		PrivateKey: private,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func (m *KeyManager) decodeKey(rec repoUser.SigningKey) (*signingKey, error) {
+	privDER, err := m.openPrivateKey(rec.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(privDER)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора приватного ключа: %w", err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("ключ не поддерживает подпись")
+	}
+
+	switch signer.(type) {
+	case ed25519.PrivateKey:
+		if rec.Algorithm != SigningAlgEdDSA {
+			return nil, fmt.Errorf("алгоритм %s не соответствует ключу Ed25519", rec.Algorithm)
+		}
+	case *rsa.PrivateKey:
+		if rec.Algorithm != SigningAlgRS256 {
+			return nil, fmt.Errorf("алгоритм %s не соответствует ключу RSA", rec.Algorithm)
+		}
+	default:
+		return nil, errors.New("неподдерживаемый тип ключа")
+	}
+
+	return &signingKey{
+		kid:       rec.KID,
+		algorithm: rec.Algorithm,
+		private:   signer,
+		public:    signer.Public(),
+		createdAt: rec.CreatedAt,
+		retiredAt: rec.RetiredAt,
+		expiresAt: rec.ExpiresAt,
+	}, nil
+}
+
+// sealPrivateKey шифрует PKCS#8 AES-GCM, если задан EncryptionSecret, иначе сохраняет PEM
+func (m *KeyManager) sealPrivateKey(der []byte) (string, error) {
+	if m.cfg.EncryptionSecret == "" {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	}
+
+	gcm, err := m.keyCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, der, nil)
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (m *KeyManager) openPrivateKey(stored string) ([]byte, error) {
+	if !strings.HasPrefix(stored, encryptedKeyPrefix) {
+		block, _ := pem.Decode([]byte(stored))
+		if block == nil {
+			return nil, errors.New("повреждённый PEM приватного ключа")
+		}
+		return block.Bytes, nil
+	}
+
+	if m.cfg.EncryptionSecret == "" {
+		return nil, errors.New("ключ зашифрован, но signing_key_secret не задан")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("повреждённый зашифрованный ключ: %w", err)
+	}
+	gcm, err := m.keyCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("повреждённый зашифрованный ключ")
+	}
+	der, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось расшифровать ключ: %w", err)
+	}
+	return der, nil
+}
+
+func (m *KeyManager) keyCipher() (cipher.AEAD, error) {
+	sum := sha256.Sum256([]byte(m.cfg.EncryptionSecret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"cmd/internal/user/models"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -10,68 +12,116 @@ import (
 
 // ОДНО ЕДИНСТВЕННОЕ ОПРЕДЕЛЕНИЕ JWTService
 type JWTService struct {
-	secretKey     string
+	keys          *KeyManager
+	legacySecret  string    // HS256-секрет прежней схемы, пусто — токены без kid не принимаются
+	legacyUntil   time.Time // момент перехода на ключи с kid: позже выпущенные HS256-токены поддельные
 	tokenExpiry   time.Duration
 	refreshExpiry time.Duration
 }
 
 // ОДНО ЕДИНСТВЕННОЕ ОПРЕДЕЛЕНИЕ Claims
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
-	RoleID int    `json:"role_id"`
+	UserID       int    `json:"user_id"`
+	Email        string `json:"email"`
+	RoleID       int    `json:"role_id"`
+	TokenVersion int    `json:"ver"`
 	jwt.RegisteredClaims
 }
 
-// NewJWTService — создаёт сервис
-func NewJWTService(secretKey string, accessTTL, refreshTTL time.Duration) *JWTService {
+// NewJWTService — создаёт сервис. Токены подписываются активным ключом keys (EdDSA/RS256) с kid в заголовке.
+// HS256-токены прежней схемы проверяются legacySecret, только если они выпущены не позже legacyUntil,
+// и принимаются не дольше legacyUntil + refreshTTL. Без legacyUntil секрет не используется.
+func NewJWTService(keys *KeyManager, legacySecret string, legacyUntil time.Time, accessTTL, refreshTTL time.Duration) *JWTService {
+	if legacyUntil.IsZero() {
+		legacySecret = ""
+	}
 	return &JWTService{
-		secretKey:     secretKey,
+		keys:          keys,
+		legacySecret:  legacySecret,
+		legacyUntil:   legacyUntil,
 		tokenExpiry:   accessTTL,
 		refreshExpiry: refreshTTL,
 	}
 }
 
-// GenerateJWTToken — access token
-func (j *JWTService) GenerateJWTToken(userID int, email string, roleID int) (string, error) {
+// JWKS — публичные ключи для проверки токенов другими сервисами
+func (j *JWTService) JWKS() JWKSet {
+	return j.keys.JWKS()
+}
+
+// sign подписывает claims активным ключом
+func (j *JWTService) sign(claims *Claims) (string, error) {
+	key, err := j.keys.activeKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.algorithm), claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// GenerateJWTToken — access token, tokenVersion сверяется AuthMiddleware с users.token_version
+func (j *JWTService) GenerateJWTToken(userID int, email string, roleID int, tokenVersion int) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		RoleID: roleID,
+		UserID:       userID,
+		Email:        email,
+		RoleID:       roleID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.tokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   email,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.sign(claims)
 }
 
 // GenerateRefreshToken — refresh token
 func (j *JWTService) GenerateRefreshToken(userID int, email string, roleID int) (string, error) {
+	// jti делает каждый refresh токен уникальным, даже если два токена выпущены в одну секунду
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", fmt.Errorf("generate jti: %w", err)
+	}
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
 		RoleID: roleID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(jti),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.refreshExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   fmt.Sprintf("refresh_%s", email),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.sign(claims)
 }
 
 // ValidateToken — ВАЖНО! ЭТОТ МЕТОД ДОЛЖЕН БЫТЬ ЗДЕСЬ!
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if token.Method != jwt.SigningMethodHS256 {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// Токен прежней схемы (HS256 без kid)
+			if j.legacySecret == "" || token.Method != jwt.SigningMethodHS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			if err := j.checkLegacy(token.Claims.(*Claims)); err != nil {
+				return nil, err
+			}
+			return []byte(j.legacySecret), nil
+		}
+
+		key, err := j.keys.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Алгоритм берём из ключа, а не из заголовка токена
+		if token.Method.Alg() != key.algorithm {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.secretKey), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{SigningAlgEdDSA, SigningAlgRS256, jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, fmt.Errorf("parse token: %w", err)
@@ -86,6 +136,18 @@ func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// checkLegacy ограничивает токены прежней схемы: выпущенные до перехода и не дольше срока refresh токена.
+// Секрет прежней схемы мог утечь, поэтому более поздний токен с ним считается поддельным.
+func (j *JWTService) checkLegacy(claims *Claims) error {
+	if claims.IssuedAt == nil || claims.IssuedAt.Time.After(j.legacyUntil) {
+		return fmt.Errorf("legacy token issued after cutoff")
+	}
+	if time.Now().After(j.legacyUntil.Add(j.refreshExpiry)) {
+		return fmt.Errorf("legacy tokens are no longer accepted")
+	}
+	return nil
+}
+
 // GetUserFromToken — возвращает пользователя из токена
 func (j *JWTService) GetUserFromToken(tokenString string) (*models.User, error) {
 	claims, err := j.ValidateToken(tokenString)
@@ -93,9 +155,10 @@ func (j *JWTService) GetUserFromToken(tokenString string) (*models.User, error)
 		return nil, err
 	}
 	return &models.User{
-		ID:       claims.UserID,
-		Email:    claims.Email,
-		RoleID:   claims.RoleID,
-		RoleName: "",
+		ID:           claims.UserID,
+		Email:        claims.Email,
+		RoleID:       claims.RoleID,
+		RoleName:     "",
+		TokenVersion: claims.TokenVersion,
 	}, nil
 }
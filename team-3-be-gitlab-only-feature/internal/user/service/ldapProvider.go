@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ExternalUser — учётная запись из внешнего каталога (LDAP/AD) в виде, понятном сервису пользователей
+type ExternalUser struct {
+	Subject   string // неизменяемый идентификатор в каталоге (objectGUID / entryUUID)
+	Email     string
+	FirstName string
+	LastName  string
+	Groups    []string
+	RoleID    int  // роль по группам каталога; 0 — ни одна группа не сопоставлена
+	Disabled  bool // учётная запись отключена в каталоге
+}
+
+// ExternalAuthenticator — внешний провайдер проверки пароля, подключаемый к LoginUser после bcrypt
+type ExternalAuthenticator interface {
+	Name() string
+	// Authenticate возвращает ErrInvalidCredentials, если пользователя нет в каталоге или пароль неверный
+	Authenticate(ctx context.Context, email, password string) (*ExternalUser, error)
+}
+
+// DirectorySource — каталог, из которого синхронизируются пользователи
+type DirectorySource interface {
+	Name() string
+	ListUsers(ctx context.Context) ([]ExternalUser, error)
+}
+
+// LDAPConfig — подключение к LDAP / Active Directory
+type LDAPConfig struct {
+	URL                string // ldap://dc.corp.local:389 или ldaps://dc.corp.local:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string // сервисная учётная запись для поиска
+	BindPassword       string
+	BaseDN             string
+	// UserFilter — поиск пользователя при входе, %s заменяется экранированным email
+	UserFilter string
+	// SyncFilter — выборка пользователей для синхронизации
+	SyncFilter string
+
+	SubjectAttr   string
+	EmailAttr     string
+	FirstNameAttr string
+	LastNameAttr  string
+	GroupAttr     string
+
+	// GroupRoles — группа каталога (CN или полный DN) → role_id
+	GroupRoles map[string]int
+	Timeout    time.Duration
+}
+
+// LDAPProvider проверяет пароль через bind и отдаёт список пользователей каталога для синхронизации
+type LDAPProvider struct {
+	cfg LDAPConfig
+}
+
+// NewLDAPProvider создает провайдер; значения по умолчанию рассчитаны на Active Directory
+func NewLDAPProvider(cfg LDAPConfig) *LDAPProvider {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(&(objectCategory=person)(objectClass=user)(mail=%s))"
+	}
+	if cfg.SyncFilter == "" {
+		cfg.SyncFilter = "(&(objectCategory=person)(objectClass=user)(mail=*))"
+	}
+	if cfg.SubjectAttr == "" {
+		cfg.SubjectAttr = "objectGUID"
+	}
+	if cfg.EmailAttr == "" {
+		cfg.EmailAttr = "mail"
+	}
+	if cfg.FirstNameAttr == "" {
+		cfg.FirstNameAttr = "givenName"
+	}
+	if cfg.LastNameAttr == "" {
+		cfg.LastNameAttr = "sn"
+	}
+	if cfg.GroupAttr == "" {
+		cfg.GroupAttr = "memberOf"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &LDAPProvider{cfg: cfg}
+}
+
+func (p *LDAPProvider) Name() string {
+	return "ldap"
+}
+
+// Authenticate ищет пользователя сервисной учёткой и проверяет пароль bind'ом от его DN
+func (p *LDAPProvider) Authenticate(ctx context.Context, email, password string) (*ExternalUser, error) {
+	// Пустой пароль в LDAP — анонимный bind, который сервер считает успешным
+	if strings.TrimSpace(password) == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	filter := fmt.Sprintf(p.cfg.UserFilter, ldap.EscapeFilter(email))
+	res, err := conn.Search(p.searchRequest(filter))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска в LDAP: %w", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	entry := res.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ошибка проверки пароля в LDAP: %w", err)
+	}
+
+	user := p.toExternalUser(entry)
+	if user.Disabled {
+		return nil, ErrAccountInactive
+	}
+	return &user, nil
+}
+
+// ListUsers выгружает пользователей каталога постранично
+func (p *LDAPProvider) ListUsers(ctx context.Context) ([]ExternalUser, error) {
+	conn, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res, err := conn.SearchWithPaging(p.searchRequest(p.cfg.SyncFilter), 500)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выгрузки пользователей из LDAP: %w", err)
+	}
+
+	users := make([]ExternalUser, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		u := p.toExternalUser(entry)
+		if u.Subject == "" || u.Email == "" {
+			continue
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// connect открывает соединение и выполняет bind сервисной учётной записью
+func (p *LDAPProvider) connect() (*ldap.Conn, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: p.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(p.cfg.URL, ldap.DialWithTLSConfig(tlsCfg))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к LDAP: %w", err)
+	}
+	conn.SetTimeout(p.cfg.Timeout)
+
+	if p.cfg.StartTLS {
+		if err := conn.StartTLS(tlsCfg); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ошибка StartTLS: %w", err)
+		}
+	}
+
+	if p.cfg.BindDN != "" {
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ошибка bind сервисной учётной записи LDAP: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (p *LDAPProvider) searchRequest(filter string) *ldap.SearchRequest {
+	return ldap.NewSearchRequest(
+		p.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(p.cfg.Timeout.Seconds()), false,
+		filter,
+		[]string{p.cfg.SubjectAttr, p.cfg.EmailAttr, p.cfg.FirstNameAttr, p.cfg.LastNameAttr, p.cfg.GroupAttr, "userAccountControl"},
+		nil,
+	)
+}
+
+func (p *LDAPProvider) toExternalUser(entry *ldap.Entry) ExternalUser {
+	u := ExternalUser{
+		Email:     strings.ToLower(strings.TrimSpace(entry.GetAttributeValue(p.cfg.EmailAttr))),
+		FirstName: entry.GetAttributeValue(p.cfg.FirstNameAttr),
+		LastName:  entry.GetAttributeValue(p.cfg.LastNameAttr),
+		Groups:    entry.GetAttributeValues(p.cfg.GroupAttr),
+	}
+
+	// objectGUID в AD бинарный, entryUUID в OpenLDAP — строка
+	if raw := entry.GetRawAttributeValue(p.cfg.SubjectAttr); len(raw) == 16 && strings.EqualFold(p.cfg.SubjectAttr, "objectGUID") {
+		u.Subject = hex.EncodeToString(raw)
+	} else {
+		u.Subject = entry.GetAttributeValue(p.cfg.SubjectAttr)
+	}
+
+	// Бит ACCOUNTDISABLE (0x2) в userAccountControl
+	if uac, err := strconv.Atoi(entry.GetAttributeValue("userAccountControl")); err == nil && uac&0x2 != 0 {
+		u.Disabled = true
+	}
+
+	u.RoleID = p.roleFromGroups(u.Groups)
+	return u
+}
+
+// roleFromGroups сопоставляет группы роли; при нескольких совпадениях — самая привилегированная (меньший id)
+func (p *LDAPProvider) roleFromGroups(groups []string) int {
+	best := 0
+	for _, group := range groups {
+		roleID, ok := p.cfg.GroupRoles[group]
+		if !ok {
+			roleID, ok = p.cfg.GroupRoles[groupCN(group)]
+		}
+		if ok && (best == 0 || roleID < best) {
+			best = roleID
+		}
+	}
+	return best
+}
+
+// groupCN достаёт CN из DN группы: CN=DocHub Admins,OU=Groups,DC=corp → DocHub Admins
+func groupCN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 {
+		return dn
+	}
+	for _, attr := range parsed.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "CN") {
+			return attr.Value
+		}
+	}
+	return dn
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOIDCDisabled         = errors.New("oidc login is disabled")
+	ErrOIDCStateInvalid     = errors.New("invalid or expired oidc state")
+	ErrOIDCAuthFailed       = errors.New("oidc authentication failed")
+	ErrOIDCEmailNotVerified = errors.New("oidc email is not verified")
+	ErrAccountInactive      = errors.New("account is inactive")
+)
+
+// SSOConfig — вход через корпоративный OIDC провайдер
+type SSOConfig struct {
+	Provider *OIDCProvider
+	// Name — идентификатор провайдера в user_identities
+	Name string
+	// GroupsClaim — claim со списком групп, поддерживается путь через точку (realm_access.roles)
+	GroupsClaim string
+	// GroupRoles — соответствие группы провайдера роли портала
+	GroupRoles map[string]int
+	// DefaultRoleID — роль для новых пользователей, если ни одна группа не сопоставлена
+	DefaultRoleID int
+	// StateTTL — сколько ждём возврата пользователя от провайдера
+	StateTTL time.Duration
+}
+
+func (c *SSOConfig) withDefaults() *SSOConfig {
+	if c == nil || c.Provider == nil {
+		return nil
+	}
+	out := *c
+	if out.Name == "" {
+		out.Name = "oidc"
+	}
+	if out.GroupsClaim == "" {
+		out.GroupsClaim = "groups"
+	}
+	if out.DefaultRoleID == 0 {
+		out.DefaultRoleID = defaultRoleID
+	}
+	if out.StateTTL <= 0 {
+		out.StateTTL = 10 * time.Minute
+	}
+	return &out
+}
+
+func (s *service) OIDCEnabled() bool {
+	return s.sso != nil
+}
+
+// BeginOIDCLogin сохраняет state/nonce/PKCE и возвращает адрес страницы входа провайдера
+func (s *service) BeginOIDCLogin(ctx context.Context, redirectTo string) (string, error) {
+	if s.sso == nil {
+		return "", ErrOIDCDisabled
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := NewPKCEVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.storage.DeleteExpiredOIDCLoginStates(ctx); err != nil {
+		log.Printf("⚠️ Не удалось очистить устаревшие состояния OIDC: %v", err)
+	}
+
+	if err := s.storage.SaveOIDCLoginState(ctx, repoUser.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectTo:   redirectTo,
+		ExpiresAt:    time.Now().Add(s.sso.StateTTL),
+	}); err != nil {
+		log.Printf("❌ SaveOIDCLoginState error: %v", err)
+		return "", err
+	}
+
+	authURL, err := s.sso.Provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Printf("❌ OIDC AuthCodeURL error: %v", err)
+		return "", err
+	}
+	return authURL, nil
+}
+
+// CompleteOIDCLogin обрабатывает callback провайдера: проверяет state и id_token,
+// находит или создаёт пользователя (JIT), синхронизирует роль по группам и выдаёт токены портала.
+// Вторым значением возвращается путь, на который пользователь хотел попасть.
+func (s *service) CompleteOIDCLogin(ctx context.Context, state, code string) (*models.AuthResponse, string, error) {
+	if s.sso == nil {
+		return nil, "", ErrOIDCDisabled
+	}
+
+	st, err := s.storage.ConsumeOIDCLoginState(ctx, state)
+	if err != nil {
+		log.Printf("⚠️ OIDC state не найден: %v", err)
+		return nil, "", ErrOIDCStateInvalid
+	}
+	if time.Now().After(st.ExpiresAt) {
+		log.Printf("⚠️ OIDC state истёк")
+		return nil, "", ErrOIDCStateInvalid
+	}
+
+	tokens, err := s.sso.Provider.Exchange(ctx, code, st.CodeVerifier)
+	if err != nil {
+		log.Printf("❌ OIDC обмен кода: %v", err)
+		return nil, "", ErrOIDCAuthFailed
+	}
+	claims, err := s.sso.Provider.VerifyIDToken(ctx, tokens.IDToken, st.Nonce)
+	if err != nil {
+		log.Printf("❌ OIDC проверка id_token: %v", err)
+		return nil, "", ErrOIDCAuthFailed
+	}
+	log.Printf("➡️ Вход через SSO: sub=%s email=%s", claims.Subject, claims.Email)
+
+	user, err := s.resolveOIDCUser(ctx, claims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.storage.LinkIdentity(ctx, repoUser.UserIdentity{
+		UserID:   user.ID,
+		Provider: s.sso.Name,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		log.Printf("❌ LinkIdentity error: %v", err)
+		return nil, "", err
+	}
+
+	user, err = s.syncOIDCRole(ctx, user, claims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID, user.TokenVersion)
+	if err != nil {
+		log.Printf("❌ GenerateJWTToken error: %v", err)
+		return nil, "", fmt.Errorf("генерация access token: %w", err)
+	}
+	refresh, err := s.issueRefreshToken(ctx, user, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	if user.PhotoPath != "" && s.s3Storage != nil {
+		if key := s.extractS3Key(user.PhotoPath); key != "" {
+			if presignedURL, err := s.s3Storage.GenerateDownloadURL(key); err == nil {
+				user.PhotoPath = presignedURL
+			}
+		}
+	}
+
+	log.Printf("✅ Успешный вход через SSO: user_id=%d", user.ID)
+	return &models.AuthResponse{
+		User:         user,
+		AccessToken:  access,
+		RefreshToken: refresh,
+		Verified:     true,
+		Message:      "Успешный вход.",
+	}, st.RedirectTo, nil
+}
+
+// resolveOIDCUser ищет пользователя по привязке, затем по подтверждённому email; иначе создаёт нового
+func (s *service) resolveOIDCUser(ctx context.Context, claims *OIDCClaims) (*models.User, error) {
+	userID, found, err := s.storage.FindIdentity(ctx, s.sso.Name, claims.Subject)
+	if err != nil {
+		log.Printf("❌ FindIdentity error: %v", err)
+		return nil, err
+	}
+	if found {
+		return s.activeUser(ctx, userID)
+	}
+
+	if claims.Email == "" {
+		log.Printf("⚠️ SSO: провайдер не передал email для sub=%s", claims.Subject)
+		return nil, ErrOIDCEmailNotVerified
+	}
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+
+	existing, err := s.storage.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repoUser.ErrUserNotFound) {
+		// Сбой базы — не повод создавать второй аккаунт с тем же email
+		log.Printf("❌ SSO GetUserByEmail error: %v", err)
+		return nil, err
+	}
+	if err == nil {
+		// Привязываем существующий аккаунт только по подтверждённому у провайдера email,
+		// иначе можно было бы войти в чужой аккаунт, указав его адрес в профиле IdP
+		if !claims.EmailVerified {
+			log.Printf("⚠️ SSO: email %s не подтверждён провайдером, привязка запрещена", email)
+			return nil, ErrOIDCEmailNotVerified
+		}
+		log.Printf("🔗 SSO: привязка к существующему пользователю id=%d", existing.ID)
+		return s.activeUser(ctx, existing.ID)
+	}
+
+	roleID, ok := s.roleFromGroups(claims)
+	if !ok {
+		roleID = s.sso.DefaultRoleID
+	}
+
+	firstName, lastName := oidcNames(claims, email)
+	user := &models.User{
+		Email:      email,
+		FirstName:  firstName,
+		LastName:   lastName,
+		RoleID:     roleID,
+		IsVerified: true,
+		CreatedAt:  time.Now(),
+		ChangedAt:  time.Now(),
+	}
+	if err := s.storage.CreateUser(ctx, user); err != nil {
+		log.Printf("❌ SSO CreateUser error: %v", err)
+		return nil, fmt.Errorf("создание пользователя: %w", err)
+	}
+	// Email подтверждён провайдером, пароль не нужен — вход только через SSO, пока пользователь его не задаст
+	if err := s.storage.MarkUserAsVerified(ctx, user.ID); err != nil {
+		log.Printf("❌ SSO MarkUserAsVerified error: %v", err)
+		return nil, err
+	}
+	log.Printf("✅ SSO: создан пользователь id=%d email=%s role_id=%d", user.ID, user.Email, user.RoleID)
+
+	s.generateAvatarAsync(user.ID)
+
+	return s.storage.GetUserByID(ctx, user.ID)
+}
+
+// syncOIDCRole приводит роль пользователя к роли из групп провайдера.
+// Если ни одна группа не сопоставлена, роль не трогаем — её мог назначить администратор портала.
+func (s *service) syncOIDCRole(ctx context.Context, user *models.User, claims *OIDCClaims) (*models.User, error) {
+	roleID, ok := s.roleFromGroups(claims)
+	if !ok || roleID == user.RoleID {
+		return user, nil
+	}
+
+	log.Printf("🔄 SSO: смена роли user_id=%d %d → %d по группам провайдера", user.ID, user.RoleID, roleID)
+	if err := s.storage.UpdateUserRole(ctx, user.ID, roleID); err != nil {
+		log.Printf("❌ UpdateUserRole error: %v", err)
+		return nil, err
+	}
+	if err := s.RevokeAccessTokens(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return s.storage.GetUserByID(ctx, user.ID)
+}
+
+// roleFromGroups выбирает роль по группам; при нескольких совпадениях — самую привилегированную (меньший id)
+func (s *service) roleFromGroups(claims *OIDCClaims) (int, bool) {
+	best := 0
+	for _, group := range claims.StringsClaim(s.sso.GroupsClaim) {
+		roleID, ok := s.sso.GroupRoles[group]
+		if !ok {
+			continue
+		}
+		if best == 0 || roleID < best {
+			best = roleID
+		}
+	}
+	return best, best != 0
+}
+
+func (s *service) activeUser(ctx context.Context, userID int) (*models.User, error) {
+	_, active, err := s.storage.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		log.Printf("⚠️ Вход в неактивный аккаунт: user_id=%d", userID)
+		return nil, ErrAccountInactive
+	}
+	return s.storage.GetUserByID(ctx, userID)
+}
+
+// oidcNames берёт имя и фамилию из claims; first_name обязателен, поэтому в крайнем случае — часть email до @
+func oidcNames(claims *OIDCClaims, email string) (string, string) {
+	first, last := claims.GivenName, claims.FamilyName
+	if first == "" && claims.Name != "" {
+		parts := strings.Fields(claims.Name)
+		first = parts[0]
+		if last == "" && len(parts) > 1 {
+			last = strings.Join(parts[1:], " ")
+		}
+	}
+	if first == "" {
+		first = strings.SplitN(email, "@", 2)[0]
+	}
+	return first, last
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка генерации случайного значения: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
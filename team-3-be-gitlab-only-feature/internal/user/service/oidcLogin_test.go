@@ -0,0 +1,521 @@
+package service
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "dochub"
+	testClientSecret = "dochub-secret"
+	testRedirectURL  = "http://dochub.test/api/v1/auth/oidc/callback"
+)
+
+// ---------------------- mock issuer ----------------------
+
+// mockIssuer — минимальный OIDC провайдер: discovery, JWKS и token endpoint с проверкой PKCE
+type mockIssuer struct {
+	t   *testing.T
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]issuedCode
+}
+
+// issuedCode — что провайдер запомнил при выдаче кода
+type issuedCode struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	m := &mockIssuer{t: t, key: key, codes: make(map[string]issuedCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+// authorize — пользователь вошёл у провайдера: код выдаётся под code_challenge и nonce из адреса авторизации
+func (m *mockIssuer) authorize(authURL string, claims jwt.MapClaims) string {
+	m.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("адрес авторизации: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		m.t.Fatalf("адрес авторизации без PKCE S256: %s", authURL)
+	}
+	if q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirectURL {
+		m.t.Fatalf("неверные client_id/redirect_uri: %s", authURL)
+	}
+
+	code, _ := randomToken(8)
+	m.mu.Lock()
+	m.codes[code] = issuedCode{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != testClientID || secret != testClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	issued, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != issued.challenge {
+		http.Error(w, `{"error":"invalid_grant","error_description":"PKCE"}`, http.StatusBadRequest)
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.srv.URL,
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"nonce": issued.nonce,
+	}
+	for k, v := range issued.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": idToken, "token_type": "Bearer"})
+}
+
+// ---------------------- storage ----------------------
+
+// oidcTestStorage — хранилище в памяти для методов, которые использует вход через SSO;
+// остальные методы интерфейса не реализованы и паникуют при вызове
+type oidcTestStorage struct {
+	repoUser.Storage
+
+	mu         sync.Mutex
+	users      map[int]*models.User
+	nextID     int
+	identities map[string]int
+	states     map[string]repoUser.OIDCLoginState
+	keys       []repoUser.SigningKey
+	emailErr   error
+}
+
+func newOIDCTestStorage() *oidcTestStorage {
+	return &oidcTestStorage{
+		users:      make(map[int]*models.User),
+		nextID:     1,
+		identities: make(map[string]int),
+		states:     make(map[string]repoUser.OIDCLoginState),
+	}
+}
+
+func (s *oidcTestStorage) addUser(email string, roleID int) *models.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := &models.User{ID: s.nextID, Email: email, FirstName: "Test", RoleID: roleID, IsVerified: true}
+	s.users[u.ID] = u
+	s.nextID++
+	return u
+}
+
+func (s *oidcTestStorage) CreateUser(ctx context.Context, u *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u.ID = s.nextID
+	s.nextID++
+	cp := *u
+	s.users[u.ID] = &cp
+	return nil
+}
+
+func (s *oidcTestStorage) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return nil, repoUser.ErrUserNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (s *oidcTestStorage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.emailErr != nil {
+		return nil, s.emailErr
+	}
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, email) {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repoUser.ErrUserNotFound
+}
+
+func (s *oidcTestStorage) MarkUserAsVerified(ctx context.Context, userID int) error { return nil }
+
+func (s *oidcTestStorage) UpdateUserRole(ctx context.Context, userID, roleID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID].RoleID = roleID
+	return nil
+}
+
+func (s *oidcTestStorage) GetTokenVersion(ctx context.Context, userID int) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return 0, false, repoUser.ErrUserNotFound
+	}
+	return u.TokenVersion, u.DeactivatedAt == nil, nil
+}
+
+func (s *oidcTestStorage) IncrementTokenVersion(ctx context.Context, userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID].TokenVersion++
+	return s.users[userID].TokenVersion, nil
+}
+
+func (s *oidcTestStorage) RevokeAllPersonalTokens(ctx context.Context, userID int) (int64, error) {
+	return 0, nil
+}
+
+func (s *oidcTestStorage) SaveRefreshToken(ctx context.Context, rt repoUser.RefreshToken) (int64, error) {
+	return 1, nil
+}
+
+func (s *oidcTestStorage) FindIdentity(ctx context.Context, provider, subject string) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.identities[provider+"|"+subject]
+	return id, ok, nil
+}
+
+func (s *oidcTestStorage) LinkIdentity(ctx context.Context, identity repoUser.UserIdentity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities[identity.Provider+"|"+identity.Subject] = identity.UserID
+	return nil
+}
+
+func (s *oidcTestStorage) SaveOIDCLoginState(ctx context.Context, st repoUser.OIDCLoginState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[st.State] = st
+	return nil
+}
+
+func (s *oidcTestStorage) ConsumeOIDCLoginState(ctx context.Context, state string) (repoUser.OIDCLoginState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[state]
+	if !ok {
+		return st, errors.New("state not found")
+	}
+	delete(s.states, state)
+	return st, nil
+}
+
+func (s *oidcTestStorage) DeleteExpiredOIDCLoginStates(ctx context.Context) error { return nil }
+
+func (s *oidcTestStorage) ListSigningKeys(ctx context.Context) ([]repoUser.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]repoUser.SigningKey(nil), s.keys...), nil
+}
+
+func (s *oidcTestStorage) RotateSigningKey(ctx context.Context, key repoUser.SigningKey, notBefore, retiredAt, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append([]repoUser.SigningKey{key}, s.keys...)
+	return true, nil
+}
+
+func (s *oidcTestStorage) DeleteExpiredSigningKeys(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ---------------------- helpers ----------------------
+
+func newOIDCTestService(t *testing.T, issuer *mockIssuer, st *oidcTestStorage) *service {
+	t.Helper()
+	keys := NewKeyManager(st, KeyManagerConfig{})
+	if err := keys.Init(context.Background()); err != nil {
+		t.Fatalf("KeyManager.Init: %v", err)
+	}
+	return &service{
+		storage:        st,
+		refreshStorage: st,
+		jwt:            NewJWTService(keys, "", time.Time{}, time.Minute, time.Hour),
+		tokenVersions:  NewTokenVersionCache(time.Minute, st.GetTokenVersion, nil),
+		sso: (&SSOConfig{
+			Provider: NewOIDCProvider(OIDCConfig{
+				Issuer:       issuer.srv.URL,
+				ClientID:     testClientID,
+				ClientSecret: testClientSecret,
+				RedirectURL:  testRedirectURL,
+			}),
+			GroupRoles:    map[string]int{"dochub-hr": 2},
+			DefaultRoleID: 4,
+		}).withDefaults(),
+	}
+}
+
+// login проходит весь вход: адрес авторизации → код у провайдера → callback
+func login(t *testing.T, svc *service, issuer *mockIssuer, claims jwt.MapClaims) (*models.AuthResponse, string, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, err := svc.BeginOIDCLogin(ctx, "/documents/7")
+	if err != nil {
+		t.Fatalf("BeginOIDCLogin: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	code := issuer.authorize(authURL, claims)
+	return svc.CompleteOIDCLogin(ctx, u.Query().Get("state"), code)
+}
+
+// ---------------------- tests ----------------------
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+
+	resp, redirectTo, err := login(t, svc, issuer, jwt.MapClaims{
+		"sub":            "sub-1",
+		"email":          "Ivan.Petrov@corp.local",
+		"email_verified": true,
+		"name":           "Иван Петров",
+		"groups":         []string{"dochub-hr"},
+	})
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if redirectTo != "/documents/7" {
+		t.Errorf("redirectTo = %q", redirectTo)
+	}
+	if resp.AccessToken == "" || resp.RefreshToken == "" {
+		t.Error("токены портала не выданы")
+	}
+	if resp.User.Email != "ivan.petrov@corp.local" || resp.User.RoleID != 2 || resp.User.FirstName != "Иван" {
+		t.Errorf("создан пользователь %+v", resp.User)
+	}
+	if id, ok, _ := st.FindIdentity(context.Background(), "oidc", "sub-1"); !ok || id != resp.User.ID {
+		t.Errorf("учётная запись провайдера не привязана: id=%d ok=%t", id, ok)
+	}
+}
+
+func TestOIDCLoginState(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+	ctx := context.Background()
+	claims := jwt.MapClaims{"sub": "sub-1", "email": "a@corp.local", "email_verified": true}
+
+	authURL, err := svc.BeginOIDCLogin(ctx, "")
+	if err != nil {
+		t.Fatalf("BeginOIDCLogin: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	state := u.Query().Get("state")
+
+	if _, _, err := svc.CompleteOIDCLogin(ctx, "forged", issuer.authorize(authURL, claims)); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("чужой state: err = %v", err)
+	}
+	if _, _, err := svc.CompleteOIDCLogin(ctx, state, issuer.authorize(authURL, claims)); err != nil {
+		t.Fatalf("вход: %v", err)
+	}
+	// state одноразовый
+	if _, _, err := svc.CompleteOIDCLogin(ctx, state, issuer.authorize(authURL, claims)); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("повтор state: err = %v", err)
+	}
+
+	// Истёкший state
+	authURL, _ = svc.BeginOIDCLogin(ctx, "")
+	u, _ = url.Parse(authURL)
+	state = u.Query().Get("state")
+	st.mu.Lock()
+	expired := st.states[state]
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	st.states[state] = expired
+	st.mu.Unlock()
+	if _, _, err := svc.CompleteOIDCLogin(ctx, state, issuer.authorize(authURL, claims)); !errors.Is(err, ErrOIDCStateInvalid) {
+		t.Errorf("истёкший state: err = %v", err)
+	}
+}
+
+func TestOIDCLoginNonceMismatch(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+
+	// id_token, выданный для другого входа, не принимается
+	_, _, err := login(t, svc, issuer, jwt.MapClaims{
+		"sub": "sub-1", "email": "a@corp.local", "email_verified": true, "nonce": "other",
+	})
+	if !errors.Is(err, ErrOIDCAuthFailed) {
+		t.Errorf("err = %v, ждём ErrOIDCAuthFailed", err)
+	}
+	if len(st.users) != 0 {
+		t.Error("пользователь создан по чужому id_token")
+	}
+}
+
+func TestOIDCLoginPKCE(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+	ctx := context.Background()
+
+	authURL, _ := svc.BeginOIDCLogin(ctx, "")
+	u, _ := url.Parse(authURL)
+	state := u.Query().Get("state")
+	code := issuer.authorize(authURL, jwt.MapClaims{"sub": "sub-1", "email": "a@corp.local", "email_verified": true})
+
+	// Перехваченный код без исходного code_verifier обменять нельзя
+	st.mu.Lock()
+	stolen := st.states[state]
+	stolen.CodeVerifier, _, _ = NewPKCEVerifier()
+	st.states[state] = stolen
+	st.mu.Unlock()
+
+	if _, _, err := svc.CompleteOIDCLogin(ctx, state, code); !errors.Is(err, ErrOIDCAuthFailed) {
+		t.Errorf("err = %v, ждём ErrOIDCAuthFailed", err)
+	}
+}
+
+func TestOIDCLoginEmailLinking(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+	existing := st.addUser("anna@corp.local", 3)
+
+	// Неподтверждённый у провайдера email не даёт войти в чужой аккаунт
+	_, _, err := login(t, svc, issuer, jwt.MapClaims{"sub": "sub-anna", "email": "anna@corp.local", "email_verified": false})
+	if !errors.Is(err, ErrOIDCEmailNotVerified) {
+		t.Fatalf("неподтверждённый email: err = %v", err)
+	}
+	if _, ok, _ := st.FindIdentity(context.Background(), "oidc", "sub-anna"); ok {
+		t.Fatal("учётная запись привязана по неподтверждённому email")
+	}
+
+	// Без email вход невозможен
+	if _, _, err := login(t, svc, issuer, jwt.MapClaims{"sub": "sub-noemail"}); !errors.Is(err, ErrOIDCEmailNotVerified) {
+		t.Errorf("без email: err = %v", err)
+	}
+
+	// Подтверждённый email привязывается к существующему пользователю, роль без групп не меняется
+	resp, _, err := login(t, svc, issuer, jwt.MapClaims{"sub": "sub-anna", "email": "ANNA@corp.local", "email_verified": "true"})
+	if err != nil {
+		t.Fatalf("подтверждённый email: %v", err)
+	}
+	if resp.User.ID != existing.ID || resp.User.RoleID != 3 {
+		t.Errorf("вход в %+v, ждём пользователя %d с ролью 3", resp.User, existing.ID)
+	}
+
+	// Дальше вход идёт по привязке, даже если email у провайдера сменился
+	resp, _, err = login(t, svc, issuer, jwt.MapClaims{"sub": "sub-anna", "email": "anna.new@corp.local", "email_verified": false})
+	if err != nil || resp.User.ID != existing.ID {
+		t.Errorf("вход по привязке: user=%v err=%v", resp, err)
+	}
+	if len(st.users) != 1 {
+		t.Errorf("пользователей %d, ждём 1", len(st.users))
+	}
+}
+
+func TestOIDCLoginDeactivatedUser(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+	u := st.addUser("gone@corp.local", 4)
+	now := time.Now()
+	u.DeactivatedAt = &now
+
+	_, _, err := login(t, svc, issuer, jwt.MapClaims{"sub": "sub-gone", "email": "gone@corp.local", "email_verified": true})
+	if !errors.Is(err, ErrAccountInactive) {
+		t.Errorf("err = %v, ждём ErrAccountInactive", err)
+	}
+}
+
+func TestOIDCLoginEmailLookupFailure(t *testing.T) {
+	issuer := newMockIssuer(t)
+	st := newOIDCTestStorage()
+	svc := newOIDCTestService(t, issuer, st)
+	dbErr := errors.New("connection refused")
+	st.emailErr = dbErr
+
+	// Сбой базы при поиске по email не должен приводить к созданию второго аккаунта
+	_, _, err := login(t, svc, issuer, jwt.MapClaims{"sub": "sub-1", "email": "a@corp.local", "email_verified": true})
+	if !errors.Is(err, dbErr) {
+		t.Errorf("err = %v, ждём ошибку базы", err)
+	}
+	if len(st.users) != 0 {
+		t.Error("пользователь создан при ошибке поиска по email")
+	}
+}
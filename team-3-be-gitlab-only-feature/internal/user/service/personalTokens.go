@@ -0,0 +1,145 @@
+package service
+
+import (
+	"cmd/internal/user/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// PersonalTokenPrefix отличает персональные токены от JWT в заголовке Authorization
+const PersonalTokenPrefix = "dhp_"
+
+// Области действия персональных токенов
+const (
+	ScopeRead           = "read"            // любые GET запросы
+	ScopeDocumentsWrite = "documents:write" // загрузка документов
+	ScopeCommentsWrite  = "comments:write"  // комментарии, лайки, реакции, просмотры
+	ScopeProfileWrite   = "profile:write"   // профиль и фото
+	ScopeWrite          = "write"           // любые изменяющие запросы, кроме администрирования
+)
+
+var PersonalTokenScopes = []string{ScopeRead, ScopeDocumentsWrite, ScopeCommentsWrite, ScopeProfileWrite, ScopeWrite}
+
+const (
+	maxPersonalTokensPerUser     = 20
+	defaultPersonalTokenLifetime = 90 * 24 * time.Hour
+)
+
+var (
+	ErrPersonalTokenInvalid  = errors.New("invalid or expired personal access token")
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidTokenScope     = errors.New("unknown token scope")
+	ErrTooManyPersonalTokens = errors.New("too many personal access tokens")
+)
+
+// CreatePersonalToken выпускает персональный токен; открытое значение возвращается один раз и нигде не хранится
+func (s *service) CreatePersonalToken(ctx context.Context, userID int, req models.CreatePersonalTokenRequest) (*models.CreatedPersonalToken, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.storage.ListPersonalTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxPersonalTokensPerUser {
+		return nil, ErrTooManyPersonalTokens
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	raw := PersonalTokenPrefix + secret
+
+	lifetime := defaultPersonalTokenLifetime
+	if req.ExpiresInDays > 0 {
+		lifetime = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	expiresAt := time.Now().Add(lifetime)
+
+	token := &models.PersonalToken{
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: HashRefreshToken(raw),
+		Prefix:    raw[:len(PersonalTokenPrefix)+8],
+		Scopes:    scopes,
+		ExpiresAt: &expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.storage.CreatePersonalToken(ctx, token); err != nil {
+		log.Printf("❌ CreatePersonalToken error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("🔑 Персональный токен создан: user_id=%d token_id=%d scopes=%v", userID, token.ID, scopes)
+	return &models.CreatedPersonalToken{PersonalToken: *token, Token: raw}, nil
+}
+
+func (s *service) ListPersonalTokens(ctx context.Context, userID int) ([]*models.PersonalToken, error) {
+	return s.storage.ListPersonalTokens(ctx, userID)
+}
+
+func (s *service) RevokePersonalToken(ctx context.Context, userID, tokenID int) error {
+	ok, err := s.storage.RevokePersonalToken(ctx, userID, tokenID)
+	if err != nil {
+		log.Printf("❌ RevokePersonalToken error: %v", err)
+		return err
+	}
+	if !ok {
+		return ErrPersonalTokenNotFound
+	}
+	log.Printf("🔒 Персональный токен отозван: user_id=%d token_id=%d", userID, tokenID)
+	return nil
+}
+
+// AuthenticateAPIToken проверяет персональный токен и возвращает владельца и области действия
+func (s *service) AuthenticateAPIToken(ctx context.Context, raw string) (*models.User, []string, error) {
+	token, user, err := s.storage.GetPersonalTokenByHash(ctx, HashRefreshToken(raw))
+	if err != nil {
+		return nil, nil, ErrPersonalTokenInvalid
+	}
+	if token.RevokedAt != nil || (token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt)) {
+		return nil, nil, ErrPersonalTokenInvalid
+	}
+
+	// Деактивированный пользователь теряет и персональные токены
+	_, active, err := s.tokenVersions.Get(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !active {
+		return nil, nil, ErrTokenRevoked
+	}
+
+	if err := s.storage.TouchPersonalToken(ctx, token.ID); err != nil {
+		log.Printf("⚠️ TouchPersonalToken error: %v", err)
+	}
+	return user, token.Scopes, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		known := false
+		for _, allowed := range PersonalTokenScopes {
+			if scope == allowed {
+				known = true
+				break
+			}
+		}
+		if !known {
+			log.Printf("⚠️ Неизвестная область действия токена: %s", scope)
+			return nil, ErrInvalidTokenScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
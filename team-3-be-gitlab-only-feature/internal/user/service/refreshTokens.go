@@ -0,0 +1,117 @@
+package service
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// HashRefreshToken возвращает SHA-256 от токена в hex — именно это значение хранится в refresh_tokens
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTokenFamilyID генерирует идентификатор семейства ротации (одно семейство = один вход)
+func newTokenFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка генерации семейства токенов: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// issueRefreshToken выпускает refresh токен и сохраняет его хэш.
+// Пустой familyID означает новый вход — создаётся новое семейство.
+func (s *service) issueRefreshToken(ctx context.Context, user *models.User, familyID string) (string, error) {
+	if s.refreshStorage == nil {
+		log.Printf("❌ refreshStorage is NIL")
+		return "", fmt.Errorf("внутренняя ошибка: хранилище refresh токенов не настроено")
+	}
+
+	if familyID == "" {
+		id, err := newTokenFamilyID()
+		if err != nil {
+			return "", err
+		}
+		familyID = id
+	}
+
+	refresh, err := s.jwt.GenerateRefreshToken(user.ID, user.Email, user.RoleID)
+	if err != nil {
+		log.Printf("❌ GenerateRefreshToken error: %v", err)
+		return "", fmt.Errorf("генерация refresh token: %w", err)
+	}
+
+	expires := time.Now().Add(s.jwt.refreshExpiry)
+	log.Printf("SaveRefreshToken: user_id=%d family=%s expires=%s", user.ID, familyID, expires.Format(time.RFC3339))
+	if _, err := s.refreshStorage.SaveRefreshToken(ctx, repoUser.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: HashRefreshToken(refresh),
+		FamilyID:  familyID,
+		ExpiresAt: expires,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		log.Printf("❌ SaveRefreshToken error: %v", err)
+		return "", fmt.Errorf("сохранение refresh token: %w", err)
+	}
+
+	return refresh, nil
+}
+
+// rotateRefreshToken проверяет предъявленный refresh токен и помечает его использованным.
+// Повторное предъявление уже обменянного токена считается кражей: всё семейство отзывается.
+func (s *service) rotateRefreshToken(ctx context.Context, refreshToken string) (repoUser.RefreshToken, error) {
+	rt, err := s.refreshStorage.GetRefreshToken(ctx, HashRefreshToken(refreshToken))
+	if err != nil {
+		log.Printf("⚠️ Refresh token не найден: %v", err)
+		return repoUser.RefreshToken{}, ErrRefreshTokenInvalid
+	}
+
+	if rt.RevokedAt != nil {
+		log.Printf("⚠️ Предъявлен отозванный refresh token: user_id=%d family=%s", rt.UserID, rt.FamilyID)
+		return repoUser.RefreshToken{}, ErrRefreshTokenInvalid
+	}
+
+	if rt.UsedAt != nil {
+		s.revokeStolenFamily(ctx, rt)
+		return repoUser.RefreshToken{}, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		_ = s.refreshStorage.DeleteRefreshToken(ctx, rt.TokenHash)
+		return repoUser.RefreshToken{}, ErrRefreshTokenInvalid
+	}
+
+	// Гонка: два запроса с одним токеном — второй проигрывает и тоже считается повторным использованием
+	ok, err := s.refreshStorage.MarkRefreshTokenUsed(ctx, rt.ID)
+	if err != nil {
+		return repoUser.RefreshToken{}, err
+	}
+	if !ok {
+		s.revokeStolenFamily(ctx, rt)
+		return repoUser.RefreshToken{}, ErrRefreshTokenReused
+	}
+
+	return rt, nil
+}
+
+func (s *service) revokeStolenFamily(ctx context.Context, rt repoUser.RefreshToken) {
+	log.Printf("🚨 Повторное использование refresh token, подозрение на кражу: user_id=%d family=%s token_id=%d",
+		rt.UserID, rt.FamilyID, rt.ID)
+	if err := s.refreshStorage.RevokeRefreshTokenFamily(ctx, rt.FamilyID); err != nil {
+		log.Printf("❌ Не удалось отозвать семейство refresh токенов %s: %v", rt.FamilyID, err)
+	}
+}
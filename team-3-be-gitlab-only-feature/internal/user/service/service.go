@@ -1,6 +1,8 @@
 package service
 
 import (
+	"cmd/internal/eventbus"
+	"cmd/internal/mail"
 	"cmd/internal/storage"
 	"cmd/internal/user/models"
 	repoUser "cmd/internal/user/repo"
@@ -9,6 +11,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -19,6 +22,9 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrTokenInvalid       = errors.New("invalid or expired verification token")
+	ErrTokenRevoked       = errors.New("access token revoked")
+	// ErrUnsupportedLanguage — язык писем вне списка mail.SupportedLanguages
+	ErrUnsupportedLanguage = errors.New("unsupported language")
 )
 
 type Service interface {
@@ -29,11 +35,19 @@ type Service interface {
 	ConfirmRegistration(ctx context.Context, req ConfirmRegistrationRequest) (*models.AuthResponse, error)
 	SetPassword(ctx context.Context, req SetPasswordRequest) error
 	LoginUser(ctx context.Context, req models.LoginRequest) (*models.AuthResponse, error)
-	DeleteUser(ctx context.Context, userID int) error
+	DeactivateUser(ctx context.Context, actorID, userID, reassignTo int) error
+	ReactivateUser(ctx context.Context, userID int) error
+	DeleteUser(ctx context.Context, actorID, userID, reassignTo int) error
+	ExportUserData(ctx context.Context, userID int) ([]byte, error)
+	RequestEmailChange(ctx context.Context, userID int, req models.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	AnonymizeUser(ctx context.Context, actorID, userID int) (*models.AnonymizeResult, error)
 
 	GetUserByID(ctx context.Context, userID int) (*models.User, error)
 	GetUserProfile(ctx context.Context, userID int) (*models.User, error)
 	GetAllUsers(ctx context.Context) ([]*models.User, error)
+	SearchUsers(ctx context.Context, filter models.UserFilter) (*models.UserPage, error)
+	EmployeeDirectory(ctx context.Context, filter models.UserFilter) (*models.DirectoryPage, error)
 
 	ValidateAccessToken(tokenString string) (int, string, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponse, error)
@@ -43,6 +57,26 @@ type Service interface {
 
 	Logout(ctx context.Context, refreshToken string) error
 	LogoutAll(ctx context.Context, userID int) error
+
+	CheckTokenVersion(ctx context.Context, userID, tokenVersion int) error
+	RevokeAccessTokens(ctx context.Context, userID int) error
+
+	OIDCEnabled() bool
+	BeginOIDCLogin(ctx context.Context, redirectTo string) (string, error)
+	CompleteOIDCLogin(ctx context.Context, state, code string) (*models.AuthResponse, string, error)
+
+	SyncDirectory(ctx context.Context, dryRun bool) (*DirectorySyncReport, error)
+	LastDirectorySyncReport(ctx context.Context) (*DirectorySyncReport, error)
+
+	CreatePersonalToken(ctx context.Context, userID int, req models.CreatePersonalTokenRequest) (*models.CreatedPersonalToken, error)
+	ListPersonalTokens(ctx context.Context, userID int) ([]*models.PersonalToken, error)
+	RevokePersonalToken(ctx context.Context, userID, tokenID int) error
+	AuthenticateAPIToken(ctx context.Context, raw string) (*models.User, []string, error)
+
+	InviteUser(ctx context.Context, invitedBy int, req models.InviteUserRequest) (*models.User, bool, error)
+
+	ListOutboxEmails(ctx context.Context, status string, limit, offset int) (*models.OutboxPage, error)
+	ResendOutboxEmail(ctx context.Context, id int64) error
 }
 
 type service struct {
@@ -53,15 +87,37 @@ type service struct {
 	jwt                *JWTService
 	maxRotationPerHour int
 	s3Storage          *storage.S3Storage
+	tokenVersions      *TokenVersionCache
+	sso                *SSOConfig
+	authenticators     []ExternalAuthenticator
+	directory          DirectorySource
+	syncMu             sync.Mutex
+	inviteOnly         bool
+	inviteTTL          time.Duration
+	avatarSlots        chan struct{} // ограничивает число одновременных фоновых загрузок аватаров в S3
 }
 
 type Config struct {
-	AppBaseURL      string
+	AppBaseURL      string // адрес фронтенда для ссылок в письмах (http_server.public_url)
 	EmailService    EmailService
-	JWTSecret       string
-	AccessTTL       time.Duration
-	RefreshTTL      time.Duration
+	JWT             *JWTService // общий с AuthMiddleware, чтобы подпись и проверка шли одним набором ключей
 	VerificationTTL time.Duration
+	// TokenVersionTTL — сколько версия токенов живёт в кэше, если её не инвалидировали явно
+	TokenVersionTTL time.Duration
+	// SSO — вход через OIDC провайдер; nil, если выключен
+	SSO *SSOConfig
+	// Authenticators — внешние проверки пароля (LDAP/AD), используются после bcrypt
+	Authenticators []ExternalAuthenticator
+	// Directory — каталог для плановой синхронизации пользователей; nil, если выключена
+	Directory DirectorySource
+	// InviteOnly — самостоятельная регистрация выключена, аккаунты создаёт администратор приглашением
+	InviteOnly bool
+	// InviteTTL — срок действия ссылки из приглашения (по умолчанию 72 часа)
+	InviteTTL time.Duration
+	// Templates — шаблоны писем; nil — только встроенные, язык по умолчанию русский
+	Templates *mail.Renderer
+	// Events — шина между инстансами для инвалидации кэшей; nil — один инстанс
+	Events eventbus.Bus
 }
 
 type SetPasswordRequest struct {
@@ -78,24 +134,28 @@ type ConfirmRegistrationRequest struct {
 type UpdateProfileRequest = repoUser.UpdateProfileRequest
 
 func New(userStorage repoUser.Storage, cfg Config, s3Storage *storage.S3Storage) Service {
-	access := cfg.AccessTTL
-	if access == 0 {
-		access = 15 * time.Minute
-	}
-	refresh := cfg.RefreshTTL
-	if refresh == 0 {
-		refresh = 24 * time.Hour
-	}
-	jwtSvc := NewJWTService(cfg.JWTSecret, access, refresh)
+	jwtSvc := cfg.JWT
 
 	verCfg := []VerificationConfig{}
 	if cfg.VerificationTTL > 0 {
 		verCfg = append(verCfg, VerificationConfig{TokenExpiry: cfg.VerificationTTL})
 	}
-	vs := NewVerificationService(userStorage, cfg.EmailService, cfg.AppBaseURL, verCfg...)
+	templates := cfg.Templates
+	if templates == nil {
+		var err error
+		if templates, err = mail.NewRenderer("", mail.LanguageRU); err != nil {
+			log.Fatalf("❌ Встроенные шаблоны писем не загружены: %v", err)
+		}
+	}
+	vs := NewVerificationService(userStorage, templates, cfg.AppBaseURL, verCfg...)
+
+	inviteTTL := cfg.InviteTTL
+	if inviteTTL <= 0 {
+		inviteTTL = 72 * time.Hour
+	}
 
 	log.Printf("🧩 Service.New: accessTTL=%s refreshTTL=%s verifyTTL=%s",
-		access, refresh, cfg.VerificationTTL)
+		jwtSvc.tokenExpiry, jwtSvc.refreshExpiry, cfg.VerificationTTL)
 
 	return &service{
 		storage:            userStorage,
@@ -105,13 +165,25 @@ func New(userStorage repoUser.Storage, cfg Config, s3Storage *storage.S3Storage)
 		jwt:                jwtSvc,
 		s3Storage:          s3Storage,
 		maxRotationPerHour: 10,
+		tokenVersions:      NewTokenVersionCache(cfg.TokenVersionTTL, userStorage.GetTokenVersion, cfg.Events),
+		sso:                cfg.SSO.withDefaults(),
+		authenticators:     cfg.Authenticators,
+		directory:          cfg.Directory,
+		inviteOnly:         cfg.InviteOnly,
+		inviteTTL:          inviteTTL,
+		avatarSlots:        make(chan struct{}, maxAvatarJobs),
 	}
 }
 
 // ---------------------- AUTH ----------------------
 
 func (s *service) RegisterUser(ctx context.Context, req models.RegisterRequest) (*models.AuthResponse, error) {
-	log.Printf("➡️ Регистрация пользователя: email=%s, role_id=%d, role=%s", req.Email, req.RoleID, req.RoleName)
+	log.Printf("➡️ Регистрация пользователя: email=%s", req.Email)
+
+	if s.inviteOnly {
+		log.Printf("⚠️ Самостоятельная регистрация выключена: email=%s", req.Email)
+		return nil, ErrRegistrationClosed
+	}
 
 	// Проверка на существование пользователя с таким же email
 	exists, err := s.storage.UserExists(ctx, req.Email)
@@ -124,54 +196,33 @@ func (s *service) RegisterUser(ctx context.Context, req models.RegisterRequest)
 		return nil, ErrUserAlreadyExists
 	}
 
-	// Проверка наличия роли
-	roleID := req.RoleID
-	if roleID == 0 {
-		if req.RoleName == "" {
-			log.Printf("❌ Роль не указана")
-			return nil, fmt.Errorf("требуется role_id или role")
-		}
-		rid, err := s.storage.GetRoleIDByName(ctx, req.RoleName)
-		if err != nil {
-			log.Printf("❌ GetRoleIDByName(%s) error: %v", req.RoleName, err)
-			return nil, fmt.Errorf("определить роль '%s' не удалось: %w", req.RoleName, err)
-		}
-		roleID = rid
+	// Роль при самостоятельной регистрации всегда базовая — повышает её только администратор
+	if req.RoleID != 0 || req.RoleName != "" {
+		log.Printf("⚠️ Регистрация: переданная роль проигнорирована (role_id=%d, role=%s)", req.RoleID, req.RoleName)
 	}
+	roleID := defaultRoleID
 
 	user := &models.User{
 		Email:      req.Email,
 		FirstName:  req.FirstName,
 		LastName:   req.LastName,
 		RoleID:     roleID,
-		RoleName:   req.RoleName,
+		Language:   req.Language,
 		IsVerified: false,
 		CreatedAt:  time.Now(),
 		ChangedAt:  time.Now(),
 	}
 
-	// Создание пользователя
-	if err := s.storage.CreateUser(ctx, user); err != nil {
+	// Создание пользователя вместе с письмом подтверждения в очереди исходящих
+	if err := s.verificationSvc.CreateUserWithVerification(ctx, user, mail.TemplateVerification, 0); err != nil {
 		log.Printf("❌ CreateUser error: %v", err)
 		return nil, fmt.Errorf("создание пользователя: %w", err)
 	}
-	log.Printf("✅ Пользователь создан успешно: id=%d email=%s", user.ID, user.Email)
+	log.Printf("✅ Пользователь создан успешно, письмо подтверждения в очереди: id=%d email=%s", user.ID, user.Email)
 
 	// АВТОМАТИЧЕСКАЯ ГЕНЕРАЦИЯ АВАТАРА ПОСЛЕ РЕГИСТРАЦИИ
-	if s.s3Storage != nil && user.FirstName != "" && user.LastName != "" {
-		go func(userID int) {
-			ctx := context.Background()
-			if _, err := s.GenerateDefaultAvatar(ctx, userID); err != nil {
-				log.Printf("⚠️ Не удалось сгенерировать аватар для user %d: %v", userID, err)
-			}
-		}(user.ID)
-	}
-
-	if s.emailService != nil {
-		log.Printf("CreateVerification и отправка email по адресу %s", user.Email)
-		if err := s.verificationSvc.CreateVerification(ctx, user.ID, user.Email); err != nil {
-			log.Printf("⚠️ Ошибка создания верификации: %v", err)
-		}
+	if user.FirstName != "" && user.LastName != "" {
+		s.generateAvatarAsync(user.ID)
 	}
 
 	return &models.AuthResponse{
@@ -221,6 +272,29 @@ func (s *service) UploadUserPhoto(ctx context.Context, userID int, file []byte,
 	return presignedURL, nil
 }
 
+const (
+	maxAvatarJobs = 4                // одновременных фоновых генераций аватара
+	avatarTimeout = 30 * time.Second // на генерацию и загрузку одного аватара
+)
+
+// generateAvatarAsync генерирует аватар нового пользователя в фоне. Одновременно идёт не больше
+// maxAvatarJobs загрузок, остальные ждут слота — импорт приглашений не запускает сотни загрузок сразу.
+func (s *service) generateAvatarAsync(userID int) {
+	if s.s3Storage == nil {
+		return
+	}
+	go func() {
+		s.avatarSlots <- struct{}{}
+		defer func() { <-s.avatarSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), avatarTimeout)
+		defer cancel()
+		if _, err := s.GenerateDefaultAvatar(ctx, userID); err != nil {
+			log.Printf("⚠️ Не удалось сгенерировать аватар для user %d: %v", userID, err)
+		}
+	}()
+}
+
 func (s *service) GenerateDefaultAvatar(ctx context.Context, userID int) (string, error) {
 	log.Printf("GenerateDefaultAvatar: userID=%d", userID)
 
@@ -319,34 +393,15 @@ func (s *service) ConfirmRegistration(ctx context.Context, req ConfirmRegistrati
 	}
 
 	// Генерация токенов
-	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID)
+	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID, user.TokenVersion)
 	if err != nil {
 		log.Printf("❌ GenerateJWTToken error: %v", err)
 		return nil, fmt.Errorf("генерация access token: %w", err)
 	}
 
-	refresh, err := s.jwt.GenerateRefreshToken(user.ID, user.Email, user.RoleID)
+	refresh, err := s.issueRefreshToken(ctx, user, "")
 	if err != nil {
-		log.Printf("❌ GenerateRefreshToken error: %v", err)
-		return nil, fmt.Errorf("генерация refresh token: %w", err)
-	}
-
-	if s.refreshStorage == nil {
-		log.Printf("❌ refreshStorage is NIL")
-		return nil, fmt.Errorf("внутренняя ошибка: хранилище токенов не настроено")
-	}
-
-	expires := time.Now().Add(s.jwt.refreshExpiry)
-	log.Printf("SaveRefreshToken(confirm): user_id=%d expires=%s", user.ID, expires.Format(time.RFC3339))
-
-	if _, err := s.refreshStorage.SaveRefreshToken(ctx, repoUser.RefreshToken{
-		UserID:    user.ID,
-		Token:     refresh,
-		ExpiresAt: expires,
-		CreatedAt: time.Now(),
-	}); err != nil {
-		log.Printf("❌ SaveRefreshToken error: %v", err)
-		return nil, fmt.Errorf("сохранение refresh token: %w", err)
+		return nil, err
 	}
 
 	log.Printf("✅ Регистрация успешно подтверждена: user_id=%d", user.ID)
@@ -366,42 +421,43 @@ func (s *service) LoginUser(ctx context.Context, req models.LoginRequest) (*mode
 
 	user, err := s.storage.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		log.Printf("❌ GetUserByEmail error: %v", err)
-		return nil, ErrUserNotFound
+		log.Printf("⚠️ GetUserByEmail: %v", err)
+		user = nil
+	}
+
+	// Сначала локальный пароль (bcrypt), затем внешние провайдеры (LDAP/AD)
+	if user == nil || user.PasswordHash == "" || !s.checkPasswordHash(req.Password, user.PasswordHash) {
+		extUser, extErr := s.authenticateExternal(ctx, req.Email, req.Password)
+		switch {
+		case extErr == nil:
+			user = extUser
+		case extErr == ErrAccountInactive:
+			return nil, extErr
+		case user == nil:
+			log.Printf("❌ Пользователь не найден: email=%s", req.Email)
+			return nil, ErrUserNotFound
+		default:
+			log.Printf("⚠️ Неправильный пароль для email=%s", req.Email)
+			return nil, ErrInvalidCredentials
+		}
 	}
-	if user.PasswordHash == "" || !s.checkPasswordHash(req.Password, user.PasswordHash) {
-		log.Printf("⚠️ Неправильный пароль для email=%s", req.Email)
-		return nil, ErrInvalidCredentials
+	if user.DeactivatedAt != nil {
+		log.Printf("⚠️ Вход в деактивированный аккаунт: user_id=%d", user.ID)
+		return nil, ErrAccountInactive
 	}
 	if !user.IsVerified {
 		log.Printf("⚠️ Пользователь не верифицирован: %s", req.Email)
 		return nil, errors.New("пользователь не верифицирован")
 	}
 
-	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID)
+	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID, user.TokenVersion)
 	if err != nil {
 		log.Printf("❌ GenerateJWTToken error: %v", err)
 		return nil, fmt.Errorf("генерация access token: %w", err)
 	}
-	refresh, err := s.jwt.GenerateRefreshToken(user.ID, user.Email, user.RoleID)
+	refresh, err := s.issueRefreshToken(ctx, user, "")
 	if err != nil {
-		log.Printf("❌ GenerateRefreshToken error: %v", err)
-		return nil, fmt.Errorf("генерация refresh token: %w", err)
-	}
-
-	if s.refreshStorage == nil {
-		log.Printf("❌ refreshStorage is NIL")
-		return nil, fmt.Errorf("внутренняя ошибка: хранилище refresh токенов не настроено")
-	}
-	expires := time.Now().Add(s.jwt.refreshExpiry)
-	log.Printf("SaveRefreshToken(login): user_id=%d expires=%s", user.ID, expires.Format(time.RFC3339))
-	if _, err := s.refreshStorage.SaveRefreshToken(ctx, repoUser.RefreshToken{
-		UserID:    user.ID,
-		Token:     refresh,
-		ExpiresAt: expires,
-	}); err != nil {
-		log.Printf("❌ SaveRefreshToken error: %v", err)
-		return nil, fmt.Errorf("сохранение refresh token: %w", err)
+		return nil, err
 	}
 
 	if user.PhotoPath != "" {
@@ -427,6 +483,35 @@ func (s *service) LoginUser(ctx context.Context, req models.LoginRequest) (*mode
 	}, nil
 }
 
+// authenticateExternal проверяет пароль у внешних провайдеров по очереди;
+// при успехе пользователь портала создаётся или обновляется по данным каталога
+func (s *service) authenticateExternal(ctx context.Context, email, password string) (*models.User, error) {
+	for _, auth := range s.authenticators {
+		ext, err := auth.Authenticate(ctx, email, password)
+		if err != nil {
+			if err != ErrInvalidCredentials && err != ErrAccountInactive {
+				log.Printf("❌ Провайдер %s: %v", auth.Name(), err)
+			}
+			if err == ErrAccountInactive {
+				return nil, err
+			}
+			continue
+		}
+
+		user, _, err := s.applyExternalUser(ctx, auth.Name(), *ext, false)
+		if err != nil {
+			log.Printf("❌ Не удалось обновить пользователя по данным %s: %v", auth.Name(), err)
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrAccountInactive
+		}
+		log.Printf("✅ Пароль подтверждён провайдером %s: user_id=%d", auth.Name(), user.ID)
+		return user, nil
+	}
+	return nil, ErrInvalidCredentials
+}
+
 // UpdateUserProfile обновляет профиль пользователя
 func (s *service) UpdateUserProfile(ctx context.Context, userID int, updateReq UpdateProfileRequest) error {
 	log.Printf("UpdateUserProfile: userID=%d", userID)
@@ -440,6 +525,14 @@ func (s *service) UpdateUserProfile(ctx context.Context, userID int, updateReq U
 	// Убираем предупреждение "declared and not used"
 	log.Printf("Обновление профиля для пользователя: %s %s", user.FirstName, user.LastName)
 
+	if updateReq.Language != nil {
+		lang := mail.NormalizeLanguage(*updateReq.Language, "")
+		if lang == "" {
+			return ErrUnsupportedLanguage
+		}
+		updateReq.Language = &lang
+	}
+
 	if err := s.storage.UpdateUserProfile(ctx, userID, updateReq); err != nil {
 		return fmt.Errorf("ошибка обновления профиля: %w", err)
 	}
@@ -448,39 +541,6 @@ func (s *service) UpdateUserProfile(ctx context.Context, userID int, updateReq U
 	return nil
 }
 
-// DeleteUser реализует удаление пользователя
-func (s *service) DeleteUser(ctx context.Context, userID int) error {
-	log.Printf("DeleteUser начат: userID=%d", userID)
-
-	// Проверяем существование пользователя
-	user, err := s.storage.GetUserByID(ctx, userID)
-	if err != nil {
-		log.Printf("❌ GetUserByID error: %v", err)
-		return fmt.Errorf("пользователь не найден: %w", err)
-	}
-
-	// Дополнительные проверки (опционально)
-	if user.IsVerified {
-		log.Printf("⚠️ Попытка удаления верифицированного пользователя: userID=%d", userID)
-		// Можно добавить дополнительную логику или запретить удаление
-	}
-
-	// Удаляем пользователя
-	if err := s.storage.DeleteUser(ctx, userID); err != nil {
-		log.Printf("❌ DeleteUser storage error: %v", err)
-		return fmt.Errorf("ошибка удаления пользователя: %w", err)
-	}
-
-	// Удаляем все refresh tokens пользователя
-	if err := s.refreshStorage.DeleteAllUserRefreshTokens(ctx, userID); err != nil {
-		log.Printf("⚠️ Ошибка удаления refresh tokens: %v", err)
-		// Не прерываем выполнение, если не удалось удалить токены
-	}
-
-	log.Printf("✅ Пользователь удален: userID=%d, email=%s", userID, user.Email)
-	return nil
-}
-
 // ---------------------- JWT / SESSIONS ----------------------
 
 func (s *service) ValidateAccessToken(tokenString string) (int, string, error) {
@@ -499,12 +559,57 @@ func (s *service) ValidateAccessToken(tokenString string) (int, string, error) {
 
 func (s *service) Logout(ctx context.Context, refreshToken string) error {
 	log.Printf("Выход (текущая сессия)")
-	return s.refreshStorage.DeleteRefreshToken(ctx, refreshToken)
+	rt, err := s.refreshStorage.GetRefreshToken(ctx, HashRefreshToken(refreshToken))
+	if err != nil {
+		// Неизвестный токен — сессии уже нет, выходить не из чего
+		log.Printf("⚠️ Logout: refresh token не найден: %v", err)
+		return nil
+	}
+	// Отзываем всю цепочку ротации текущей сессии, чтобы ранее выданные токены тоже перестали работать
+	return s.refreshStorage.RevokeRefreshTokenFamily(ctx, rt.FamilyID)
 }
 
 func (s *service) LogoutAll(ctx context.Context, userID int) error {
 	log.Printf("Выход всех пользователей user_id=%d", userID)
-	return s.refreshStorage.DeleteAllUserRefreshTokens(ctx, userID)
+	if err := s.refreshStorage.DeleteAllUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.RevokeAccessTokens(ctx, userID)
+}
+
+// CheckTokenVersion сверяет версию из access токена с текущей версией пользователя
+func (s *service) CheckTokenVersion(ctx context.Context, userID, tokenVersion int) error {
+	version, active, err := s.tokenVersions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("❌ CheckTokenVersion error: %v", err)
+		return err
+	}
+	if !active || version != tokenVersion {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// RevokeAccessTokens делает недействительными все выданные пользователю access токены, включая
+// персональные: выход со всех устройств, деактивация, смена email или роли отзывают и их
+func (s *service) RevokeAccessTokens(ctx context.Context, userID int) error {
+	n, err := s.storage.RevokeAllPersonalTokens(ctx, userID)
+	if err != nil {
+		log.Printf("❌ RevokeAllPersonalTokens error: %v", err)
+		return fmt.Errorf("отзыв персональных токенов: %w", err)
+	}
+	if n > 0 {
+		log.Printf("🔒 Персональные токены отозваны: user_id=%d count=%d", userID, n)
+	}
+
+	version, err := s.storage.IncrementTokenVersion(ctx, userID)
+	s.tokenVersions.Invalidate(userID)
+	if err != nil {
+		log.Printf("❌ IncrementTokenVersion error: %v", err)
+		return fmt.Errorf("отзыв access токенов: %w", err)
+	}
+	log.Printf("🔒 Access токены отозваны: user_id=%d token_version=%d", userID, version)
+	return nil
 }
 
 // ---------------------- LEGACY VERIFY ----------------------
@@ -650,7 +755,7 @@ func (s *service) ResendVerificationEmail(ctx context.Context, userID int, email
 	log.Printf("ResendVerificationEmail: targetEmail=%s", targetEmail)
 
 	// 4) создаём новый верификационный токен и отправляем письмо
-	if err := s.verificationSvc.CreateVerification(ctx, user.ID, targetEmail); err != nil {
+	if err := s.verificationSvc.CreateVerification(ctx, user.ID, targetEmail, user.FirstName, user.Language); err != nil {
 		log.Printf("❌ ResendVerificationEmail: создание токена верификации error: %v", err)
 		return fmt.Errorf("создание токена верификации: %w", err)
 	}
@@ -718,14 +823,13 @@ func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*model
 		return nil, errors.New("неверный тип токена")
 	}
 
-	rt, err := s.refreshStorage.GetRefreshToken(ctx, refreshToken)
+	// Ротация токенов: старый помечается использованным, повторное предъявление отзывает всё семейство
+	rt, err := s.rotateRefreshToken(ctx, refreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("refresh token не найден: %w", err)
+		return nil, err
 	}
-
-	if time.Now().After(rt.ExpiresAt) {
-		_ = s.refreshStorage.DeleteRefreshToken(ctx, refreshToken)
-		return nil, errors.New("срок действия refresh token истек")
+	if rt.UserID != claims.UserID {
+		return nil, ErrRefreshTokenInvalid
 	}
 
 	user, err := s.storage.GetUserByID(ctx, claims.UserID)
@@ -733,29 +837,21 @@ func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*model
 		return nil, fmt.Errorf("пользователь не найден: %w", err)
 	}
 
-	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID)
-	if err != nil {
-		return nil, fmt.Errorf("генерация access token: %w", err)
+	// Удалённый пользователь не может продлить сессию
+	if _, active, err := s.tokenVersions.Get(ctx, user.ID); err != nil {
+		return nil, err
+	} else if !active {
+		return nil, ErrTokenRevoked
 	}
 
-	newRefresh, err := s.jwt.GenerateRefreshToken(user.ID, user.Email, user.RoleID)
+	access, err := s.jwt.GenerateJWTToken(user.ID, user.Email, user.RoleID, user.TokenVersion)
 	if err != nil {
-		return nil, fmt.Errorf("генерация refresh token: %w", err)
-	}
-
-	// Ротация токенов
-	if err := s.refreshStorage.DeleteRefreshToken(ctx, refreshToken); err != nil {
-		log.Printf("Ошибка удаления старого токена: %v", err)
+		return nil, fmt.Errorf("генерация access token: %w", err)
 	}
 
-	expires := time.Now().Add(s.jwt.refreshExpiry)
-	if _, err := s.refreshStorage.SaveRefreshToken(ctx, repoUser.RefreshToken{
-		UserID:    user.ID,
-		Token:     newRefresh,
-		ExpiresAt: expires,
-		CreatedAt: time.Now(),
-	}); err != nil {
-		return nil, fmt.Errorf("save refresh token: %w", err)
+	newRefresh, err := s.issueRefreshToken(ctx, user, rt.FamilyID)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.AuthResponse{
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"cmd/internal/eventbus"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// topicTokenVersion — инвалидация записи кэша на всех инстансах; payload — ID пользователя
+const topicTokenVersion = "user.token_version"
+
+// TokenVersionCache кэширует версию токенов пользователей, чтобы AuthMiddleware не ходил в базу на каждый запрос.
+// Инвалидация расходится по инстансам через шину событий; без шины изменения с других инстансов
+// подхватываются по истечении ttl.
+type TokenVersionCache struct {
+	mu      sync.RWMutex
+	entries map[int]tokenVersionEntry
+	// Поколения: Invalidate увеличивает поколение пользователя, сброс — общее. Версия, прочитанная из базы
+	// до инвалидации, не сохраняется — иначе отозванный токен принимался бы ещё ttl
+	gens  map[int]uint64
+	epoch uint64
+	ttl   time.Duration
+	load  func(ctx context.Context, userID int) (int, bool, error)
+	bus   eventbus.Bus
+}
+
+type tokenVersionEntry struct {
+	version  int
+	active   bool
+	loadedAt time.Time
+}
+
+// NewTokenVersionCache создает кэш; load вызывается при промахе или устаревшей записи, bus может быть nil
+func NewTokenVersionCache(ttl time.Duration, load func(ctx context.Context, userID int) (int, bool, error), bus eventbus.Bus) *TokenVersionCache {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	c := &TokenVersionCache{
+		entries: make(map[int]tokenVersionEntry),
+		gens:    make(map[int]uint64),
+		ttl:     ttl,
+		load:    load,
+		bus:     bus,
+	}
+	if bus != nil {
+		bus.Subscribe(topicTokenVersion, func(msg eventbus.Message) {
+			var userID int
+			if err := msg.Decode(&userID); err == nil {
+				c.forget(userID)
+			}
+		})
+		// Инвалидации за время разрыва связи с шиной потеряны — сбрасываем кэш целиком
+		bus.Subscribe(eventbus.TopicResync, func(eventbus.Message) { c.reset() })
+	}
+	return c
+}
+
+// Get возвращает актуальную версию токенов и признак активности пользователя
+func (c *TokenVersionCache) Get(ctx context.Context, userID int) (int, bool, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[userID]
+	gen, epoch := c.gens[userID], c.epoch
+	c.mu.RUnlock()
+
+	if ok && time.Since(entry.loadedAt) < c.ttl {
+		return entry.version, entry.active, nil
+	}
+
+	version, active, err := c.load(ctx, userID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	c.mu.Lock()
+	if c.gens[userID] == gen && c.epoch == epoch {
+		c.entries[userID] = tokenVersionEntry{version: version, active: active, loadedAt: time.Now()}
+	}
+	c.mu.Unlock()
+
+	return version, active, nil
+}
+
+// Invalidate сбрасывает запись пользователя на всех инстансах — следующий запрос перечитает версию из базы
+func (c *TokenVersionCache) Invalidate(userID int) {
+	c.forget(userID)
+	if c.bus != nil {
+		if err := c.bus.Publish(context.Background(), topicTokenVersion, userID); err != nil {
+			log.Printf("⚠️ Инвалидация версии токенов пользователя %d не разослана: %v", userID, err)
+		}
+	}
+}
+
+func (c *TokenVersionCache) forget(userID int) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.gens[userID]++
+	c.mu.Unlock()
+}
+
+func (c *TokenVersionCache) reset() {
+	c.mu.Lock()
+	c.entries = make(map[int]tokenVersionEntry)
+	c.epoch++
+	c.mu.Unlock()
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmd/internal/user/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUserAnonymized      = errors.New("user is anonymized")
+	ErrUserFilesNotDeleted = errors.New("user files were not deleted")
+)
+
+// ExportUserData собирает персональные данные пользователя в ZIP: профиль, комментарии,
+// лайки, отметки об ознакомлении и метаданные загруженных документов (каждый раздел — отдельный JSON)
+func (s *service) ExportUserData(ctx context.Context, userID int) ([]byte, error) {
+	log.Printf("➡️ Экспорт данных пользователя: user_id=%d", userID)
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserByID error: %v", err)
+		return nil, ErrUserNotFound
+	}
+	comments, err := s.storage.ListUserComments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	likes, err := s.storage.ListUserLikes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	views, err := s.storage.ListUserViews(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := s.storage.ListUserDocuments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"comments.json", comments},
+		{"likes.json", likes},
+		{"views.json", views},
+		{"documents.json", documents},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+	for _, section := range sections {
+		raw, err := json.MarshalIndent(section.data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сериализации %s: %w", section.name, err)
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: section.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка создания архива: %w", err)
+		}
+		if _, err := f.Write(raw); err != nil {
+			return nil, fmt.Errorf("ошибка записи архива: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка создания архива: %w", err)
+	}
+
+	log.Printf("✅ Экспорт готов: user_id=%d comments=%d likes=%d views=%d documents=%d",
+		userID, len(comments), len(likes), len(views), len(documents))
+	return buf.Bytes(), nil
+}
+
+// AnonymizeUser необратимо обезличивает пользователя: имя, email и фото стираются, аватары удаляются из S3,
+// учётная запись деактивируется. Комментарии, лайки и просмотры остаются для статистики.
+func (s *service) AnonymizeUser(ctx context.Context, actorID, userID int) (*models.AnonymizeResult, error) {
+	log.Printf("➡️ Обезличивание пользователя: user_id=%d actor=%d", userID, actorID)
+
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserByID error: %v", err)
+		return nil, ErrUserNotFound
+	}
+
+	// Сначала файлы: при ошибке S3 данные в базе ещё не тронуты и операцию можно просто повторить
+	result := &models.AnonymizeResult{UserID: userID}
+	if s.s3Storage != nil {
+		deleted, err := s.s3Storage.DeletePrefix(ctx, fmt.Sprintf("photos/user-%d/", userID))
+		result.DeletedFiles += deleted
+		if err != nil {
+			log.Printf("❌ Не удалось удалить фото user_id=%d: %v", userID, err)
+			return nil, ErrUserFilesNotDeleted
+		}
+		// Аватары из общего каталога avatars/ не привязаны к пользователю по пути — удаляем текущий
+		if key := s.extractS3Key(user.PhotoPath); strings.HasPrefix(key, "avatars/") {
+			if err := s.s3Storage.DeleteFile(ctx, key); err != nil {
+				log.Printf("❌ Не удалось удалить аватар %s: %v", key, err)
+				return nil, ErrUserFilesNotDeleted
+			}
+			result.DeletedFiles++
+		}
+	}
+
+	// Адрес в зарезервированном домене .invalid уникален и не может получить письмо
+	placeholder := fmt.Sprintf("anonymized-%d@deleted.invalid", userID)
+	if err := s.storage.AnonymizeUser(ctx, userID, placeholder); err != nil {
+		log.Printf("❌ AnonymizeUser storage error: %v", err)
+		return nil, err
+	}
+	if err := s.endUserSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Пользователь обезличен: user_id=%d удалено файлов=%d", userID, result.DeletedFiles)
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+var (
+	ErrCannotModifySelf      = errors.New("cannot deactivate or delete own account")
+	ErrInvalidReassignTarget = errors.New("invalid document reassignment target")
+)
+
+// DeactivateUser отключает учётную запись: вход запрещён, пользователь скрыт из списков,
+// но его документы, комментарии и отметки об ознакомлении сохраняются. Деактивацию можно отменить.
+// Если reassignTo > 0, авторство документов передаётся этому пользователю.
+func (s *service) DeactivateUser(ctx context.Context, actorID, userID, reassignTo int) error {
+	log.Printf("DeactivateUser: user_id=%d actor=%d reassign_to=%d", userID, actorID, reassignTo)
+
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserByID error: %v", err)
+		return ErrUserNotFound
+	}
+	if user.DeactivatedAt != nil {
+		log.Printf("⚠️ Пользователь уже деактивирован: user_id=%d", userID)
+		return nil
+	}
+	if err := s.checkReassignTarget(ctx, userID, reassignTo); err != nil {
+		return err
+	}
+
+	// Деактивация и передача документов — одна транзакция, как при удалении
+	if err := s.storage.DeactivateUser(ctx, userID, &actorID, reassignTo); err != nil {
+		log.Printf("❌ DeactivateUser storage error: %v", err)
+		return err
+	}
+	if reassignTo > 0 {
+		log.Printf("🔄 Документы переданы: user_id=%d → %d", userID, reassignTo)
+	}
+	if err := s.endUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Пользователь деактивирован: user_id=%d email=%s", userID, user.Email)
+	return nil
+}
+
+// ReactivateUser возвращает доступ деактивированному пользователю
+func (s *service) ReactivateUser(ctx context.Context, userID int) error {
+	log.Printf("ReactivateUser: user_id=%d", userID)
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserByID error: %v", err)
+		return ErrUserNotFound
+	}
+	if user.AnonymizedAt != nil {
+		return ErrUserAnonymized
+	}
+	if err := s.storage.ReactivateUser(ctx, userID); err != nil {
+		log.Printf("❌ ReactivateUser storage error: %v", err)
+		return err
+	}
+	s.tokenVersions.Invalidate(userID)
+
+	log.Printf("✅ Пользователь активирован: user_id=%d", userID)
+	return nil
+}
+
+// DeleteUser окончательно удаляет учётную запись. Комментарии, лайки и отметки об ознакомлении
+// остаются в истории без автора; документы передаются reassignTo, а без него остаются без автора.
+func (s *service) DeleteUser(ctx context.Context, actorID, userID, reassignTo int) error {
+	log.Printf("DeleteUser: user_id=%d actor=%d reassign_to=%d", userID, actorID, reassignTo)
+
+	if actorID == userID {
+		return ErrCannotModifySelf
+	}
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ GetUserByID error: %v", err)
+		return ErrUserNotFound
+	}
+	if err := s.checkReassignTarget(ctx, userID, reassignTo); err != nil {
+		return err
+	}
+
+	// Refresh токены и персональные токены удаляются каскадом вместе с пользователем
+	if err := s.storage.DeleteUser(ctx, userID, reassignTo); err != nil {
+		log.Printf("❌ DeleteUser storage error: %v", err)
+		return err
+	}
+	s.tokenVersions.Invalidate(userID)
+
+	log.Printf("✅ Пользователь удалён: user_id=%d email=%s", userID, user.Email)
+	return nil
+}
+
+// checkReassignTarget — документы можно передать только другому активному пользователю
+func (s *service) checkReassignTarget(ctx context.Context, userID, reassignTo int) error {
+	if reassignTo == 0 {
+		return nil
+	}
+	if reassignTo == userID {
+		return ErrInvalidReassignTarget
+	}
+	target, err := s.storage.GetUserByID(ctx, reassignTo)
+	if err != nil || target.DeactivatedAt != nil {
+		log.Printf("⚠️ Нельзя передать документы пользователю %d: %v", reassignTo, err)
+		return ErrInvalidReassignTarget
+	}
+	return nil
+}
+
+// endUserSessions завершает все сессии: refresh токены удаляются, access токены отзываются сразу
+func (s *service) endUserSessions(ctx context.Context, userID int) error {
+	if err := s.refreshStorage.DeleteAllUserRefreshTokens(ctx, userID); err != nil {
+		log.Printf("⚠️ Ошибка удаления refresh tokens: %v", err)
+	}
+	return s.RevokeAccessTokens(ctx, userID)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"cmd/internal/user/models"
+	repoUser "cmd/internal/user/repo"
+	"context"
+	"errors"
+	"log"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	defaultUsersPageSize = 50
+	maxUsersPageSize     = 200
+)
+
+// SearchUsers — список пользователей для администратора: поиск, фильтры, сортировка, курсорная пагинация
+func (s *service) SearchUsers(ctx context.Context, filter models.UserFilter) (*models.UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUsersPageSize
+	}
+	if filter.Limit > maxUsersPageSize {
+		filter.Limit = maxUsersPageSize
+	}
+
+	page, err := s.storage.SearchUsers(ctx, filter)
+	if err != nil {
+		if errors.Is(err, repoUser.ErrInvalidCursor) {
+			return nil, ErrInvalidCursor
+		}
+		log.Printf("❌ SearchUsers storage error: %v", err)
+		return nil, err
+	}
+
+	for _, user := range page.Items {
+		user.PhotoPath = s.presignPhoto(user.ID, user.PhotoPath)
+	}
+	log.Printf("✅ SearchUsers: count=%d total=%d", len(page.Items), page.Total)
+	return page, nil
+}
+
+// EmployeeDirectory — справочник сотрудников для всех авторизованных пользователей.
+// Показываются только активные сотрудники, принявшие приглашение; email и служебные поля скрыты.
+func (s *service) EmployeeDirectory(ctx context.Context, filter models.UserFilter) (*models.DirectoryPage, error) {
+	verified := true
+	filter.Verified = &verified
+	filter.Status = "active"
+	filter.HideEmail = true
+	if filter.Sort == "email" || filter.Sort == "" {
+		filter.Sort = "name"
+		filter.Desc = false
+	}
+
+	page, err := s.SearchUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &models.DirectoryPage{Items: make([]models.DirectoryEntry, 0, len(page.Items)), Total: page.Total, NextCursor: page.NextCursor}
+	for _, user := range page.Items {
+		out.Items = append(out.Items, models.DirectoryEntry{
+			ID:         user.ID,
+			FirstName:  user.FirstName,
+			LastName:   user.LastName,
+			PhotoPath:  user.PhotoPath,
+			RoleName:   user.RoleName,
+			Department: user.Department,
+		})
+	}
+	return out, nil
+}
+
+// presignPhoto заменяет ключ S3 на временную ссылку; при ошибке оставляет как есть
+func (s *service) presignPhoto(userID int, photoPath string) string {
+	if photoPath == "" || s.s3Storage == nil {
+		return photoPath
+	}
+	key := s.extractS3Key(photoPath)
+	if key == "" {
+		return photoPath
+	}
+	presignedURL, err := s.s3Storage.GenerateDownloadURL(key)
+	if err != nil {
+		log.Printf("⚠️ Ошибка генерации presigned URL для пользователя %d: %v", userID, err)
+		return photoPath
+	}
+	return presignedURL
+}
@@ -1,21 +1,24 @@
 package service
 
 import (
+	"cmd/internal/mail"
+	"cmd/internal/user/models"
 	repoUserInterface "cmd/internal/user/repo"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 )
 
 // VerificationService сервис для верификации email
 type VerificationService struct {
-	storage      repoUserInterface.Storage // Используем Storage интерфейс, а не RefreshStorage
-	emailService EmailService
-	appBaseURL   string
-	tokenExpiry  time.Duration
+	storage     repoUserInterface.Storage // Используем Storage интерфейс, а не RefreshStorage
+	templates   *mail.Renderer
+	appBaseURL  string
+	tokenExpiry time.Duration
 }
 
 // VerificationConfig конфигурация для VerificationService
@@ -24,7 +27,7 @@ type VerificationConfig struct {
 }
 
 // NewVerificationService создает новый сервис верификации
-func NewVerificationService(storage repoUserInterface.Storage, emailService EmailService, appBaseURL string, config ...VerificationConfig) *VerificationService {
+func NewVerificationService(storage repoUserInterface.Storage, templates *mail.Renderer, appBaseURL string, config ...VerificationConfig) *VerificationService {
 	tokenExpiry := 1 * time.Hour
 
 	if len(config) > 0 {
@@ -32,10 +35,10 @@ func NewVerificationService(storage repoUserInterface.Storage, emailService Emai
 	}
 
 	return &VerificationService{
-		storage:      storage, // Просто присваиваем переданный storage
-		emailService: emailService,
-		appBaseURL:   appBaseURL,
-		tokenExpiry:  tokenExpiry,
+		storage:     storage, // Просто присваиваем переданный storage
+		templates:   templates,
+		appBaseURL:  appBaseURL,
+		tokenExpiry: tokenExpiry,
 	}
 }
 
@@ -48,53 +51,105 @@ func (vs *VerificationService) GenerateVerificationToken() (string, error) {
 	return hex.EncodeToString(token), nil
 }
 
-// GetBaseURL подготавливает ссылку для подтверждения email
+// GetBaseURL возвращает адрес фронтенда, на страницы которого ведут ссылки из писем
 func (vs *VerificationService) GetBaseURL() string {
-	baseURL := vs.appBaseURL
+	baseURL := strings.TrimRight(vs.appBaseURL, "/")
 	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
 		baseURL = "http://" + baseURL
 	}
 	return baseURL
 }
 
-// SendVerificationEmail отправляет email с ссылкой для подтверждения
-func (vs *VerificationService) SendVerificationEmail(userID int, email, token string) error {
-	// baseURL := vs.GetBaseURL()
-	verificationURL := fmt.Sprintf("http://45.130.9.212/confirm-password?user_id=%d&token=%s", userID, token)
+// confirmURL — ссылка на страницу установки пароля
+func (vs *VerificationService) confirmURL(userID int, token string) string {
+	return fmt.Sprintf("%s/confirm-password?user_id=%d&token=%s", vs.GetBaseURL(), userID, token)
+}
+
+// confirmEmailChangeURL — ссылка на страницу подтверждения нового email
+func (vs *VerificationService) confirmEmailChangeURL(token string) string {
+	return fmt.Sprintf("%s/confirm-email?token=%s", vs.GetBaseURL(), token)
+}
+
+// renderEmail рендерит письмо на языке получателя в запись очереди исходящих
+func (vs *VerificationService) renderEmail(userID int, to, lang, name string, data map[string]interface{}) (models.OutboxEmail, error) {
+	msg, err := vs.templates.Render(name, lang, data)
+	if err != nil {
+		return models.OutboxEmail{}, err
+	}
+	email := models.OutboxEmail{
+		Recipient: to,
+		Template:  name,
+		Subject:   msg.Subject,
+		Text:      msg.Text,
+		HTML:      msg.HTML,
+	}
+	if userID > 0 {
+		email.UserID = &userID
+	}
+	return email, nil
+}
 
-	return vs.emailService.SendVerificationEmail(email, verificationURL)
+// linkEmail — письмо со ссылкой установки пароля (подтверждение регистрации или приглашение)
+func (vs *VerificationService) linkEmail(userID int, email, firstName, lang, template, token string, expiresAt time.Time) (models.OutboxEmail, error) {
+	return vs.renderEmail(userID, email, lang, template, map[string]interface{}{
+		"FirstName": firstName,
+		"URL":       vs.confirmURL(userID, token),
+		"ExpiresAt": expiresAt,
+	})
 }
 
-// CreateVerification создает и сохраняет токен верификации
-func (vs *VerificationService) CreateVerification(ctx context.Context, userID int, email string) error {
-	fmt.Printf("🔧 DEBUG: CreateVerification начат для userID: %d, email: %s\n", userID, email)
+// CreateUserWithVerification создаёт пользователя вместе со ссылкой установки пароля; письмо
+// (template — verification или invite) ставится в очередь той же транзакцией
+func (vs *VerificationService) CreateUserWithVerification(ctx context.Context, user *models.User, template string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = vs.tokenExpiry
+	}
 
-	// Генерируем токен
 	token, err := vs.GenerateVerificationToken()
 	if err != nil {
-		fmt.Printf("❌ Ошибка генерации токена: %v\n", err)
 		return fmt.Errorf("ошибка генерации токена верификации: %w", err)
 	}
-	fmt.Printf("🔧 DEBUG: Токен сгенерирован: %s\n", token)
 
-	// Сохраняем токен в БД с временем expiration
-	expiresAt := time.Now().Add(vs.tokenExpiry)
-	err = vs.storage.SaveVerificationToken(ctx, userID, token, expiresAt)
+	expiresAt := time.Now().Add(ttl)
+	return vs.storage.CreateUserWithVerification(ctx, user, token, expiresAt, func(userID int) (models.OutboxEmail, error) {
+		return vs.linkEmail(userID, user.Email, user.FirstName, user.Language, template, token, expiresAt)
+	})
+}
+
+// CreateInvitation перевыпускает ссылку приглашения с отдельным сроком жизни и ставит письмо в очередь.
+// Пользователь завершает регистрацию тем же подтверждением с установкой пароля.
+func (vs *VerificationService) CreateInvitation(ctx context.Context, userID int, email, firstName, lang string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = vs.tokenExpiry
+	}
+	return vs.issueToken(ctx, userID, email, firstName, lang, mail.TemplateInvite, ttl)
+}
+
+// CreateVerification создает и сохраняет токен верификации, письмо ставится в очередь
+func (vs *VerificationService) CreateVerification(ctx context.Context, userID int, email, firstName, lang string) error {
+	return vs.issueToken(ctx, userID, email, firstName, lang, mail.TemplateVerification, vs.tokenExpiry)
+}
+
+func (vs *VerificationService) issueToken(ctx context.Context, userID int, email, firstName, lang, template string, ttl time.Duration) error {
+	token, err := vs.GenerateVerificationToken()
 	if err != nil {
-		fmt.Printf("❌ Ошибка сохранения токена: %v\n", err)
-		return fmt.Errorf("failed to save verification token: %w", err)
+		log.Printf("❌ Ошибка генерации токена: userID=%d: %v", userID, err)
+		return fmt.Errorf("ошибка генерации токена верификации: %w", err)
 	}
-	fmt.Printf("🔧 DEBUG: Токен сохранен в БД\n")
 
-	// Отправляем email
-	fmt.Printf("🔧 DEBUG: Вызов SendVerificationEmail...\n")
-	err = vs.SendVerificationEmail(userID, email, token)
+	expiresAt := time.Now().Add(ttl)
+	msg, err := vs.linkEmail(userID, email, firstName, lang, template, token, expiresAt)
 	if err != nil {
-		fmt.Printf("❌ Ошибка отправки email: %v\n", err)
-		return fmt.Errorf("failed to send verification email: %w", err)
+		return err
+	}
+
+	// Токен и письмо сохраняются вместе: письмо отправит фоновый процесс с повторами
+	if err := vs.storage.SaveVerificationToken(ctx, userID, token, expiresAt, msg); err != nil {
+		log.Printf("❌ Ошибка сохранения токена: userID=%d: %v", userID, err)
+		return fmt.Errorf("failed to save verification token: %w", err)
 	}
 
-	fmt.Printf("✅ CreateVerification завершен успешно!\n")
+	log.Printf("✅ Токен сохранён, письмо (%s) поставлено в очередь: userID=%d", template, userID)
 	return nil
 }
 
@@ -61,9 +61,9 @@ func (s *ViewStorage) CountViews(ctx context.Context, docID int) (int, error) {
 // 4. Список ознакомившихся пользователей
 func (s *ViewStorage) GetViewers(ctx context.Context, docID int) ([]models_v.Viewer, error) {
 	const q = `
-		SELECT u.id, u.first_name, u.last_name, u.photo_path
+		SELECT COALESCE(u.id, 0), COALESCE(u.first_name, 'Удалённый пользователь'), COALESCE(u.last_name, ''), u.photo_path
 		FROM document_views v
-		JOIN users u ON u.id = v.user_id
+		LEFT JOIN users u ON u.id = v.user_id
 		WHERE v.document_id = $1
 		ORDER BY v.viewed_at DESC;
 	`
@@ -1,6 +1,7 @@
 package service_v
 
 import (
+	"cmd/internal/realtime"
 	"cmd/internal/storage"
 	"cmd/internal/views/models_v"
 	"cmd/internal/views/repo_v"
@@ -11,17 +12,32 @@ import (
 type ViewService struct {
 	repo      *repo_v.ViewStorage
 	s3Storage *storage.S3Storage
+	events    realtime.Publisher
 }
 
-func NewViewService(r *repo_v.ViewStorage, s3Storage *storage.S3Storage) *ViewService {
+func NewViewService(r *repo_v.ViewStorage, s3Storage *storage.S3Storage, events realtime.Publisher) *ViewService {
 	return &ViewService{
 		repo:      r,
 		s3Storage: s3Storage,
+		events:    events,
 	}
 }
 
 func (s *ViewService) AddView(ctx context.Context, userID, docID int) error {
-	return s.repo.AddView(ctx, userID, docID)
+	if err := s.repo.AddView(ctx, userID, docID); err != nil {
+		return err
+	}
+
+	// Подписчики документа получают новое число ознакомившихся
+	if s.events != nil {
+		if count, err := s.repo.CountViews(ctx, docID); err == nil {
+			s.events.Publish(ctx, realtime.DocumentEvent(realtime.EventViewAdded, docID, map[string]int{
+				"views":   count,
+				"user_id": userID,
+			}))
+		}
+	}
+	return nil
 }
 
 func (s *ViewService) CountViews(ctx context.Context, docID int) (int, error) {
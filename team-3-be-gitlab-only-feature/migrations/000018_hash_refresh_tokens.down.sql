@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_token_hash;
+
+-- Хэши обратно в токены не превращаются, поэтому старые сессии сбрасываются
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN IF EXISTS revoked_at,
+DROP COLUMN IF EXISTS used_at,
+DROP COLUMN IF EXISTS family_id;
+
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash TYPE VARCHAR(255);
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
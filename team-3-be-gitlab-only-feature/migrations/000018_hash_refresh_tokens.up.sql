@@ -0,0 +1,27 @@
+-- Храним только SHA-256 от refresh токена, сами токены в базу больше не попадают
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+UPDATE refresh_tokens
+SET token_hash = encode(sha256(token_hash::bytea), 'hex');
+
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash TYPE VARCHAR(64);
+
+-- Семейство ротации: все токены, выпущенные из одного входа
+ALTER TABLE refresh_tokens
+ADD COLUMN IF NOT EXISTS family_id VARCHAR(64),
+ADD COLUMN IF NOT EXISTS used_at TIMESTAMP DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP DEFAULT NULL;
+
+UPDATE refresh_tokens SET family_id = md5(id::text || token_hash) WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+-- Один и тот же токен мог быть сохранён несколько раз (старая схема без уникальности):
+-- оставляем последнюю запись, иначе уникальный индекс не создастся
+DELETE FROM refresh_tokens rt
+USING refresh_tokens newer
+WHERE newer.token_hash = rt.token_hash
+  AND newer.id > rt.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
@@ -0,0 +1,4 @@
+-- Версия токенов пользователя: увеличивается при смене роли, удалении и "выйти везде",
+-- access токены со старой версией перестают приниматься сразу
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+CREATE TABLE jwt_signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    retired_at TIMESTAMP DEFAULT NULL,  -- ключ больше не подписывает новые токены
+    expires_at TIMESTAMP DEFAULT NULL   -- после этого момента токены с этим kid не принимаются
+);
+
+CREATE INDEX idx_jwt_signing_keys_created_at ON jwt_signing_keys (created_at);
@@ -0,0 +1,24 @@
+-- Внешние учётные записи (SSO): связь subject провайдера с пользователем портала
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+-- Незавершённые входы через OIDC: state, nonce и PKCE verifier живут до callback
+CREATE TABLE oidc_login_states (
+    state VARCHAR(128) PRIMARY KEY,
+    nonce VARCHAR(128) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    redirect_to TEXT NOT NULL DEFAULT '/',
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_oidc_login_states_expires_at ON oidc_login_states (expires_at);
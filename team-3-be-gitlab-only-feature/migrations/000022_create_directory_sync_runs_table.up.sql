@@ -0,0 +1,11 @@
+-- Отчёты синхронизации пользователей с LDAP / Active Directory
+CREATE TABLE directory_sync_runs (
+    id SERIAL PRIMARY KEY,
+    source VARCHAR(64) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    report JSONB NOT NULL
+);
+
+CREATE INDEX idx_directory_sync_runs_started_at ON directory_sync_runs (started_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS invited_at,
+DROP COLUMN IF EXISTS invited_by,
+DROP COLUMN IF EXISTS department;
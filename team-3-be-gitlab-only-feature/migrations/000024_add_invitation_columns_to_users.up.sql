@@ -0,0 +1,5 @@
+-- Отдел сотрудника и сведения о приглашении (регистрация только по приглашению администратора)
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS department VARCHAR(100) DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS invited_by INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS invited_at TIMESTAMP DEFAULT NULL;
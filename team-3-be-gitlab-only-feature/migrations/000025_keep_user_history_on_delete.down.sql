@@ -0,0 +1,34 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS deactivated_by;
+
+DELETE FROM document_views WHERE user_id IS NULL;
+DELETE FROM document_likes WHERE user_id IS NULL;
+DELETE FROM comments WHERE user_id IS NULL;
+
+ALTER TABLE document_views
+DROP CONSTRAINT IF EXISTS document_views_user_document_key,
+DROP CONSTRAINT IF EXISTS document_views_user_id_fkey,
+ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE document_views
+ADD PRIMARY KEY (user_id, document_id),
+ADD CONSTRAINT document_views_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE document_likes
+DROP CONSTRAINT IF EXISTS document_likes_user_document_key,
+DROP CONSTRAINT IF EXISTS document_likes_user_id_fkey,
+ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE document_likes
+ADD PRIMARY KEY (user_id, document_id),
+ADD CONSTRAINT document_likes_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE comments
+DROP CONSTRAINT IF EXISTS comments_user_id_fkey,
+ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE comments
+ADD CONSTRAINT comments_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,33 @@
+-- При окончательном удалении пользователя его комментарии, лайки и отметки об ознакомлении сохраняются без автора
+ALTER TABLE comments
+DROP CONSTRAINT IF EXISTS comments_user_id_fkey,
+ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE comments
+ADD CONSTRAINT comments_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Первичный ключ не допускает NULL, поэтому заменяем его уникальным ограничением
+ALTER TABLE document_likes
+DROP CONSTRAINT IF EXISTS document_likes_pkey,
+DROP CONSTRAINT IF EXISTS document_likes_user_id_fkey,
+ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE document_likes
+ADD CONSTRAINT document_likes_user_document_key UNIQUE (user_id, document_id),
+ADD CONSTRAINT document_likes_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE document_views
+DROP CONSTRAINT IF EXISTS document_views_pkey,
+DROP CONSTRAINT IF EXISTS document_views_user_id_fkey,
+ALTER COLUMN user_id DROP NOT NULL;
+
+ALTER TABLE document_views
+ADD CONSTRAINT document_views_user_document_key UNIQUE (user_id, document_id),
+ADD CONSTRAINT document_views_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Кто деактивировал пользователя; NULL — деактивирован синхронизацией с каталогом
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS deactivated_by INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL;
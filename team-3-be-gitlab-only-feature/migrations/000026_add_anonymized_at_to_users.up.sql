@@ -0,0 +1,3 @@
+-- Пользователь обезличен по запросу: имя, email и фото удалены, статистика сохранена
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP DEFAULT NULL;
@@ -0,0 +1,9 @@
+-- Запрос смены email: новый адрес применяется только после перехода по ссылке из письма; хранится SHA-256 токена
+CREATE TABLE email_change_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    new_email VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
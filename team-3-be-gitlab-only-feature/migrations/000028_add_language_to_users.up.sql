@@ -0,0 +1,3 @@
+-- Язык писем и интерфейса пользователя (ru, en)
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS language VARCHAR(5) NOT NULL DEFAULT 'ru';
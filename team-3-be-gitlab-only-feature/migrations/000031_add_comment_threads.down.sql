@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS comment_mentions;
+
+DROP INDEX IF EXISTS idx_comments_document_roots;
+DROP INDEX IF EXISTS idx_comments_thread_id;
+
+-- Ответы становятся обычными комментариями
+ALTER TABLE comments
+DROP COLUMN IF EXISTS depth,
+DROP COLUMN IF EXISTS thread_id,
+DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,18 @@
+-- Ответы на комментарии: parent_id — на что ответили, thread_id — корневой комментарий ветки
+-- (NULL у самого корня), depth — уровень вложенности начиная с 0
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+ADD COLUMN IF NOT EXISTS thread_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+ADD COLUMN IF NOT EXISTS depth SMALLINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_thread_id ON comments(thread_id);
+CREATE INDEX IF NOT EXISTS idx_comments_document_roots ON comments(document_id, created_at DESC) WHERE parent_id IS NULL;
+
+-- Упоминания @пользователя в тексте комментария
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_mentions_user_id ON comment_mentions(user_id);
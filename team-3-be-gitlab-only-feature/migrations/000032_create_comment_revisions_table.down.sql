@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS comment_revisions;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,14 @@
+-- Правка комментария: edited_at — время последней правки, прежний текст уходит в comment_revisions
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP DEFAULT NULL;
+
+-- text — текст до правки, edited_by и edited_at — кто и когда его заменил
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    edited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    edited_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_revisions_comment_id ON comment_revisions(comment_id, id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS comment_mutes;
+DROP TABLE IF EXISTS comment_reports;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS hidden_at,
+DROP COLUMN IF EXISTS hidden_by,
+DROP COLUMN IF EXISTS is_hidden;
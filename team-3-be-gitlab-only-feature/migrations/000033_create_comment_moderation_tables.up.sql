@@ -0,0 +1,31 @@
+-- Скрытый модератором комментарий виден как заглушка, если на него есть ответы
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS hidden_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP;
+
+-- Жалобы на комментарии; status: pending — в очереди модерации, dismissed — отклонена, resolved — приняты меры
+CREATE TABLE IF NOT EXISTS comment_reports (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    reporter_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL CHECK (char_length(reason) <= 500),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'dismissed', 'resolved')),
+    action VARCHAR(20),
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (comment_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_reports_pending ON comment_reports(comment_id) WHERE status = 'pending';
+
+-- Запрет комментировать; muted_until NULL — бессрочно
+CREATE TABLE IF NOT EXISTS comment_mutes (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    muted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    muted_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
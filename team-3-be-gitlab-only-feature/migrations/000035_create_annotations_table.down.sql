@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS annotations;
+
+ALTER TABLE documents
+DROP COLUMN IF EXISTS version;
@@ -0,0 +1,24 @@
+-- Версия файла документа: аннотации привязаны к версии, на которой их оставили
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Аннотации PDF: страница и selector — прямоугольник в долях страницы
+-- ({"type":"rect","x":..,"y":..,"width":..,"height":..}) или цитата ({"type":"text","exact":..,"prefix":..,"suffix":..})
+CREATE TABLE IF NOT EXISTS annotations (
+    id SERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    document_version INTEGER NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    page INTEGER NOT NULL CHECK (page >= 1),
+    selector JSONB NOT NULL CHECK (selector->>'type' IN ('rect', 'text')),
+    color VARCHAR(7) NOT NULL DEFAULT '#FFD400',
+    text TEXT NOT NULL DEFAULT '' CHECK (char_length(text) <= 2000),
+    status VARCHAR(10) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_annotations_document ON annotations(document_id, document_version, page) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS document_status_history;
+DROP TABLE IF EXISTS document_approvers;
+DROP INDEX IF EXISTS idx_documents_status;
+ALTER TABLE documents DROP COLUMN IF EXISTS status;
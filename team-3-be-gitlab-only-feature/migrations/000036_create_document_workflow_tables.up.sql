@@ -0,0 +1,38 @@
+-- Согласование документов перед публикацией: черновик → на согласовании → одобрен или отклонён.
+-- Уже опубликованные документы считаются одобренными
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'approved'
+        CHECK (status IN ('draft', 'in_review', 'approved', 'rejected'));
+
+CREATE INDEX IF NOT EXISTS idx_documents_status ON documents(status) WHERE deleted_at IS NULL;
+
+-- Согласующие текущего круга: конкретный пользователь или любой пользователь роли
+CREATE TABLE IF NOT EXISTS document_approvers (
+    id SERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER REFERENCES roles(id) ON DELETE CASCADE,
+    decision VARCHAR(16) CHECK (decision IN ('approved', 'rejected')),
+    decided_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    comment TEXT NOT NULL DEFAULT '',
+    CHECK ((user_id IS NULL) <> (role_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_approvers_document_id ON document_approvers(document_id);
+CREATE INDEX IF NOT EXISTS idx_document_approvers_user_id ON document_approvers(user_id);
+CREATE INDEX IF NOT EXISTS idx_document_approvers_role_id ON document_approvers(role_id);
+
+-- Журнал переходов и решений согласующих
+CREATE TABLE IF NOT EXISTS document_status_history (
+    id SERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    from_status VARCHAR(16) NOT NULL,
+    to_status VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_status_history_document_id ON document_status_history(document_id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_documents_expires_at;
+DROP INDEX IF EXISTS idx_documents_publish_pending;
+
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_schedule_check;
+
+ALTER TABLE documents
+DROP COLUMN IF EXISTS archived_at,
+DROP COLUMN IF EXISTS published_at,
+DROP COLUMN IF EXISTS expires_at,
+DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,20 @@
+-- Отложенная публикация и срок действия документов.
+-- published_at — когда разосланы уведомления о публикации (планировщик не рассылает дважды),
+-- archived_at — когда истёкший документ ушёл в архив (виден только администраторам)
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+ALTER TABLE documents
+    ADD CONSTRAINT documents_schedule_check
+        CHECK (publish_at IS NULL OR expires_at IS NULL OR expires_at > publish_at);
+
+-- Уже опубликованные документы повторно не объявляются
+UPDATE documents SET published_at = created_at WHERE status = 'approved';
+
+CREATE INDEX IF NOT EXISTS idx_documents_publish_pending ON documents(publish_at)
+    WHERE published_at IS NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_documents_expires_at ON documents(expires_at)
+    WHERE archived_at IS NULL AND deleted_at IS NULL;
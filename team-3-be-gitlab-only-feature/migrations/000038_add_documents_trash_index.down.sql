@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_documents_deleted_at;
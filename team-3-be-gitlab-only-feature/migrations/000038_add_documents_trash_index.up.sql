@@ -0,0 +1,2 @@
+-- Корзина: поиск удалённых документов и кандидатов на окончательное удаление
+CREATE INDEX IF NOT EXISTS idx_documents_deleted_at ON documents(deleted_at) WHERE deleted_at IS NOT NULL;
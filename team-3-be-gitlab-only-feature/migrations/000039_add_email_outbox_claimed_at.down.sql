@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_email_outbox_claimed_at;
+ALTER TABLE email_outbox DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,4 @@
+-- Время последней попытки отправки: лимит писем в минуту считается по всем экземплярам сервиса
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_claimed_at ON email_outbox(claimed_at) WHERE claimed_at IS NOT NULL;